@@ -31,7 +31,7 @@ func GetLoggerInjectionUnaryInterceptor(
 func ExtractLogger(ctx context.Context) *slog.Logger {
 	logger, ok := ctx.Value(slogContextKey{}).(*slog.Logger)
 	if !ok {
-		return slog.New(new(disabledLoggerHandler))
+		return NewDisabledLogger()
 	}
 
 	return logger
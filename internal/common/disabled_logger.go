@@ -7,6 +7,10 @@ import (
 
 var _ slog.Handler = (*disabledLoggerHandler)(nil)
 
+func NewDisabledLogger() *slog.Logger {
+	return slog.New(new(disabledLoggerHandler))
+}
+
 type disabledLoggerHandler struct{}
 
 func (*disabledLoggerHandler) Enabled(_ context.Context, _ slog.Level) bool {
@@ -0,0 +1,352 @@
+// Package filter implements a subset of the AIP-160 filtering language (https://google.aip.dev/160):
+// comparisons (=, !=, <, <=, >, >=, :), AND, OR, NOT and parentheses,
+// with OR binding tighter than AND and juxtaposition meaning AND.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid filter")
+
+// Env resolves a field path, such as "email" or "caller.id", to its value.
+// Supported values are strings, booleans, integers, floats, time.Time and []string,
+// which only supports the ":" (has) operator.
+type Env interface {
+	Lookup(field string) (any, bool)
+}
+
+type Fields map[string]any
+
+func (f Fields) Lookup(field string) (any, bool) {
+	value, ok := f[field]
+
+	return value, ok
+}
+
+type Expr interface {
+	Eval(env Env) (bool, error)
+	// Fields returns the field paths referenced by the expression.
+	Fields() []string
+}
+
+type ParseOption func(*parser)
+
+// WithFieldReferences makes unquoted values that are field paths with a dot, such as caller.id,
+// refer to the value of that field instead of being literals, so that fields can be compared with each other.
+func WithFieldReferences() ParseOption {
+	return func(p *parser) {
+		p.references = true
+	}
+}
+
+// Parse parses the filter. An empty filter matches everything.
+func Parse(source string, opts ...ParseOption) (Expr, error) { //nolint:ireturn
+	p := &parser{
+		tokens:     nil,
+		pos:        0,
+		references: false,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p.tokens = tokens
+
+	if p.peek().kind == tokenEOF {
+		return matchAll{}, nil
+	}
+
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.peek(); token.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalid, token.text, token.pos)
+	}
+
+	return expr, nil
+}
+
+type matchAll struct{}
+
+func (matchAll) Eval(Env) (bool, error) {
+	return true, nil
+}
+
+func (matchAll) Fields() []string {
+	return nil
+}
+
+type andExpr struct {
+	operands []Expr
+}
+
+func (e *andExpr) Eval(env Env) (bool, error) {
+	for _, operand := range e.operands {
+		ok, err := operand.Eval(env)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (e *andExpr) Fields() []string {
+	return collectFields(e.operands)
+}
+
+type orExpr struct {
+	operands []Expr
+}
+
+func (e *orExpr) Eval(env Env) (bool, error) {
+	for _, operand := range e.operands {
+		ok, err := operand.Eval(env)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (e *orExpr) Fields() []string {
+	return collectFields(e.operands)
+}
+
+type notExpr struct {
+	operand Expr
+}
+
+func (e *notExpr) Eval(env Env) (bool, error) {
+	ok, err := e.operand.Eval(env)
+
+	return !ok && err == nil, err
+}
+
+func (e *notExpr) Fields() []string {
+	return e.operand.Fields()
+}
+
+type comparison struct {
+	field    string
+	operator string
+	value    string
+	// quoted values are always compared as strings.
+	quoted bool
+	// reference values are field paths, resolved when the comparison is evaluated.
+	reference bool
+	// exact values are never wildcards.
+	exact bool
+}
+
+func (c *comparison) Fields() []string {
+	if c.reference {
+		return []string{c.field, c.value}
+	}
+
+	return []string{c.field}
+}
+
+func (c *comparison) Eval(env Env) (bool, error) {
+	if !c.reference {
+		return c.compare(env)
+	}
+
+	referenced, ok := env.Lookup(c.value)
+	if !ok {
+		return false, fmt.Errorf("%w: unknown field %q", ErrInvalid, c.value)
+	}
+
+	value, quoted, err := formatValue(referenced)
+	if err != nil {
+		return false, fmt.Errorf("%w: field %q cannot be compared: %w", ErrInvalid, c.value, err)
+	}
+
+	resolved := &comparison{
+		field:     c.field,
+		operator:  c.operator,
+		value:     value,
+		quoted:    quoted,
+		reference: false,
+		exact:     true,
+	}
+
+	return resolved.compare(env)
+}
+
+func (c *comparison) compare(env Env) (bool, error) {
+	actual, ok := env.Lookup(c.field)
+	if !ok {
+		return false, fmt.Errorf("%w: unknown field %q", ErrInvalid, c.field)
+	}
+
+	switch actual := actual.(type) {
+	case string:
+		return c.compareString(actual)
+	case bool:
+		return c.compareBool(actual)
+	case int:
+		return c.compareFloat(float64(actual))
+	case int64:
+		return c.compareFloat(float64(actual))
+	case uint64:
+		return c.compareFloat(float64(actual))
+	case float64:
+		return c.compareFloat(actual)
+	case time.Time:
+		return c.compareTime(actual)
+	case []string:
+		return c.compareStrings(actual)
+	case fmt.Stringer:
+		return c.compareString(actual.String())
+	default:
+		return false, fmt.Errorf("%w: field %q of type %T cannot be filtered", ErrInvalid, c.field, actual)
+	}
+}
+
+func (c *comparison) compareString(actual string) (bool, error) {
+	switch {
+	case c.operator == ":":
+		return strings.Contains(actual, c.value), nil
+	case c.operator == "=" && !c.exact:
+		return matchWildcard(actual, c.value), nil
+	case c.operator == "!=" && !c.exact:
+		return !matchWildcard(actual, c.value), nil
+	default:
+		return compareOrdered(strings.Compare(actual, c.value), c.operator), nil
+	}
+}
+
+func (c *comparison) compareStrings(actual []string) (bool, error) {
+	if c.operator != ":" {
+		return false, fmt.Errorf("%w: repeated field %q only supports the \":\" operator", ErrInvalid, c.field)
+	}
+
+	return slices.Contains(actual, c.value), nil
+}
+
+func (c *comparison) compareBool(actual bool) (bool, error) {
+	expected, err := strconv.ParseBool(c.value)
+	if err != nil || c.quoted {
+		return false, fmt.Errorf("%w: field %q must be compared with true or false", ErrInvalid, c.field)
+	}
+
+	switch c.operator {
+	case "=", ":":
+		return actual == expected, nil
+	case "!=":
+		return actual != expected, nil
+	default:
+		return false, fmt.Errorf("%w: operator %q is not supported for field %q", ErrInvalid, c.operator, c.field)
+	}
+}
+
+func (c *comparison) compareFloat(actual float64) (bool, error) {
+	expected, err := strconv.ParseFloat(c.value, 64)
+	if err != nil {
+		return false, fmt.Errorf("%w: field %q must be compared with a number", ErrInvalid, c.field)
+	}
+
+	switch {
+	case actual < expected:
+		return compareOrdered(-1, c.operator), nil
+	case actual > expected:
+		return compareOrdered(1, c.operator), nil
+	default:
+		return compareOrdered(0, c.operator), nil
+	}
+}
+
+func (c *comparison) compareTime(actual time.Time) (bool, error) {
+	expected, err := time.Parse(time.RFC3339Nano, c.value)
+	if err != nil {
+		return false, fmt.Errorf("%w: field %q must be compared with an RFC 3339 timestamp", ErrInvalid, c.field)
+	}
+
+	return compareOrdered(actual.Compare(expected), c.operator), nil
+}
+
+// formatValue formats the value of a referenced field as a literal, reporting whether it must be quoted.
+func formatValue(value any) (string, bool, error) {
+	switch value := value.(type) {
+	case string:
+		return value, true, nil
+	case bool:
+		return strconv.FormatBool(value), false, nil
+	case int:
+		return strconv.Itoa(value), false, nil
+	case int64:
+		return strconv.FormatInt(value, 10), false, nil //nolint:gomnd
+	case uint64:
+		return strconv.FormatUint(value, 10), false, nil //nolint:gomnd
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), false, nil //nolint:gomnd
+	case time.Time:
+		return value.Format(time.RFC3339Nano), true, nil
+	case fmt.Stringer:
+		return value.String(), true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func compareOrdered(cmp int, operator string) bool {
+	switch operator {
+	case "=", ":":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// matchWildcard supports a leading and/or trailing "*" in the expected value, as AIP-160 allows for strings.
+func matchWildcard(actual string, pattern string) bool {
+	prefix := strings.HasSuffix(pattern, "*")
+	suffix := strings.HasPrefix(pattern, "*")
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+
+	switch {
+	case prefix && suffix:
+		return strings.Contains(actual, pattern)
+	case prefix:
+		return strings.HasPrefix(actual, pattern)
+	case suffix:
+		return strings.HasSuffix(actual, pattern)
+	default:
+		return actual == pattern
+	}
+}
+
+func collectFields(operands []Expr) []string {
+	fields := make([]string, 0, len(operands))
+	for _, operand := range operands {
+		fields = append(fields, operand.Fields()...)
+	}
+
+	return fields
+}
@@ -0,0 +1,177 @@
+package filter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/filter"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	env := filter.Fields{
+		"username":    "alice",
+		"email":       "alice@corp.com",
+		"admin":       true,
+		"logins":      42,
+		"create_time": time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: ``, expected: true},
+		{filter: `admin = true`, expected: true},
+		{filter: `admin = false`, expected: false},
+		{filter: `admin != false`, expected: true},
+		{filter: `email:"@corp.com"`, expected: true},
+		{filter: `email:"@gmail.com"`, expected: false},
+		{filter: `admin = true AND email:"@corp.com"`, expected: true},
+		{filter: `admin = true email:"@gmail.com"`, expected: false},
+		{filter: `username = bob OR username = alice`, expected: true},
+		{filter: `admin = false AND username = bob OR username = alice`, expected: false},
+		{filter: `(admin = false AND username = bob) OR username = alice`, expected: true},
+		{filter: `NOT admin = true`, expected: false},
+		{filter: `NOT (username = bob)`, expected: true},
+		{filter: `username = ali*`, expected: true},
+		{filter: `email = *@corp.com`, expected: true},
+		{filter: `username = "ali*"`, expected: true},
+		{filter: `username > aaa AND username < bob`, expected: true},
+		{filter: `logins >= 42 AND logins < 43.5`, expected: true},
+		{filter: `create_time > "2023-01-01T00:00:00Z"`, expected: true},
+		{filter: `create_time <= "2023-01-01T00:00:00Z"`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := filter.Parse(tc.filter)
+			require.NoError(t, err)
+
+			actual, err := expr.Eval(env)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, source := range []string{
+		`admin`,
+		`admin =`,
+		`= true`,
+		`(admin = true`,
+		`admin = true)`,
+		`email:"unterminated`,
+		`admin ! true`,
+		`AND admin = true`,
+	} {
+		source := source
+		t.Run(source, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := filter.Parse(source)
+			assert.ErrorIs(t, err, filter.ErrInvalid)
+		})
+	}
+}
+
+func TestEval_Invalid(t *testing.T) {
+	t.Parallel()
+
+	env := filter.Fields{
+		"admin":  true,
+		"logins": 42,
+	}
+
+	for _, source := range []string{
+		`unknown = 1`,
+		`admin = maybe`,
+		`admin > true`,
+		`logins = many`,
+	} {
+		source := source
+		t.Run(source, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := filter.Parse(source)
+			require.NoError(t, err)
+
+			_, err = expr.Eval(env)
+			assert.ErrorIs(t, err, filter.ErrInvalid)
+		})
+	}
+}
+
+func TestParse_FieldReferences(t *testing.T) {
+	t.Parallel()
+
+	env := filter.Fields{
+		"caller.id":    "b5f3",
+		"caller.roles": []string{"support", "superuser"},
+		"caller.admin": true,
+		"request.id":   "b5f3",
+		"request.name": "b5*",
+		"request.size": 10,
+	}
+
+	testCases := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: `request.id = caller.id`, expected: true},
+		{filter: `request.id != caller.id`, expected: false},
+		{filter: `request.name = caller.id`, expected: false},
+		{filter: `request.id = request.name`, expected: false},
+		{filter: `request.id = "caller.id"`, expected: false},
+		{filter: `caller.admin = caller.admin`, expected: true},
+		{filter: `request.size >= 9.5`, expected: true},
+		{filter: `caller.roles:support`, expected: true},
+		{filter: `caller.roles:"admin"`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := filter.Parse(tc.filter, filter.WithFieldReferences())
+			require.NoError(t, err)
+
+			actual, err := expr.Eval(env)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	expr, err := filter.Parse(`request.id = caller.id`)
+	require.NoError(t, err)
+
+	actual, err := expr.Eval(env)
+	require.NoError(t, err)
+	assert.False(t, actual, "values must only be references with the option")
+
+	expr, err = filter.Parse(`caller.roles = support`, filter.WithFieldReferences())
+	require.NoError(t, err)
+
+	_, err = expr.Eval(env)
+	assert.ErrorIs(t, err, filter.ErrInvalid)
+}
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	expr, err := filter.Parse(`admin = true AND (email:"@corp.com" OR NOT username = bob)`)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"admin", "email", "username"}, expr.Fields())
+}
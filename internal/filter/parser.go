@@ -0,0 +1,243 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", pos: i})
+			i++
+		case r == '"':
+			text, end, err := readString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end
+		case strings.ContainsRune("=!<>:", r):
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != ':' {
+				operator += "="
+			}
+
+			if operator == "!" {
+				return nil, fmt.Errorf("%w: unexpected \"!\" at position %d", ErrInvalid, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: i})
+			i += len(operator)
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()\"=!<>:", runes[i]) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of filter", pos: len(runes)}), nil
+}
+
+func readString(runes []rune, start int) (string, int, error) {
+	var builder strings.Builder
+
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 == len(runes) {
+				return "", 0, fmt.Errorf("%w: unterminated string at position %d", ErrInvalid, start)
+			}
+
+			i++
+			builder.WriteRune(runes[i])
+		case '"':
+			return builder.String(), i + 1, nil
+		default:
+			builder.WriteRune(runes[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: unterminated string at position %d", ErrInvalid, start)
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	references bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+
+	return t.kind == tokenWord && t.text == keyword
+}
+
+// parseExpression parses a sequence of factors joined with AND or juxtaposition.
+func (p *parser) parseExpression() (Expr, error) { //nolint:ireturn
+	operands := make([]Expr, 0, 1)
+
+	for {
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if p.isKeyword("AND") {
+			p.next()
+
+			continue
+		}
+
+		if t := p.peek(); t.kind == tokenEOF || t.kind == tokenRightParen {
+			break
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return &andExpr{operands: operands}, nil
+}
+
+// parseFactor parses terms joined with OR, which binds tighter than AND in AIP-160.
+func (p *parser) parseFactor() (Expr, error) { //nolint:ireturn
+	operands := make([]Expr, 0, 1)
+
+	for {
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if !p.isKeyword("OR") {
+			break
+		}
+
+		p.next()
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return &orExpr{operands: operands}, nil
+}
+
+func (p *parser) parseTerm() (Expr, error) { //nolint:ireturn
+	if p.isKeyword("NOT") {
+		p.next()
+
+		operand, err := p.parseSimple()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notExpr{operand: operand}, nil
+	}
+
+	return p.parseSimple()
+}
+
+func (p *parser) parseSimple() (Expr, error) { //nolint:ireturn
+	t := p.next()
+
+	switch t.kind {
+	case tokenLeftParen:
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, fmt.Errorf("%w: expected \")\" at position %d", ErrInvalid, closing.pos)
+		}
+
+		return expr, nil
+	case tokenWord:
+		return p.parseComparison(t)
+	case tokenEOF, tokenString, tokenOperator, tokenRightParen:
+	}
+
+	return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalid, t.text, t.pos)
+}
+
+func (p *parser) parseComparison(field token) (Expr, error) { //nolint:ireturn
+	operator := p.next()
+	if operator.kind != tokenOperator {
+		return nil, fmt.Errorf(
+			"%w: expected a comparison operator after %q at position %d",
+			ErrInvalid, field.text, operator.pos,
+		)
+	}
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("%w: expected a value at position %d", ErrInvalid, value.pos)
+	}
+
+	return &comparison{
+		field:     field.text,
+		operator:  operator.text,
+		value:     value.text,
+		quoted:    value.kind == tokenString,
+		reference: p.references && value.kind == tokenWord && isFieldPath(value.text),
+		exact:     false,
+	}, nil
+}
+
+// isFieldPath reports whether the unquoted value is a field path, such as caller.id, rather than a literal.
+func isFieldPath(value string) bool {
+	return strings.Contains(value, ".") && unicode.IsLetter([]rune(value)[0])
+}
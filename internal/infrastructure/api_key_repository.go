@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// SaveAPIKey creates the key. It fails with common.ErrNotFound if the owner of the key does not exist.
+func (r *Repository) SaveAPIKey(key *models.APIKey) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if _, err := r.GetByID(key.UserID); err != nil {
+		return err
+	}
+
+	r.stateMu.RLock()
+	_, idExists := r.apiKeys[key.ID]
+	_, hashExists := r.apiKeyHashes[string(key.Hash)]
+	r.stateMu.RUnlock()
+
+	if idExists || hashExists {
+		return fmt.Errorf("%w: api key %q already exists", common.ErrAlreadyExists, key.ID)
+	}
+
+	stored := *key
+	stored.Scopes = slices.Clone(key.Scopes)
+
+	return r.commit(&walRecord{
+		Op:     walOpSaveAPIKey,
+		APIKey: &stored,
+	})
+}
+
+func (r *Repository) GetAPIKeyByHash(hash []byte) (*models.APIKey, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if id, ok := r.apiKeyHashes[string(hash)]; ok {
+		return r.apiKeys[id], nil
+	}
+
+	return nil, fmt.Errorf("%w: api key not found", common.ErrNotFound)
+}
+
+// ListAPIKeys returns the keys of the user, oldest first.
+func (r *Repository) ListAPIKeys(userID uuid.UUID) ([]*models.APIKey, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	keys := make([]*models.APIKey, 0)
+	for _, key := range r.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b *models.APIKey) int {
+		if cmp := a.CreatedAt.Compare(b.CreatedAt); cmp != 0 {
+			return cmp
+		}
+
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+
+	return keys, nil
+}
+
+func (r *Repository) DeleteAPIKey(id uuid.UUID) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	_, ok := r.apiKeys[id]
+	r.stateMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: api key %q not found", common.ErrNotFound, id)
+	}
+
+	return r.commit(&walRecord{
+		Op:       walOpDeleteAPIKey,
+		APIKeyID: &id,
+	})
+}
+
+func (r *Repository) getAllAPIKeys() []*models.APIKey {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	keys := make([]*models.APIKey, 0, len(r.apiKeys))
+	for _, key := range r.apiKeys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (r *Repository) storeAPIKey(key *models.APIKey) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.apiKeys[key.ID] = key
+	r.apiKeyHashes[string(key.Hash)] = key.ID
+}
+
+func (r *Repository) deleteAPIKey(id uuid.UUID) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.deleteAPIKeyLocked(id)
+}
+
+func (r *Repository) deleteAPIKeyLocked(id uuid.UUID) {
+	key, ok := r.apiKeys[id]
+	if !ok {
+		return
+	}
+
+	delete(r.apiKeyHashes, string(key.Hash))
+	delete(r.apiKeys, id)
+}
@@ -0,0 +1,210 @@
+package infrastructure
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// SaveGroup creates the group if its version is zero, or replaces it if its version matches the stored one,
+// and assigns the new version to the group.
+// It fails with common.ErrConflict on a version mismatch
+// and with common.ErrAlreadyExists if another group has the same name.
+func (r *Repository) SaveGroup(group *models.Group) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	var stored uint64
+	if existing, ok := r.groups[group.ID]; ok {
+		stored = existing.Version
+	}
+	id, nameTaken := r.groupNames[groupNameKey(group)]
+	r.stateMu.RUnlock()
+
+	if group.Version != stored {
+		return fmt.Errorf(
+			"%w: group with id %q has version %d, expected %d",
+			common.ErrConflict, group.ID, stored, group.Version,
+		)
+	}
+
+	if nameTaken && id != group.ID {
+		return fmt.Errorf(
+			"%w: group with name %q already exists in organization %q",
+			common.ErrAlreadyExists, group.Name, group.Organization,
+		)
+	}
+
+	saved := cloneGroup(group)
+	saved.Version++
+
+	err := r.commit(&walRecord{
+		Op:    walOpSaveGroup,
+		Group: saved,
+	})
+	if err != nil {
+		return err
+	}
+
+	group.Version = saved.Version
+
+	return nil
+}
+
+func (r *Repository) GetGroup(id uuid.UUID) (*models.Group, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if group, ok := r.groups[id]; ok {
+		return group, nil
+	}
+
+	return nil, fmt.Errorf("%w: group with id %q not found", common.ErrNotFound, id)
+}
+
+// ListGroups returns all groups ordered by name.
+func (r *Repository) ListGroups() ([]*models.Group, error) {
+	groups := r.getAllGroups()
+
+	sortGroups(groups)
+
+	return groups, nil
+}
+
+// GroupsOfMember returns the groups the user or group with the ID is a direct member of, ordered by name.
+func (r *Repository) GroupsOfMember(memberID uuid.UUID) ([]*models.Group, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	groups := make([]*models.Group, 0, len(r.memberGroups[memberID]))
+	for id := range r.memberGroups[memberID] {
+		groups = append(groups, r.groups[id])
+	}
+
+	sortGroups(groups)
+
+	return groups, nil
+}
+
+// DeleteGroup removes the group and removes it from the groups it is a member of.
+func (r *Repository) DeleteGroup(id uuid.UUID) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if _, err := r.GetGroup(id); err != nil {
+		return err
+	}
+
+	return r.commit(&walRecord{
+		Op:      walOpDeleteGroup,
+		GroupID: &id,
+	})
+}
+
+func (r *Repository) getAllGroups() []*models.Group {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	groups := make([]*models.Group, 0, len(r.groups))
+	for _, group := range r.groups {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func (r *Repository) storeGroup(group *models.Group) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.storeGroupLocked(group)
+}
+
+func (r *Repository) storeGroupLocked(group *models.Group) {
+	if existing, ok := r.groups[group.ID]; ok {
+		delete(r.groupNames, groupNameKey(existing))
+		r.unindexMembersLocked(existing)
+	}
+
+	r.groups[group.ID] = group
+	r.groupNames[groupNameKey(group)] = group.ID
+
+	for _, memberID := range groupMemberIDs(group) {
+		groups, ok := r.memberGroups[memberID]
+		if !ok {
+			groups = make(map[uuid.UUID]struct{})
+			r.memberGroups[memberID] = groups
+		}
+
+		groups[group.ID] = struct{}{}
+	}
+}
+
+func (r *Repository) deleteGroup(id uuid.UUID) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	existing, ok := r.groups[id]
+	if !ok {
+		return
+	}
+
+	delete(r.groups, id)
+	delete(r.groupNames, groupNameKey(existing))
+	r.unindexMembersLocked(existing)
+	r.removeGroupMemberLocked(id)
+}
+
+// removeGroupMemberLocked removes the user or group with the ID from all groups it is a direct member of.
+// Stored groups are never mutated in place, so the groups are replaced with changed copies.
+func (r *Repository) removeGroupMemberLocked(memberID uuid.UUID) {
+	for groupID := range r.memberGroups[memberID] {
+		group := cloneGroup(r.groups[groupID])
+		group.UserIDs = slices.DeleteFunc(group.UserIDs, func(id uuid.UUID) bool { return id == memberID })
+		group.GroupIDs = slices.DeleteFunc(group.GroupIDs, func(id uuid.UUID) bool { return id == memberID })
+		group.Version++
+
+		r.storeGroupLocked(group)
+	}
+
+	delete(r.memberGroups, memberID)
+}
+
+func (r *Repository) unindexMembersLocked(group *models.Group) {
+	for _, memberID := range groupMemberIDs(group) {
+		delete(r.memberGroups[memberID], group.ID)
+
+		if len(r.memberGroups[memberID]) == 0 {
+			delete(r.memberGroups, memberID)
+		}
+	}
+}
+
+func groupNameKey(group *models.Group) orgKey {
+	return orgKey{organization: group.Organization, name: group.Name}
+}
+
+func groupMemberIDs(group *models.Group) []uuid.UUID {
+	return append(slices.Clone(group.UserIDs), group.GroupIDs...)
+}
+
+func cloneGroup(group *models.Group) *models.Group {
+	cloned := *group
+	cloned.Roles = slices.Clone(group.Roles)
+	cloned.UserIDs = slices.Clone(group.UserIDs)
+	cloned.GroupIDs = slices.Clone(group.GroupIDs)
+
+	return &cloned
+}
+
+func sortGroups(groups []*models.Group) {
+	slices.SortFunc(groups, func(a, b *models.Group) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
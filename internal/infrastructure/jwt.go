@@ -0,0 +1,152 @@
+package infrastructure
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	// PrivateKey is either an ed25519.PrivateKey, signing with EdDSA, or an *rsa.PrivateKey, signing with RS256.
+	PrivateKey crypto.Signer
+}
+
+// JWTIssuer issues and verifies signed JWT access tokens.
+type JWTIssuer struct {
+	config    JWTConfig
+	method    jwt.SigningMethod
+	publicKey crypto.PublicKey
+	parser    *jwt.Parser
+}
+
+func NewJWTIssuer(config JWTConfig) (*JWTIssuer, error) {
+	var method jwt.SigningMethod
+
+	switch config.PrivateKey.(type) {
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", config.PrivateKey)
+	}
+
+	return &JWTIssuer{
+		config:    config,
+		method:    method,
+		publicKey: config.PrivateKey.Public(),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{method.Alg()}),
+			jwt.WithIssuer(config.Issuer),
+			jwt.WithAudience(config.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+	}, nil
+}
+
+// accessTokenClaims are the registered claims and the time the password of the subject was last changed.
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	// PasswordChangedAt is in Unix nanoseconds, since the precision of registered dates is a second,
+	// and is omitted if the password has not been changed since the creation of the user.
+	PasswordChangedAt int64 `json:"pwd_changed_at,omitempty"`
+}
+
+func (i *JWTIssuer) Issue(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(i.config.TTL)
+
+	claims := accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.config.Issuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{i.config.Audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+		PasswordChangedAt: 0,
+	}
+
+	if !user.PasswordChangedAt.IsZero() {
+		claims.PasswordChangedAt = user.PasswordChangedAt.UnixNano()
+	}
+
+	token, err := jwt.NewWithClaims(i.method, claims).SignedString(i.config.PrivateKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// Verify checks the signature and the registered claims of the token and returns the ID of its subject
+// and the time the password of the subject was last changed when the token was issued, which is zero if it never was.
+func (i *JWTIssuer) Verify(token string) (uuid.UUID, time.Time, error) {
+	claims := new(accessTokenClaims)
+
+	_, err := i.parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return i.publicKey, nil
+	})
+	if err != nil {
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("%w: %w", common.ErrInvalidToken, err)
+	}
+
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("%w: invalid subject %q", common.ErrInvalidToken, claims.Subject)
+	}
+
+	var passwordChangedAt time.Time
+	if claims.PasswordChangedAt != 0 {
+		passwordChangedAt = time.Unix(0, claims.PasswordChangedAt).UTC()
+	}
+
+	return id, passwordChangedAt, nil
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS #8 or PKCS #1 private key.
+func LoadPrivateKey(path string) (crypto.Signer, error) { //nolint:ireturn
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key %q: %w", path, err)
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("private key %q is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %q: %w", path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,151 @@
+package infrastructure_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+)
+
+func TestJWTIssuer(t *testing.T) {
+	t.Parallel()
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{
+		"EdDSA": ed25519Key,
+		"RS256": rsaKey,
+	} {
+		key := key
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := newTestJWTIssuer(t, "issuer", "audience", time.Minute, key)
+			user := createTestUser(t)
+
+			token, expiresAt, err := issuer.Issue(user)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+			id, passwordChangedAt, err := issuer.Verify(token)
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, id)
+			assert.True(t, passwordChangedAt.IsZero())
+
+			user.PasswordChangedAt = time.Now().UTC()
+
+			token, _, err = issuer.Issue(user)
+			require.NoError(t, err)
+
+			_, passwordChangedAt, err = issuer.Verify(token)
+			assert.NoError(t, err)
+			assert.True(t, user.PasswordChangedAt.Equal(passwordChangedAt), "the time is kept to the nanosecond")
+		})
+	}
+}
+
+func TestJWTIssuer_Verify(t *testing.T) {
+	t.Parallel()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, anotherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	verifier := newTestJWTIssuer(t, "issuer", "audience", time.Minute, key)
+
+	testCases := []struct {
+		name   string
+		issuer *infrastructure.JWTIssuer
+		tamper func(token string) string
+	}{
+		{
+			name:   "expired",
+			issuer: newTestJWTIssuer(t, "issuer", "audience", -time.Minute, key),
+			tamper: nil,
+		},
+		{
+			name:   "another issuer",
+			issuer: newTestJWTIssuer(t, "another", "audience", time.Minute, key),
+			tamper: nil,
+		},
+		{
+			name:   "another audience",
+			issuer: newTestJWTIssuer(t, "issuer", "another", time.Minute, key),
+			tamper: nil,
+		},
+		{
+			name:   "another key",
+			issuer: newTestJWTIssuer(t, "issuer", "audience", time.Minute, anotherKey),
+			tamper: nil,
+		},
+		{
+			name:   "tampered payload",
+			issuer: verifier,
+			tamper: func(token string) string {
+				parts := strings.Split(token, ".")
+				parts[1] = "eyJzdWIiOiJhZG1pbiJ9"
+
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name:   "garbage",
+			issuer: verifier,
+			tamper: func(string) string {
+				return "garbage"
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			token, _, err := tc.issuer.Issue(createTestUser(t))
+			require.NoError(t, err)
+
+			if tc.tamper != nil {
+				token = tc.tamper(token)
+			}
+
+			_, _, err = verifier.Verify(token)
+			assert.ErrorIs(t, err, common.ErrInvalidToken)
+		})
+	}
+}
+
+func newTestJWTIssuer(
+	t *testing.T,
+	issuer string,
+	audience string,
+	ttl time.Duration,
+	key crypto.Signer,
+) *infrastructure.JWTIssuer {
+	t.Helper()
+
+	jwtIssuer, err := infrastructure.NewJWTIssuer(infrastructure.JWTConfig{
+		Issuer:     issuer,
+		Audience:   audience,
+		TTL:        ttl,
+		PrivateKey: key,
+	})
+	require.NoError(t, err)
+
+	return jwtIssuer
+}
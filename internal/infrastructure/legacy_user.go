@@ -0,0 +1,107 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// legacyUser holds the fields users were stored with before roles replaced the admin flag.
+type legacyUser struct {
+	Admin bool `json:"Admin"`
+}
+
+// migrate grants the superuser role to the users that were stored as admins.
+func (u *legacyUser) migrate(user *models.User) {
+	if u.Admin && !slices.Contains(user.Roles, models.SuperuserRole) {
+		user.Roles = append(slices.Clone(user.Roles), models.SuperuserRole)
+	}
+}
+
+func (r *walRecord) UnmarshalJSON(data []byte) error {
+	type plainRecord walRecord
+
+	if err := json.Unmarshal(data, (*plainRecord)(r)); err != nil {
+		return fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+
+	if r.Group != nil {
+		migrateGroupOrganization(r.Group)
+	}
+
+	migrateTupleOrganizations(r.TupleWrites)
+	migrateTupleOrganizations(r.TupleDeletes)
+
+	if r.User == nil {
+		return nil
+	}
+
+	var legacy struct {
+		User legacyUser `json:"user"`
+	}
+
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to unmarshal legacy user: %w", err)
+	}
+
+	legacy.User.migrate(r.User)
+	migrateUserOrganization(r.User)
+
+	return nil
+}
+
+func (s *snapshot) UnmarshalJSON(data []byte) error {
+	type plainSnapshot snapshot
+
+	if err := json.Unmarshal(data, (*plainSnapshot)(s)); err != nil {
+		return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	var legacy struct {
+		Users []legacyUser `json:"users"`
+	}
+
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to unmarshal legacy users: %w", err)
+	}
+
+	for i, user := range s.Users {
+		if i < len(legacy.Users) && user != nil {
+			legacy.Users[i].migrate(user)
+			migrateUserOrganization(user)
+		}
+	}
+
+	for _, group := range s.Groups {
+		if group != nil {
+			migrateGroupOrganization(group)
+		}
+	}
+
+	migrateTupleOrganizations(s.Tuples)
+
+	return nil
+}
+
+// migrateUserOrganization moves the users stored before organizations were introduced to the default organization.
+func migrateUserOrganization(user *models.User) {
+	if user.Organization == "" {
+		user.Organization = models.DefaultOrganization
+	}
+}
+
+func migrateGroupOrganization(group *models.Group) {
+	if group.Organization == "" {
+		group.Organization = models.DefaultOrganization
+	}
+}
+
+func migrateTupleOrganizations(tuples []*models.Tuple) {
+	for _, tuple := range tuples {
+		if tuple != nil && tuple.Organization == "" {
+			tuple.Organization = models.DefaultOrganization
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// MemoryOutbox keeps sent mails in memory instead of delivering them, which is meant for tests and development.
+type MemoryOutbox struct {
+	mu    sync.Mutex
+	mails []*models.Mail
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{
+		mu:    sync.Mutex{},
+		mails: nil,
+	}
+}
+
+func (o *MemoryOutbox) Send(mail *models.Mail) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sent := *mail
+	o.mails = append(o.mails, &sent)
+
+	return nil
+}
+
+// Mails returns the sent mails, oldest first.
+func (o *MemoryOutbox) Mails() []*models.Mail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return slices.Clone(o.mails)
+}
+
+// FileOutbox appends every sent mail to a file as a line of JSON, from which another process may deliver it.
+type FileOutbox struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileOutbox(path string) *FileOutbox {
+	return &FileOutbox{
+		mu:   sync.Mutex{},
+		path: path,
+	}
+}
+
+type outboxEntry struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+func (o *FileOutbox) Send(mail *models.Mail) error {
+	line, err := json.Marshal(&outboxEntry{
+		To:      mail.To,
+		Subject: mail.Subject,
+		Body:    mail.Body,
+		SentAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal mail: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	file, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package infrastructure_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func TestMemoryOutbox(t *testing.T) {
+	t.Parallel()
+
+	sut := infrastructure.NewMemoryOutbox()
+	mail := &models.Mail{To: "alice@corp.com", Subject: "first", Body: "body"}
+
+	require.NoError(t, sut.Send(mail))
+	require.NoError(t, sut.Send(&models.Mail{To: "bob@corp.com", Subject: "second", Body: "body"}))
+
+	mail.Subject = "changed after sending"
+
+	mails := sut.Mails()
+	require.Len(t, mails, 2)
+	assert.Equal(t, "first", mails[0].Subject)
+	assert.Equal(t, "bob@corp.com", mails[1].To)
+}
+
+func TestFileOutbox(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	sut := infrastructure.NewFileOutbox(path)
+
+	require.NoError(t, sut.Send(&models.Mail{To: "alice@corp.com", Subject: "first", Body: "line\nbreak"}))
+	require.NoError(t, sut.Send(&models.Mail{To: "bob@corp.com", Subject: "second", Body: "body"}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, file.Close()) })
+
+	var entries []map[string]string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := make(map[string]string)
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, "alice@corp.com", entries[0]["to"])
+	assert.Equal(t, "line\nbreak", entries[0]["body"])
+	assert.Equal(t, "second", entries[1]["subject"])
+	assert.NotEmpty(t, entries[1]["sent_at"])
+}
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// orderedIndex keeps the users sorted by organization and key, with ties broken by ID,
+// so that a page of an organization can be found by a binary search instead of a full scan.
+type orderedIndex struct {
+	keyFn   func(user *models.User) string
+	entries []models.UserListPosition
+}
+
+func newOrderedIndex(keyFn func(user *models.User) string) *orderedIndex {
+	return &orderedIndex{
+		keyFn:   keyFn,
+		entries: make([]models.UserListPosition, 0),
+	}
+}
+
+// key returns the key of the user in the index: the organization followed by the key of the user.
+// Organization names cannot contain a zero byte, so the users of an organization are adjacent in the index.
+func (idx *orderedIndex) key(user *models.User) string {
+	return organizationPrefix(user.Organization) + idx.keyFn(user)
+}
+
+func organizationPrefix(organization string) string {
+	return organization + "\x00"
+}
+
+func (idx *orderedIndex) insert(user *models.User) {
+	entry := models.UserListPosition{Key: idx.key(user), ID: user.ID}
+	i := idx.search(entry)
+
+	idx.entries = append(idx.entries, models.UserListPosition{})
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = entry
+}
+
+func (idx *orderedIndex) remove(user *models.User) {
+	entry := models.UserListPosition{Key: idx.key(user), ID: user.ID}
+
+	i := idx.search(entry)
+	if i < len(idx.entries) && idx.entries[i] == entry {
+		idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+	}
+}
+
+// search returns the index of the first entry that is not less than the given one.
+func (idx *orderedIndex) search(entry models.UserListPosition) int {
+	return sort.Search(len(idx.entries), func(i int) bool {
+		return comparePositions(idx.entries[i], entry) >= 0
+	})
+}
+
+// scan calls fn for the entries of the organization after the given exclusive position in the given direction
+// until fn returns false. Positions outside of the organization are clamped to it, so the scan never leaves it.
+func (idx *orderedIndex) scan(
+	organization string,
+	after *models.UserListPosition,
+	descending bool,
+	fn func(id uuid.UUID) bool,
+) {
+	prefix := organizationPrefix(organization)
+	// The organization followed by the byte after zero sorts after every key of the organization.
+	start := idx.search(models.UserListPosition{Key: prefix, ID: uuid.UUID{}})
+	end := idx.search(models.UserListPosition{Key: organization + "\x01", ID: uuid.UUID{}})
+
+	if descending {
+		if after != nil {
+			end = min(end, idx.search(*after))
+		}
+
+		for i := end - 1; i >= start; i-- {
+			if !fn(idx.entries[i].ID) {
+				return
+			}
+		}
+
+		return
+	}
+
+	if after != nil {
+		from := idx.search(*after)
+		if from < len(idx.entries) && idx.entries[from] == *after {
+			from++
+		}
+
+		start = max(start, from)
+	}
+
+	for i := start; i < end; i++ {
+		if !fn(idx.entries[i].ID) {
+			return
+		}
+	}
+}
+
+func comparePositions(a models.UserListPosition, b models.UserListPosition) int {
+	if cmp := strings.Compare(a.Key, b.Key); cmp != 0 {
+		return cmp
+	}
+
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+func usernameIndexKey(user *models.User) string {
+	return user.Username
+}
+
+func emailIndexKey(user *models.User) string {
+	return emailKey(user.Email)
+}
+
+// createTimeIndexKey formats the time with a fixed width, so that keys sort in chronological order.
+func createTimeIndexKey(user *models.User) string {
+	return user.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000")
+}
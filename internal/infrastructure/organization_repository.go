@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// SaveOrganization creates the organization.
+// It fails with common.ErrAlreadyExists if an organization with the same name exists.
+func (r *Repository) SaveOrganization(organization *models.Organization) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	_, exists := r.organizations[organization.Name]
+	r.stateMu.RUnlock()
+
+	if exists {
+		return fmt.Errorf("%w: organization %q already exists", common.ErrAlreadyExists, organization.Name)
+	}
+
+	stored := *organization
+
+	return r.commit(&walRecord{
+		Op:           walOpSaveOrganization,
+		Organization: &stored,
+	})
+}
+
+func (r *Repository) GetOrganization(name string) (*models.Organization, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if organization, ok := r.organizations[name]; ok {
+		return organization, nil
+	}
+
+	return nil, fmt.Errorf("%w: organization %q not found", common.ErrNotFound, name)
+}
+
+// ListOrganizations returns all organizations ordered by name.
+func (r *Repository) ListOrganizations() ([]*models.Organization, error) {
+	organizations := r.getAllOrganizations()
+
+	slices.SortFunc(organizations, func(a, b *models.Organization) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return organizations, nil
+}
+
+func (r *Repository) getAllOrganizations() []*models.Organization {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	organizations := make([]*models.Organization, 0, len(r.organizations))
+	for _, organization := range r.organizations {
+		organizations = append(organizations, organization)
+	}
+
+	return organizations
+}
+
+func (r *Repository) storeOrganization(organization *models.Organization) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.organizations[organization.Name] = organization
+}
@@ -0,0 +1,453 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+)
+
+type PasswordHashAlgorithm string
+
+const (
+	PasswordHashBcrypt   PasswordHashAlgorithm = "bcrypt"
+	PasswordHashScrypt   PasswordHashAlgorithm = "scrypt"
+	PasswordHashArgon2id PasswordHashAlgorithm = "argon2id"
+)
+
+const (
+	defaultScryptCost     = 15
+	defaultArgon2idCost   = 2
+	defaultArgon2idMemory = 19 * 1024
+
+	maxScryptCost = 30
+
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+
+	scryptBlockSize   = 8
+	scryptParallelism = 1
+
+	argon2idParallelism = 1
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+func ParsePasswordHashAlgorithm(algorithm string) (PasswordHashAlgorithm, error) {
+	switch PasswordHashAlgorithm(algorithm) {
+	case PasswordHashBcrypt, PasswordHashScrypt, PasswordHashArgon2id:
+		return PasswordHashAlgorithm(algorithm), nil
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+}
+
+type PasswordHasherConfig struct {
+	// Algorithm hashes new passwords. Hashes of the other algorithms are still verified.
+	Algorithm PasswordHashAlgorithm
+	// Cost is the log2 of the rounds of bcrypt, the log2 of the CPU/memory cost N of scrypt,
+	// or the number of passes of argon2id. Zero is the recommended cost of the algorithm.
+	Cost int
+	// Memory is the memory of argon2id in KiB, and is not used by the other algorithms.
+	// Zero is the recommended memory.
+	Memory uint32
+}
+
+// PasswordHasher hashes passwords into self-describing strings: bcrypt hashes in their own format,
+// scrypt and argon2id hashes in the PHC string format, such as $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>.
+type PasswordHasher struct {
+	config PasswordHasherConfig
+}
+
+func NewPasswordHasher(config PasswordHasherConfig) (*PasswordHasher, error) {
+	switch config.Algorithm {
+	case PasswordHashBcrypt:
+		config.Cost = defaultIfZero(config.Cost, bcrypt.DefaultCost)
+		if config.Cost < bcrypt.MinCost || config.Cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost %d is not between %d and %d", config.Cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case PasswordHashScrypt:
+		config.Cost = defaultIfZero(config.Cost, defaultScryptCost)
+		if config.Cost < 1 || config.Cost > maxScryptCost {
+			return nil, fmt.Errorf("scrypt cost %d is not between 1 and %d", config.Cost, maxScryptCost)
+		}
+	case PasswordHashArgon2id:
+		config.Cost = defaultIfZero(config.Cost, defaultArgon2idCost)
+		config.Memory = defaultIfZero(config.Memory, defaultArgon2idMemory)
+
+		if config.Cost < 1 {
+			return nil, fmt.Errorf("argon2id cost %d is less than 1", config.Cost)
+		}
+
+		if config.Memory < 8*argon2idParallelism {
+			return nil, fmt.Errorf("argon2id memory %d KiB is less than %d KiB", config.Memory, 8*argon2idParallelism)
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", config.Algorithm)
+	}
+
+	return &PasswordHasher{
+		config: config,
+	}, nil
+}
+
+// Hash hashes the password with a random salt using the configured algorithm and cost.
+// It fails without hashing if the context is already done, but a hash that has started runs to the end.
+func (h *PasswordHasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	switch h.config.Algorithm {
+	case PasswordHashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password with bcrypt: %w", err)
+		}
+
+		return hash, nil
+	case PasswordHashScrypt:
+		params := scryptParams{
+			costLog2:    h.config.Cost,
+			blockSize:   scryptBlockSize,
+			parallelism: scryptParallelism,
+		}
+
+		return hashPHC(params, password)
+	case PasswordHashArgon2id:
+		params := argon2idParams{
+			version:     argon2.Version,
+			memory:      h.config.Memory,
+			passes:      uint32(h.config.Cost),
+			parallelism: argon2idParallelism,
+		}
+
+		return hashPHC(params, password)
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", h.config.Algorithm)
+	}
+}
+
+// Verify fails with common.ErrInvalidCredentials if the password does not match the hash, and reports
+// whether the hash should be replaced since it does not use the configured algorithm and cost.
+// Hashes of every supported algorithm are verified, whichever is configured.
+// Like Hash, it fails without verifying if the context is already done.
+func (h *PasswordHasher) Verify(ctx context.Context, hash []byte, password string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if bytes.HasPrefix(hash, []byte("$2")) {
+		err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+		switch {
+		case err == nil:
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, fmt.Errorf("%w: password does not match bcrypt hash", common.ErrInvalidCredentials)
+		default:
+			return false, fmt.Errorf("failed to compare bcrypt hash: %w", err)
+		}
+
+		cost, err := bcrypt.Cost(hash)
+		if err != nil {
+			return false, fmt.Errorf("failed to get cost of bcrypt hash: %w", err)
+		}
+
+		return h.config.Algorithm != PasswordHashBcrypt || cost != h.config.Cost, nil
+	}
+
+	params, salt, key, err := parsePHCHash(string(hash))
+	if err != nil {
+		return false, err
+	}
+
+	derived, err := params.derive(password, salt, len(key))
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare(derived, key) != 1 {
+		return false, fmt.Errorf("%w: password does not match %s hash", common.ErrInvalidCredentials, params.algorithm())
+	}
+
+	return !params.current(h.config) || len(key) != passwordKeyLength, nil
+}
+
+// Parameters returns the algorithm and parameters that the hash encodes, without its salt and key,
+// such as $2a$10$ or $argon2id$v=19$m=19456,t=2,p=1$. Hashes with the same parameters take as long to verify.
+func (h *PasswordHasher) Parameters(hash []byte) (string, error) {
+	if bytes.HasPrefix(hash, []byte("$2")) {
+		cost, err := bcrypt.Cost(hash)
+		if err != nil {
+			return "", fmt.Errorf("%w: failed to get cost of bcrypt hash: %w", ErrUnknownPasswordHash, err)
+		}
+
+		return fmt.Sprintf("$2a$%02d$", cost), nil
+	}
+
+	params, _, _, err := parsePHCHash(string(hash))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$%s$%s$", params.algorithm(), params.encode()), nil
+}
+
+// HashWithParameters hashes the password with a random salt using the algorithm and parameters
+// returned by Parameters instead of the configured ones. Like Hash, it fails if the context is already done.
+func (h *PasswordHasher) HashWithParameters(ctx context.Context, parameters string, password string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if strings.HasPrefix(parameters, "$2") {
+		segments := strings.Split(parameters, "$")
+		if len(segments) != 4 || segments[3] != "" {
+			return nil, fmt.Errorf("%w: malformed bcrypt parameters %q", ErrUnknownPasswordHash, parameters)
+		}
+
+		cost, err := strconv.Atoi(segments[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bcrypt cost %q: %w", ErrUnknownPasswordHash, segments[2], err)
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password with bcrypt: %w", err)
+		}
+
+		return hash, nil
+	}
+
+	params, rest, err := parsePHCParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 1 || rest[0] != "" {
+		return nil, fmt.Errorf("%w: malformed %s parameters %q", ErrUnknownPasswordHash, params.algorithm(), parameters)
+	}
+
+	return hashPHC(params, password)
+}
+
+// phcParams are the parameters of a key derivation function encoded in a PHC string.
+type phcParams interface {
+	algorithm() PasswordHashAlgorithm
+	// encode returns the PHC segments between the algorithm and the salt.
+	encode() string
+	derive(password string, salt []byte, keyLength int) ([]byte, error)
+	// current reports whether the parameters are the ones the config hashes new passwords with.
+	current(config PasswordHasherConfig) bool
+}
+
+type scryptParams struct {
+	costLog2    int
+	blockSize   int
+	parallelism int
+}
+
+func (p scryptParams) algorithm() PasswordHashAlgorithm {
+	return PasswordHashScrypt
+}
+
+func (p scryptParams) encode() string {
+	return fmt.Sprintf("ln=%d,r=%d,p=%d", p.costLog2, p.blockSize, p.parallelism)
+}
+
+func (p scryptParams) derive(password string, salt []byte, keyLength int) ([]byte, error) {
+	if p.costLog2 < 1 || p.costLog2 > maxScryptCost {
+		return nil, fmt.Errorf("%w: scrypt cost %d is out of range", ErrUnknownPasswordHash, p.costLog2)
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<p.costLog2, p.blockSize, p.parallelism, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive scrypt key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (p scryptParams) current(config PasswordHasherConfig) bool {
+	return config.Algorithm == PasswordHashScrypt &&
+		p.costLog2 == config.Cost &&
+		p.blockSize == scryptBlockSize &&
+		p.parallelism == scryptParallelism
+}
+
+type argon2idParams struct {
+	version     int
+	memory      uint32
+	passes      uint32
+	parallelism uint8
+}
+
+func (p argon2idParams) algorithm() PasswordHashAlgorithm {
+	return PasswordHashArgon2id
+}
+
+func (p argon2idParams) encode() string {
+	return fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", p.version, p.memory, p.passes, p.parallelism)
+}
+
+func (p argon2idParams) derive(password string, salt []byte, keyLength int) ([]byte, error) {
+	if p.version != argon2.Version {
+		return nil, fmt.Errorf("%w: argon2id version %d is not supported", ErrUnknownPasswordHash, p.version)
+	}
+
+	if p.passes < 1 || p.parallelism < 1 {
+		return nil, fmt.Errorf("%w: argon2id passes and parallelism must be positive", ErrUnknownPasswordHash)
+	}
+
+	return argon2.IDKey([]byte(password), salt, p.passes, p.memory, p.parallelism, uint32(keyLength)), nil
+}
+
+func (p argon2idParams) current(config PasswordHasherConfig) bool {
+	return config.Algorithm == PasswordHashArgon2id &&
+		p.memory == config.Memory &&
+		p.passes == uint32(config.Cost) &&
+		p.parallelism == argon2idParallelism
+}
+
+// hashPHC derives a key from the password and a random salt, and encodes them with the parameters as a PHC string.
+func hashPHC(params phcParams, password string) ([]byte, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := params.derive(password, salt, passwordKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(
+		"$%s$%s$%s$%s",
+		params.algorithm(),
+		params.encode(),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// parsePHCHash parses $scrypt$ln=<cost>,r=<block size>,p=<parallelism>$<salt>$<key>
+// and $argon2id$v=<version>$m=<memory>,t=<passes>,p=<parallelism>$<salt>$<key>.
+func parsePHCHash(hash string) (phcParams, []byte, []byte, error) { //nolint:ireturn
+	params, rest, err := parsePHCParams(hash)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(rest) != 2 {
+		return nil, nil, nil, fmt.Errorf("%w: malformed %s hash", ErrUnknownPasswordHash, params.algorithm())
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(rest[0])
+	if err != nil || len(salt) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: failed to decode salt", ErrUnknownPasswordHash)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(rest[1])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: failed to decode key", ErrUnknownPasswordHash)
+	}
+
+	return params, salt, key, nil
+}
+
+// parsePHCParams parses the algorithm and parameters at the start of a PHC string,
+// and returns them with the segments that follow.
+func parsePHCParams(hash string) (phcParams, []string, error) { //nolint:ireturn
+	segments := strings.Split(hash, "$")
+	if len(segments) < 2 || segments[0] != "" {
+		return nil, nil, ErrUnknownPasswordHash
+	}
+
+	switch PasswordHashAlgorithm(segments[1]) { //nolint:exhaustive
+	case PasswordHashScrypt:
+		if len(segments) < 3 {
+			return nil, nil, fmt.Errorf("%w: malformed scrypt hash", ErrUnknownPasswordHash)
+		}
+
+		values, err := parsePHCValues(segments[2], "ln", "r", "p")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return scryptParams{
+			costLog2:    int(values[0]),
+			blockSize:   int(values[1]),
+			parallelism: int(values[2]),
+		}, segments[3:], nil
+	case PasswordHashArgon2id:
+		if len(segments) < 4 {
+			return nil, nil, fmt.Errorf("%w: malformed argon2id hash", ErrUnknownPasswordHash)
+		}
+
+		version, err := parsePHCValues(segments[2], "v")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		values, err := parsePHCValues(segments[3], "m", "t", "p")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if values[2] > 255 {
+			return nil, nil, fmt.Errorf("%w: argon2id parallelism %d is too high", ErrUnknownPasswordHash, values[2])
+		}
+
+		return argon2idParams{
+			version:     int(version[0]),
+			memory:      values[0],
+			passes:      values[1],
+			parallelism: uint8(values[2]),
+		}, segments[4:], nil
+	default:
+		return nil, nil, fmt.Errorf("%w: algorithm %q", ErrUnknownPasswordHash, segments[1])
+	}
+}
+
+// parsePHCValues parses a segment of comma-separated name=value parameters with exactly the names in order.
+func parsePHCValues(segment string, names ...string) ([]uint32, error) {
+	pairs := strings.Split(segment, ",")
+	if len(pairs) != len(names) {
+		return nil, fmt.Errorf("%w: parameters %q are not %v", ErrUnknownPasswordHash, segment, names)
+	}
+
+	values := make([]uint32, len(names))
+	for i, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name != names[i] {
+			return nil, fmt.Errorf("%w: parameters %q are not %v", ErrUnknownPasswordHash, segment, names)
+		}
+
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %q: %w", ErrUnknownPasswordHash, name, err)
+		}
+
+		values[i] = uint32(parsed)
+	}
+
+	return values, nil
+}
+
+func defaultIfZero[T int | uint32](value T, defaultValue T) T {
+	if value == 0 {
+		return defaultValue
+	}
+
+	return value
+}
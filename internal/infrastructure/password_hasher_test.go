@@ -0,0 +1,184 @@
+package infrastructure_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		config infrastructure.PasswordHasherConfig
+		prefix string
+	}{
+		{
+			name:   "bcrypt",
+			config: newPasswordHasherConfig(infrastructure.PasswordHashBcrypt, bcrypt.MinCost, 0),
+			prefix: "$2a$04$",
+		},
+		{
+			name:   "scrypt",
+			config: newPasswordHasherConfig(infrastructure.PasswordHashScrypt, 10, 0),
+			prefix: "$scrypt$ln=10,r=8,p=1$",
+		},
+		{
+			name:   "argon2id",
+			config: newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 1, 64),
+			prefix: "$argon2id$v=19$m=64,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hasher, err := infrastructure.NewPasswordHasher(tc.config)
+			require.NoError(t, err)
+
+			hash, err := hasher.Hash(context.Background(), "password")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(hash), tc.prefix), "hash %q", hash)
+
+			another, err := hasher.Hash(context.Background(), "password")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, another, "every hash has its own salt")
+
+			outdated, err := hasher.Verify(context.Background(), hash, "password")
+			require.NoError(t, err)
+			assert.False(t, outdated)
+
+			_, err = hasher.Verify(context.Background(), hash, "wrong")
+			assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+			parameters, err := hasher.Parameters(hash)
+			require.NoError(t, err)
+			assert.Equal(t, tc.prefix, parameters)
+		})
+	}
+}
+
+func TestPasswordHasher_HashWithParameters(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := infrastructure.NewPasswordHasher(newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 1, 64))
+	require.NoError(t, err)
+
+	for _, parameters := range []string{
+		"$2a$04$",
+		"$scrypt$ln=4,r=8,p=1$",
+		"$argon2id$v=19$m=32,t=2,p=1$",
+	} {
+		hash, err := hasher.HashWithParameters(context.Background(), parameters, "password")
+		require.NoError(t, err, parameters)
+		assert.True(t, strings.HasPrefix(string(hash), parameters), "hash %q", hash)
+
+		outdated, err := hasher.Verify(context.Background(), hash, "password")
+		require.NoError(t, err, parameters)
+		assert.True(t, outdated, "the parameters are not the configured ones")
+	}
+
+	for _, malformed := range []string{
+		"",
+		"$2a$",
+		"$2a$xx$",
+		"$scrypt$ln=4,r=8,p=1$c2FsdA$",
+		"$md5$",
+	} {
+		_, err := hasher.HashWithParameters(context.Background(), malformed, "password")
+		assert.ErrorIs(t, err, infrastructure.ErrUnknownPasswordHash, malformed)
+	}
+}
+
+func TestPasswordHasher_Outdated(t *testing.T) {
+	t.Parallel()
+
+	current, err := infrastructure.NewPasswordHasher(newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 1, 64))
+	require.NoError(t, err)
+
+	for name, config := range map[string]infrastructure.PasswordHasherConfig{
+		"another algorithm": newPasswordHasherConfig(infrastructure.PasswordHashBcrypt, bcrypt.MinCost, 0),
+		"another cost":      newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 2, 64),
+		"another memory":    newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 1, 128),
+	} {
+		hasher, err := infrastructure.NewPasswordHasher(config)
+		require.NoError(t, err)
+
+		hash, err := hasher.Hash(context.Background(), "password")
+		require.NoError(t, err)
+
+		outdated, err := current.Verify(context.Background(), hash, "password")
+		require.NoError(t, err, name)
+		assert.True(t, outdated, name)
+
+		_, err = current.Verify(context.Background(), hash, "wrong")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials, name)
+	}
+}
+
+func TestPasswordHasher_Verify(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := infrastructure.NewPasswordHasher(newPasswordHasherConfig(infrastructure.PasswordHashScrypt, 10, 0))
+	require.NoError(t, err)
+
+	// The third scrypt test vector of RFC 7914, with the salt "NaCl" and a 64-byte key.
+	const hash = "$scrypt$ln=10,r=8,p=16$TmFDbA$" +
+		"/bq+HJ00cgB4VucZDQHp/nxq18vII3gw53N2Y0s3MWIurzDZLiKjiG/xCSedmDDaxyevuUqD7m2DYMvfoswGQA"
+
+	outdated, err := hasher.Verify(context.Background(), []byte(hash), "password")
+	require.NoError(t, err)
+	assert.True(t, outdated, "the parallelism and key length are not the current ones")
+
+	_, err = hasher.Verify(context.Background(), []byte(hash), "wrong")
+	assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+	for _, malformed := range []string{
+		"",
+		"plaintext",
+		"$md5$salt$hash",
+		"$scrypt$ln=4,r=8$c2FsdA$Hw29Tf0e7b5q8y+kHGJ4tYeuwOfT7/TRpNUt8R0qgb8",
+		"$scrypt$ln=4,r=8,p=1$c2FsdA$",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$Hw29Tf0e7b5q8y+kHGJ4tYeuwOfT7/TRpNUt8R0qgb8",
+	} {
+		_, err := hasher.Verify(context.Background(), []byte(malformed), "password")
+		assert.ErrorIs(t, err, infrastructure.ErrUnknownPasswordHash, malformed)
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	t.Parallel()
+
+	for name, config := range map[string]infrastructure.PasswordHasherConfig{
+		"unknown algorithm": newPasswordHasherConfig("md5", 0, 0),
+		"bcrypt cost":       newPasswordHasherConfig(infrastructure.PasswordHashBcrypt, bcrypt.MaxCost+1, 0),
+		"scrypt cost":       newPasswordHasherConfig(infrastructure.PasswordHashScrypt, 31, 0),
+		"argon2id cost":     newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, -1, 0),
+		"argon2id memory":   newPasswordHasherConfig(infrastructure.PasswordHashArgon2id, 1, 4),
+	} {
+		_, err := infrastructure.NewPasswordHasher(config)
+		assert.Error(t, err, name)
+	}
+}
+
+func newPasswordHasherConfig(
+	algorithm infrastructure.PasswordHashAlgorithm,
+	cost int,
+	memory uint32,
+) infrastructure.PasswordHasherConfig {
+	return infrastructure.PasswordHasherConfig{
+		Algorithm: algorithm,
+		Cost:      cost,
+		Memory:    memory,
+	}
+}
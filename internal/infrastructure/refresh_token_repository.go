@@ -0,0 +1,209 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+type refreshTokenFamily struct {
+	userID uuid.UUID
+	hashes []string
+}
+
+// SaveRefreshToken starts a new family with the token.
+// It fails with common.ErrNotFound if the owner of the token does not exist.
+func (r *Repository) SaveRefreshToken(token *models.RefreshToken) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if _, err := r.GetByID(token.UserID); err != nil {
+		return err
+	}
+
+	r.stateMu.RLock()
+	_, tokenExists := r.refreshTokens[string(token.Hash)]
+	_, familyExists := r.refreshTokenFamilies[token.FamilyID]
+	r.stateMu.RUnlock()
+
+	if tokenExists || familyExists {
+		return fmt.Errorf("%w: refresh token or its family already exists", common.ErrAlreadyExists)
+	}
+
+	stored := *token
+
+	return r.commit(&walRecord{
+		Op:           walOpSaveRefreshToken,
+		RefreshToken: &stored,
+	})
+}
+
+func (r *Repository) GetRefreshToken(hash []byte) (*models.RefreshToken, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if token, ok := r.refreshTokens[string(hash)]; ok {
+		return token, nil
+	}
+
+	return nil, fmt.Errorf("%w: refresh token not found", common.ErrNotFound)
+}
+
+// RotateRefreshToken marks the token with the previous hash as rotated and adds the next token to its family.
+// It fails with common.ErrConflict if the previous token has already been rotated.
+func (r *Repository) RotateRefreshToken(previousHash []byte, next *models.RefreshToken) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	previous, err := r.GetRefreshToken(previousHash)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case previous.Rotated:
+		return fmt.Errorf("%w: refresh token has already been rotated", common.ErrConflict)
+	case previous.FamilyID != next.FamilyID || previous.UserID != next.UserID:
+		return fmt.Errorf("refresh token of family %q cannot be rotated to family %q", previous.FamilyID, next.FamilyID)
+	}
+
+	stored := *next
+
+	return r.commit(&walRecord{
+		Op:               walOpRotateRefreshToken,
+		RefreshToken:     &stored,
+		RefreshTokenHash: previousHash,
+	})
+}
+
+func (r *Repository) DeleteRefreshTokenFamily(familyID uuid.UUID) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	_, ok := r.refreshTokenFamilies[familyID]
+	r.stateMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: refresh token family %q not found", common.ErrNotFound, familyID)
+	}
+
+	return r.commit(&walRecord{
+		Op:       walOpDeleteRefreshTokenFamily,
+		FamilyID: &familyID,
+	})
+}
+
+func (r *Repository) DeleteRefreshTokensOfUser(userID uuid.UUID) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	found := false
+	for _, family := range r.refreshTokenFamilies {
+		if family.userID == userID {
+			found = true
+
+			break
+		}
+	}
+	r.stateMu.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	return r.commit(&walRecord{
+		Op:     walOpDeleteUserRefreshTokens,
+		UserID: &userID,
+	})
+}
+
+func (r *Repository) getAllRefreshTokens() []*models.RefreshToken {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	tokens := make([]*models.RefreshToken, 0, len(r.refreshTokens))
+	for _, token := range r.refreshTokens {
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+func (r *Repository) storeRefreshToken(token *models.RefreshToken) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.storeRefreshTokenLocked(token)
+}
+
+func (r *Repository) storeRefreshTokenLocked(token *models.RefreshToken) {
+	key := string(token.Hash)
+
+	family, ok := r.refreshTokenFamilies[token.FamilyID]
+	if !ok {
+		family = &refreshTokenFamily{
+			userID: token.UserID,
+			hashes: make([]string, 0, 1),
+		}
+		r.refreshTokenFamilies[token.FamilyID] = family
+	}
+
+	if _, ok := r.refreshTokens[key]; !ok {
+		family.hashes = append(family.hashes, key)
+	}
+
+	r.refreshTokens[key] = token
+}
+
+func (r *Repository) rotateRefreshToken(previousHash []byte, next *models.RefreshToken) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if previous, ok := r.refreshTokens[string(previousHash)]; ok {
+		rotated := *previous
+		rotated.Rotated = true
+		r.refreshTokens[string(previousHash)] = &rotated
+	}
+
+	r.storeRefreshTokenLocked(next)
+}
+
+func (r *Repository) deleteRefreshTokenFamily(familyID uuid.UUID) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.deleteRefreshTokenFamilyLocked(familyID)
+}
+
+func (r *Repository) deleteRefreshTokenFamilyLocked(familyID uuid.UUID) {
+	family, ok := r.refreshTokenFamilies[familyID]
+	if !ok {
+		return
+	}
+
+	for _, hash := range family.hashes {
+		delete(r.refreshTokens, hash)
+	}
+
+	delete(r.refreshTokenFamilies, familyID)
+}
+
+func (r *Repository) deleteRefreshTokensOfUser(userID uuid.UUID) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.deleteRefreshTokensOfUserLocked(userID)
+}
+
+func (r *Repository) deleteRefreshTokensOfUserLocked(userID uuid.UUID) {
+	for familyID, family := range r.refreshTokenFamilies {
+		if family.userID == userID {
+			r.deleteRefreshTokenFamilyLocked(familyID)
+		}
+	}
+}
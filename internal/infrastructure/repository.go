@@ -1,8 +1,15 @@
 package infrastructure
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,84 +17,718 @@ import (
 	"github.com/ScareTrow/grpc_user_auth/internal/models"
 )
 
+const (
+	walFileName      = "users.wal"
+	snapshotFileName = "users.snapshot"
+)
+
 type Repository struct {
-	users sync.Map
+	// writeMu serializes writes, so the order of records in the log matches the order of changes in the maps,
+	// and uniqueness checks are not raced by other writers.
+	writeMu sync.Mutex
+	lsn     uint64
+	wal     *wal
+
+	// stateMu guards the maps below. Writers hold it only while applying a record, not while logging it.
+	stateMu   sync.RWMutex
+	users     map[uuid.UUID]*models.User
+	usernames map[orgKey]uuid.UUID
+	emails    map[orgKey]uuid.UUID
+	orders    map[models.UserOrderField]*orderedIndex
+
+	refreshTokens        map[string]*models.RefreshToken
+	refreshTokenFamilies map[uuid.UUID]*refreshTokenFamily
+
+	apiKeys      map[uuid.UUID]*models.APIKey
+	apiKeyHashes map[string]uuid.UUID
+
+	roles map[string]*models.Role
+
+	organizations map[string]*models.Organization
+
+	groups     map[uuid.UUID]*models.Group
+	groupNames map[orgKey]uuid.UUID
+	// memberGroups indexes the groups by the IDs of their direct members, users and groups alike.
+	memberGroups map[uuid.UUID]map[uuid.UUID]struct{}
+
+	// tuples indexes the tuples by object and relation, and then by subject.
+	tuples        map[string]map[string]*models.Tuple
+	tupleRevision uint64
+
+	dataDir              string
+	logger               *slog.Logger
+	snapshotMu           sync.Mutex
+	snapshotThreshold    int
+	recordsSinceSnapshot int
+	snapshotTrigger      chan struct{}
+	stopSnapshots        chan struct{}
+	snapshotsDone        chan struct{}
+}
+
+type repositoryOptions struct {
+	dataDir           string
+	syncPolicy        SyncPolicy
+	syncInterval      time.Duration
+	snapshotInterval  time.Duration
+	snapshotThreshold int
+	logger            *slog.Logger
 }
 
-func NewRepository() *Repository {
-	return &Repository{
-		users: sync.Map{},
+type RepositoryOption func(*repositoryOptions)
+
+// WithDataDir makes the repository durable by keeping a write-ahead log and snapshots in the given directory.
+// Without it the repository lives in memory only.
+func WithDataDir(dir string) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.dataDir = dir
 	}
 }
 
-func (r *Repository) Save(user *models.User) error {
-	r.users.Store(user.ID.String(), user)
+func WithSyncPolicy(policy SyncPolicy) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.syncPolicy = policy
+	}
+}
 
-	return nil
+func WithSyncInterval(interval time.Duration) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.syncInterval = interval
+	}
 }
 
-func (r *Repository) GetByID(id uuid.UUID) (*models.User, error) {
-	if value, ok := r.users.Load(id.String()); ok {
-		user, ok := value.(*models.User)
-		if !ok {
-			return nil, fmt.Errorf("unexpected value %+#v in users map", value)
-		}
+// WithSnapshotInterval makes the repository take a snapshot and compact the log periodically.
+func WithSnapshotInterval(interval time.Duration) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.snapshotInterval = interval
+	}
+}
 
-		return user, nil
+// WithSnapshotThreshold makes the repository take a snapshot and compact the log
+// once the given number of records has been logged since the previous snapshot.
+func WithSnapshotThreshold(records int) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.snapshotThreshold = records
 	}
+}
 
-	return nil, fmt.Errorf("%w: user with id %q not found", common.ErrNotFound, id)
+func WithLogger(logger *slog.Logger) RepositoryOption {
+	return func(options *repositoryOptions) {
+		options.logger = logger
+	}
 }
 
-func (r *Repository) GetByUsername(username string) (*models.User, error) {
-	var found *models.User
+func NewRepository(opts ...RepositoryOption) (*Repository, error) {
+	options := &repositoryOptions{
+		dataDir:           "",
+		syncPolicy:        SyncAlways,
+		syncInterval:      time.Second,
+		snapshotInterval:  0,
+		snapshotThreshold: 0,
+		logger:            common.NewDisabledLogger(),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
 
-	r.users.Range(func(_, value any) bool {
-		user, ok := value.(*models.User)
-		if !ok {
-			return false
+	repo := &Repository{
+		writeMu: sync.Mutex{},
+		lsn:     0,
+		wal:     nil,
+
+		stateMu:   sync.RWMutex{},
+		users:     make(map[uuid.UUID]*models.User),
+		usernames: make(map[orgKey]uuid.UUID),
+		emails:    make(map[orgKey]uuid.UUID),
+		orders: map[models.UserOrderField]*orderedIndex{
+			models.UserOrderByUsername:   newOrderedIndex(usernameIndexKey),
+			models.UserOrderByEmail:      newOrderedIndex(emailIndexKey),
+			models.UserOrderByCreateTime: newOrderedIndex(createTimeIndexKey),
+		},
+
+		refreshTokens:        make(map[string]*models.RefreshToken),
+		refreshTokenFamilies: make(map[uuid.UUID]*refreshTokenFamily),
+
+		apiKeys:      make(map[uuid.UUID]*models.APIKey),
+		apiKeyHashes: make(map[string]uuid.UUID),
+
+		roles: make(map[string]*models.Role),
+
+		organizations: make(map[string]*models.Organization),
+
+		groups:       make(map[uuid.UUID]*models.Group),
+		groupNames:   make(map[orgKey]uuid.UUID),
+		memberGroups: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+
+		tuples:        make(map[string]map[string]*models.Tuple),
+		tupleRevision: 0,
+
+		dataDir:              options.dataDir,
+		logger:               options.logger,
+		snapshotMu:           sync.Mutex{},
+		snapshotThreshold:    options.snapshotThreshold,
+		recordsSinceSnapshot: 0,
+		snapshotTrigger:      make(chan struct{}, 1),
+		stopSnapshots:        make(chan struct{}),
+		snapshotsDone:        make(chan struct{}),
+	}
+
+	if options.dataDir == "" {
+		close(repo.snapshotsDone)
+
+		return repo, nil
+	}
+
+	if err := repo.recover(options); err != nil {
+		return nil, err
+	}
+
+	if options.snapshotInterval > 0 || options.snapshotThreshold > 0 {
+		go repo.snapshotLoop(options.snapshotInterval)
+	} else {
+		close(repo.snapshotsDone)
+	}
+
+	return repo, nil
+}
+
+// recover loads the latest snapshot and replays the log records that are not covered by it.
+func (r *Repository) recover(options *repositoryOptions) error {
+	if err := os.MkdirAll(options.dataDir, 0o700); err != nil { //nolint:gomnd
+		return fmt.Errorf("failed to create data dir %q: %w", options.dataDir, err)
+	}
+
+	snap, err := readSnapshot(filepath.Join(options.dataDir, snapshotFileName))
+	if err != nil {
+		return err
+	}
+
+	if snap != nil {
+		for _, user := range snap.Users {
+			r.storeUser(user)
 		}
 
-		if user.Username == username {
-			found = user
+		for _, token := range snap.RefreshTokens {
+			r.storeRefreshToken(token)
+		}
 
-			return false
+		for _, key := range snap.APIKeys {
+			r.storeAPIKey(key)
 		}
 
-		return true
+		for _, role := range snap.Roles {
+			r.storeRole(role)
+		}
+
+		for _, organization := range snap.Organizations {
+			r.storeOrganization(organization)
+		}
+
+		for _, group := range snap.Groups {
+			r.storeGroup(group)
+		}
+
+		r.writeTuples(snap.Tuples, nil, snap.TupleRevision)
+
+		r.lsn = snap.LSN
+	}
+
+	wal, replayed, err := openWAL(
+		filepath.Join(options.dataDir, walFileName),
+		options.syncPolicy,
+		options.syncInterval,
+	)
+	if err != nil {
+		return err
+	}
+
+	if replayed.tornBytes > 0 {
+		r.logger.Warn(
+			"truncated torn records at the end of the write-ahead log",
+			slog.Int64("valid_bytes", replayed.validSize),
+			slog.Int64("torn_bytes", replayed.tornBytes),
+		)
+	}
+
+	for _, record := range replayed.records {
+		// The process may have crashed between writing a snapshot and compacting the log.
+		if record.LSN <= r.lsn {
+			continue
+		}
+
+		if err := r.apply(record); err != nil {
+			return errors.Join(fmt.Errorf("failed to replay record %d: %w", record.LSN, err), wal.close())
+		}
+
+		r.recordsSinceSnapshot++
+	}
+
+	r.wal = wal
+
+	return nil
+}
+
+func (r *Repository) Close() error {
+	if r.wal == nil {
+		return nil
+	}
+
+	close(r.stopSnapshots)
+	<-r.snapshotsDone
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	return r.wal.close()
+}
+
+// Snapshot writes a point-in-time copy of all users, swaps it in place of the previous one
+// and drops the log records it covers. It is a no-op for an in-memory repository.
+func (r *Repository) Snapshot() error {
+	if r.wal == nil {
+		return nil
+	}
+
+	r.snapshotMu.Lock()
+	defer r.snapshotMu.Unlock()
+
+	r.writeMu.Lock()
+	users, err := r.GetAll()
+	if err != nil {
+		r.writeMu.Unlock()
+
+		return err
+	}
+
+	snap := &snapshot{
+		LSN:           r.lsn,
+		Users:         users,
+		RefreshTokens: r.getAllRefreshTokens(),
+		APIKeys:       r.getAllAPIKeys(),
+		Roles:         r.getAllRoles(),
+		Organizations: r.getAllOrganizations(),
+		Groups:        r.getAllGroups(),
+		Tuples:        r.getAllTuples(),
+		TupleRevision: r.tupleRevision,
+	}
+	coveredSize := r.wal.currentSize()
+	r.recordsSinceSnapshot = 0
+	r.writeMu.Unlock()
+
+	// Stored entities are never mutated in place, so the snapshot is encoded without blocking writers.
+	if err := writeSnapshot(filepath.Join(r.dataDir, snapshotFileName), snap); err != nil {
+		return err
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	return r.wal.compact(coveredSize)
+}
+
+func (r *Repository) snapshotLoop(interval time.Duration) {
+	defer close(r.snapshotsDone)
+
+	var tick <-chan time.Time
+
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stopSnapshots:
+			return
+		case <-tick:
+		case <-r.snapshotTrigger:
+		}
+
+		if err := r.Snapshot(); err != nil {
+			r.logger.Error("failed to snapshot repository", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Save creates the user if its version is zero, or replaces it if its version matches the stored one,
+// and assigns the new version to the user.
+// It fails with common.ErrConflict on a version mismatch
+// and with common.ErrAlreadyExists if another user has the same username or email.
+func (r *Repository) Save(user *models.User) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if err := r.checkVersion(user.ID, user.Version); err != nil {
+		return err
+	}
+
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+
+	stored := *user
+	stored.Roles = slices.Clone(user.Roles)
+	stored.Version++
+
+	if user.EmailVerification != nil {
+		verification := *user.EmailVerification
+		stored.EmailVerification = &verification
+	}
+
+	if user.PasswordReset != nil {
+		reset := *user.PasswordReset
+		stored.PasswordReset = &reset
+	}
+
+	err := r.commit(&walRecord{
+		Op:   walOpSaveUser,
+		User: &stored,
 	})
+	if err != nil {
+		return err
+	}
+
+	user.Version = stored.Version
+
+	return nil
+}
 
-	if found == nil {
-		return nil, fmt.Errorf("%w: user with username %q not found", common.ErrNotFound, username)
+func (r *Repository) GetByID(id uuid.UUID) (*models.User, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if user, ok := r.users[id]; ok {
+		return user, nil
 	}
 
-	return found, nil
+	return nil, fmt.Errorf("%w: user with id %q not found", common.ErrNotFound, id)
+}
+
+func (r *Repository) GetByUsername(organization string, username string) (*models.User, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if id, ok := r.usernames[orgKey{organization: organization, name: username}]; ok {
+		return r.users[id], nil
+	}
+
+	return nil, fmt.Errorf(
+		"%w: user with username %q not found in organization %q", common.ErrNotFound, username, organization,
+	)
 }
 
 func (r *Repository) GetAll() ([]*models.User, error) {
-	users := make([]*models.User, 0)
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// List returns a page of users in the order of the query, starting after the position of the query.
+func (r *Repository) List(query *models.UserListQuery) (*models.UserListPage, error) {
+	index, ok := r.orders[query.OrderBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported order %q", query.OrderBy)
+	}
+
+	if query.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", query.Limit)
+	}
+
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	page := &models.UserListPage{
+		Users: make([]*models.User, 0, query.Limit),
+		Next:  nil,
+	}
+
+	var matchErr error
+
+	index.scan(query.Organization, query.After, query.Descending, func(id uuid.UUID) bool {
+		if len(page.Users) == query.Limit {
+			last := page.Users[len(page.Users)-1]
+			page.Next = &models.UserListPosition{Key: index.key(last), ID: last.ID}
 
-	r.users.Range(func(key, value interface{}) bool {
-		user, ok := value.(*models.User)
-		if !ok {
 			return false
 		}
 
-		users = append(users, user)
+		user := r.users[id]
+
+		if query.Match != nil {
+			matched, err := query.Match(user)
+			if err != nil {
+				matchErr = err
+
+				return false
+			}
+
+			if !matched {
+				return true
+			}
+		}
+
+		page.Users = append(page.Users, user)
 
 		return true
 	})
 
-	return users, nil
+	if matchErr != nil {
+		return nil, matchErr
+	}
+
+	return page, nil
 }
 
-func (r *Repository) Delete(id uuid.UUID) error {
+// Delete removes the user if its version matches expectedVersion, or unconditionally if it is zero.
+func (r *Repository) Delete(id uuid.UUID, expectedVersion uint64) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
 	existing, err := r.GetByID(id)
 	if err != nil {
 		return err
 	}
 
-	r.users.Delete(existing.ID.String())
+	if expectedVersion != 0 && existing.Version != expectedVersion {
+		return fmt.Errorf(
+			"%w: user with id %q has version %d, expected %d",
+			common.ErrConflict, id, existing.Version, expectedVersion,
+		)
+	}
+
+	return r.commit(&walRecord{
+		Op:     walOpDeleteUser,
+		UserID: &existing.ID,
+	})
+}
+
+// commit logs the record, if the repository is durable, and applies it to the in-memory state.
+// The caller must hold writeMu.
+func (r *Repository) commit(record *walRecord) error {
+	record.LSN = r.lsn + 1
+
+	if r.wal != nil {
+		if err := r.wal.append(record); err != nil {
+			return err
+		}
+	}
+
+	if err := r.apply(record); err != nil {
+		return err
+	}
+
+	r.recordsSinceSnapshot++
+	if r.snapshotThreshold > 0 && r.recordsSinceSnapshot >= r.snapshotThreshold {
+		select {
+		case r.snapshotTrigger <- struct{}{}:
+		default:
+		}
+	}
 
 	return nil
 }
+
+func (r *Repository) apply(record *walRecord) error {
+	switch record.Op {
+	case walOpSaveUser:
+		if record.User == nil {
+			return fmt.Errorf("%s record without user", record.Op)
+		}
+
+		r.storeUser(record.User)
+	case walOpDeleteUser:
+		if record.UserID == nil {
+			return fmt.Errorf("%s record without user id", record.Op)
+		}
+
+		r.deleteUser(*record.UserID, record.LSN)
+	case walOpSaveRefreshToken:
+		if record.RefreshToken == nil {
+			return fmt.Errorf("%s record without refresh token", record.Op)
+		}
+
+		r.storeRefreshToken(record.RefreshToken)
+	case walOpRotateRefreshToken:
+		if record.RefreshToken == nil || record.RefreshTokenHash == nil {
+			return fmt.Errorf("%s record without refresh tokens", record.Op)
+		}
+
+		r.rotateRefreshToken(record.RefreshTokenHash, record.RefreshToken)
+	case walOpDeleteRefreshTokenFamily:
+		if record.FamilyID == nil {
+			return fmt.Errorf("%s record without family id", record.Op)
+		}
+
+		r.deleteRefreshTokenFamily(*record.FamilyID)
+	case walOpDeleteUserRefreshTokens:
+		if record.UserID == nil {
+			return fmt.Errorf("%s record without user id", record.Op)
+		}
+
+		r.deleteRefreshTokensOfUser(*record.UserID)
+	case walOpSaveAPIKey:
+		if record.APIKey == nil {
+			return fmt.Errorf("%s record without api key", record.Op)
+		}
+
+		r.storeAPIKey(record.APIKey)
+	case walOpDeleteAPIKey:
+		if record.APIKeyID == nil {
+			return fmt.Errorf("%s record without api key id", record.Op)
+		}
+
+		r.deleteAPIKey(*record.APIKeyID)
+	case walOpSaveRole:
+		if record.Role == nil {
+			return fmt.Errorf("%s record without role", record.Op)
+		}
+
+		r.storeRole(record.Role)
+	case walOpSaveOrganization:
+		if record.Organization == nil {
+			return fmt.Errorf("%s record without organization", record.Op)
+		}
+
+		r.storeOrganization(record.Organization)
+	case walOpSaveGroup:
+		if record.Group == nil {
+			return fmt.Errorf("%s record without group", record.Op)
+		}
+
+		r.storeGroup(record.Group)
+	case walOpDeleteGroup:
+		if record.GroupID == nil {
+			return fmt.Errorf("%s record without group id", record.Op)
+		}
+
+		r.deleteGroup(*record.GroupID)
+	case walOpWriteTuples:
+		r.writeTuples(record.TupleWrites, record.TupleDeletes, record.LSN)
+	default:
+		return fmt.Errorf("unknown record operation %q", record.Op)
+	}
+
+	r.lsn = record.LSN
+
+	return nil
+}
+
+// checkVersion must be called with writeMu held, so the result stays valid until the user is committed.
+// Zero version means a user that does not exist yet.
+func (r *Repository) checkVersion(id uuid.UUID, version uint64) error {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	var stored uint64
+	if existing, ok := r.users[id]; ok {
+		stored = existing.Version
+	}
+
+	if version == stored {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user with id %q has version %d, expected %d", common.ErrConflict, id, stored, version)
+}
+
+// checkUnique must be called with writeMu held, so the result stays valid until the user is committed.
+func (r *Repository) checkUnique(user *models.User) error {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if id, ok := r.usernames[usernameKey(user)]; ok && id != user.ID {
+		return fmt.Errorf(
+			"%w: user with username %q already exists in organization %q",
+			common.ErrAlreadyExists, user.Username, user.Organization,
+		)
+	}
+
+	if id, ok := r.emails[userEmailKey(user)]; ok && id != user.ID {
+		return fmt.Errorf(
+			"%w: user with email %q already exists in organization %q",
+			common.ErrAlreadyExists, user.Email, user.Organization,
+		)
+	}
+
+	return nil
+}
+
+func (r *Repository) storeUser(user *models.User) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if existing, ok := r.users[user.ID]; ok {
+		delete(r.usernames, usernameKey(existing))
+		delete(r.emails, userEmailKey(existing))
+
+		for _, index := range r.orders {
+			index.remove(existing)
+		}
+	}
+
+	r.users[user.ID] = user
+	r.usernames[usernameKey(user)] = user.ID
+	r.emails[userEmailKey(user)] = user.ID
+
+	for _, index := range r.orders {
+		index.insert(user)
+	}
+}
+
+func (r *Repository) deleteUser(id uuid.UUID, lsn uint64) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	existing, ok := r.users[id]
+	if !ok {
+		return
+	}
+
+	delete(r.users, id)
+	delete(r.usernames, usernameKey(existing))
+	delete(r.emails, userEmailKey(existing))
+
+	for _, index := range r.orders {
+		index.remove(existing)
+	}
+
+	r.deleteRefreshTokensOfUserLocked(id)
+
+	for keyID, key := range r.apiKeys {
+		if key.UserID == id {
+			r.deleteAPIKeyLocked(keyID)
+		}
+	}
+
+	r.removeGroupMemberLocked(id)
+
+	if r.deleteUserTuplesLocked(models.Object{Namespace: models.UserNamespace, ID: id.String()}) {
+		r.tupleRevision = lsn
+	}
+}
+
+// orgKey is the key of the indexes of names that are unique within an organization.
+type orgKey struct {
+	organization string
+	name         string
+}
+
+func usernameKey(user *models.User) orgKey {
+	return orgKey{organization: user.Organization, name: user.Username}
+}
+
+func userEmailKey(user *models.User) orgKey {
+	return orgKey{organization: user.Organization, name: emailKey(user.Email)}
+}
+
+// emailKey normalizes the email for the unique index, since emails are case-insensitive in practice.
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
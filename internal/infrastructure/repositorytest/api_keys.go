@@ -0,0 +1,122 @@
+package repositorytest
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testAPIKeys(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	t.Run("unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		err := sut.SaveAPIKey(NewAPIKey(t, uuid.New()))
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := sut.GetAPIKeyByHash(NewAPIKey(t, uuid.New()).Hash)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, key)
+
+		err = sut.DeleteAPIKey(uuid.New())
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		first := NewAPIKey(t, user.ID)
+		second := NewAPIKey(t, user.ID)
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+
+		require.NoError(t, sut.SaveAPIKey(second))
+		require.NoError(t, sut.SaveAPIKey(first))
+		assert.ErrorIs(t, sut.SaveAPIKey(first), common.ErrAlreadyExists)
+
+		actual, err := sut.GetAPIKeyByHash(first.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, first, actual)
+
+		keys, err := sut.ListAPIKeys(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.APIKey{first, second}, keys)
+
+		require.NoError(t, sut.DeleteAPIKey(first.ID))
+
+		_, err = sut.GetAPIKeyByHash(first.Hash)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+
+		keys, err = sut.ListAPIKeys(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.APIKey{second}, keys)
+	})
+}
+
+func testAPIKeysOfDeletedUser(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	deleted := NewUser(t)
+	kept := NewUser(t)
+	require.NoError(t, sut.Save(deleted))
+	require.NoError(t, sut.Save(kept))
+
+	deletedKey := NewAPIKey(t, deleted.ID)
+	keptKey := NewAPIKey(t, kept.ID)
+	require.NoError(t, sut.SaveAPIKey(deletedKey))
+	require.NoError(t, sut.SaveAPIKey(keptKey))
+
+	require.NoError(t, sut.Delete(deleted.ID, 0))
+
+	_, err := sut.GetAPIKeyByHash(deletedKey.Hash)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	keys, err := sut.ListAPIKeys(deleted.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = sut.GetAPIKeyByHash(keptKey.Hash)
+	assert.NoError(t, err)
+}
+
+// NewAPIKey returns a scoped API key of the user with a random hash.
+func NewAPIKey(t *testing.T, userID uuid.UUID) *models.APIKey {
+	t.Helper()
+
+	const hashSize = 32
+
+	hash := make([]byte, hashSize)
+	_, err := rand.Read(hash)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	return &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      "test",
+		Prefix:    "gua_test",
+		Hash:      hash,
+		Scopes:    []string{"/users.UserService/*"},
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
@@ -0,0 +1,113 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testGroups(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	user := NewUser(t)
+	require.NoError(t, sut.Save(user))
+
+	eng := NewGroup("eng", "support")
+	eng.UserIDs = []uuid.UUID{user.ID}
+	admins := NewGroup("admins")
+
+	require.NoError(t, sut.SaveGroup(eng))
+	assert.Equal(t, uint64(1), eng.Version)
+	require.NoError(t, sut.SaveGroup(admins))
+	assert.ErrorIs(t, sut.SaveGroup(NewGroup("eng")), common.ErrAlreadyExists)
+
+	eng.Roles[0] = "auditor"
+
+	actual, err := sut.GetGroup(eng.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"support"}, actual.Roles, "stored group must not alias the argument")
+
+	nested := *actual
+	nested.GroupIDs = []uuid.UUID{admins.ID}
+	require.NoError(t, sut.SaveGroup(&nested))
+
+	stale := *actual
+	assert.ErrorIs(t, sut.SaveGroup(&stale), common.ErrConflict)
+
+	groups, err := sut.ListGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "admins", groups[0].Name)
+	assert.Equal(t, "eng", groups[1].Name)
+
+	of, err := sut.GroupsOfMember(user.ID)
+	require.NoError(t, err)
+	require.Len(t, of, 1)
+	assert.Equal(t, eng.ID, of[0].ID)
+
+	of, err = sut.GroupsOfMember(admins.ID)
+	require.NoError(t, err)
+	require.Len(t, of, 1)
+	assert.Equal(t, eng.ID, of[0].ID)
+
+	_, err = sut.GetGroup(uuid.New())
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.ErrorIs(t, sut.DeleteGroup(uuid.New()), common.ErrNotFound)
+}
+
+func testGroupsOfDeletedMembers(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	deleted := NewUser(t)
+	kept := NewUser(t)
+	require.NoError(t, sut.Save(deleted))
+	require.NoError(t, sut.Save(kept))
+
+	nested := NewGroup("nested")
+	require.NoError(t, sut.SaveGroup(nested))
+
+	parent := NewGroup("parent")
+	parent.UserIDs = []uuid.UUID{deleted.ID, kept.ID}
+	parent.GroupIDs = []uuid.UUID{nested.ID}
+	require.NoError(t, sut.SaveGroup(parent))
+
+	require.NoError(t, sut.Delete(deleted.ID, 0))
+	require.NoError(t, sut.DeleteGroup(nested.ID))
+
+	actual, err := sut.GetGroup(parent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{kept.ID}, actual.UserIDs)
+	assert.Empty(t, actual.GroupIDs)
+	assert.Greater(t, actual.Version, parent.Version)
+
+	groups, err := sut.GroupsOfMember(deleted.ID)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	_, err = sut.GetGroup(nested.ID)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+}
+
+// NewGroup returns a group without members with the roles.
+func NewGroup(name string, roles ...string) *models.Group {
+	return &models.Group{
+		ID:           uuid.New(),
+		Organization: models.DefaultOrganization,
+		Name:         name,
+		Roles:        roles,
+		UserIDs:      nil,
+		GroupIDs:     nil,
+		CreatedAt:    time.Now().UTC(),
+		Version:      0,
+	}
+}
@@ -0,0 +1,127 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testOrganizations(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	organizations, err := sut.ListOrganizations()
+	require.NoError(t, err)
+	assert.Empty(t, organizations)
+
+	_, err = sut.GetOrganization("acme")
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	acme := NewOrganization("acme")
+	globex := NewOrganization("globex")
+
+	require.NoError(t, sut.SaveOrganization(globex))
+	require.NoError(t, sut.SaveOrganization(acme))
+	assert.ErrorIs(t, sut.SaveOrganization(NewOrganization("acme")), common.ErrAlreadyExists)
+
+	actual, err := sut.GetOrganization("acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme, actual)
+
+	organizations, err = sut.ListOrganizations()
+	require.NoError(t, err)
+	require.Len(t, organizations, 2)
+	assert.Equal(t, "acme", organizations[0].Name)
+	assert.Equal(t, "globex", organizations[1].Name)
+}
+
+func testUsersOfOrganizations(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	user := NewUser(t)
+	require.NoError(t, sut.Save(user))
+
+	namesake := NewUser(t)
+	namesake.Organization = "acme"
+	namesake.Username = user.Username
+	namesake.Email = user.Email
+	require.NoError(t, sut.Save(namesake), "usernames and emails must only be unique within an organization")
+
+	actual, err := sut.GetByUsername(models.DefaultOrganization, user.Username)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, actual.ID)
+
+	actual, err = sut.GetByUsername("acme", user.Username)
+	require.NoError(t, err)
+	assert.Equal(t, namesake.ID, actual.ID)
+
+	_, err = sut.GetByUsername("globex", user.Username)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	page, err := sut.List(&models.UserListQuery{
+		Organization: "acme",
+		OrderBy:      models.UserOrderByUsername,
+		Descending:   false,
+		After:        nil,
+		Limit:        10,
+		Match:        nil,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, namesake.ID, page.Users[0].ID)
+
+	require.NoError(t, sut.Save(NewUser(t)))
+
+	page, err = sut.List(&models.UserListQuery{
+		Organization: models.DefaultOrganization,
+		OrderBy:      models.UserOrderByUsername,
+		Descending:   false,
+		After:        nil,
+		Limit:        1,
+		Match:        nil,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, page.Next)
+
+	for _, descending := range []bool{false, true} {
+		crossed, err := sut.List(&models.UserListQuery{
+			Organization: "acme",
+			OrderBy:      models.UserOrderByUsername,
+			Descending:   descending,
+			After:        page.Next,
+			Limit:        10,
+			Match:        nil,
+		})
+		require.NoError(t, err)
+
+		for _, listed := range crossed.Users {
+			assert.Equal(t, "acme", listed.Organization, "a position of another organization must not leave the organization")
+		}
+	}
+
+	group := NewGroup("eng")
+	require.NoError(t, sut.SaveGroup(group))
+
+	namesakeGroup := NewGroup("eng")
+	namesakeGroup.Organization = "acme"
+	require.NoError(t, sut.SaveGroup(namesakeGroup), "group names must only be unique within an organization")
+
+	duplicate := NewGroup("eng")
+	duplicate.Organization = "acme"
+	assert.ErrorIs(t, sut.SaveGroup(duplicate), common.ErrAlreadyExists)
+}
+
+func NewOrganization(name string) *models.Organization {
+	return &models.Organization{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,167 @@
+package repositorytest
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testRefreshTokens(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	user := NewUser(t)
+	require.NoError(t, sut.Save(user))
+
+	t.Run("unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		err := sut.SaveRefreshToken(NewRefreshToken(t, uuid.New(), uuid.New()))
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		token, err := sut.GetRefreshToken(NewRefreshToken(t, user.ID, uuid.New()).Hash)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, token)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		t.Parallel()
+
+		first := NewRefreshToken(t, user.ID, uuid.New())
+		require.NoError(t, sut.SaveRefreshToken(first))
+
+		actual, err := sut.GetRefreshToken(first.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, first, actual)
+
+		second := NewRefreshToken(t, user.ID, first.FamilyID)
+		require.NoError(t, sut.RotateRefreshToken(first.Hash, second))
+
+		actual, err = sut.GetRefreshToken(first.Hash)
+		require.NoError(t, err)
+		assert.True(t, actual.Rotated)
+		assert.False(t, first.Rotated, "stored token must not alias the argument")
+
+		actual, err = sut.GetRefreshToken(second.Hash)
+		require.NoError(t, err)
+		assert.False(t, actual.Rotated)
+
+		err = sut.RotateRefreshToken(first.Hash, NewRefreshToken(t, user.ID, first.FamilyID))
+		assert.ErrorIs(t, err, common.ErrConflict)
+
+		require.NoError(t, sut.DeleteRefreshTokenFamily(first.FamilyID))
+
+		for _, token := range []*models.RefreshToken{first, second} {
+			_, err = sut.GetRefreshToken(token.Hash)
+			assert.ErrorIs(t, err, common.ErrNotFound)
+		}
+
+		err = sut.DeleteRefreshTokenFamily(first.FamilyID)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("families are independent", func(t *testing.T) {
+		t.Parallel()
+
+		revoked := NewRefreshToken(t, user.ID, uuid.New())
+		kept := NewRefreshToken(t, user.ID, uuid.New())
+		require.NoError(t, sut.SaveRefreshToken(revoked))
+		require.NoError(t, sut.SaveRefreshToken(kept))
+
+		require.NoError(t, sut.DeleteRefreshTokenFamily(revoked.FamilyID))
+
+		_, err := sut.GetRefreshToken(kept.Hash)
+		assert.NoError(t, err)
+	})
+}
+
+func testRefreshTokensOfDeletedUser(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	deleted := NewUser(t)
+	kept := NewUser(t)
+	require.NoError(t, sut.Save(deleted))
+	require.NoError(t, sut.Save(kept))
+
+	deletedToken := NewRefreshToken(t, deleted.ID, uuid.New())
+	keptToken := NewRefreshToken(t, kept.ID, uuid.New())
+	require.NoError(t, sut.SaveRefreshToken(deletedToken))
+	require.NoError(t, sut.SaveRefreshToken(keptToken))
+
+	require.NoError(t, sut.Delete(deleted.ID, 0))
+
+	_, err := sut.GetRefreshToken(deletedToken.Hash)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	_, err = sut.GetRefreshToken(keptToken.Hash)
+	assert.NoError(t, err)
+}
+
+func testRefreshTokensOfUser(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	revoked := NewUser(t)
+	kept := NewUser(t)
+	require.NoError(t, sut.Save(revoked))
+	require.NoError(t, sut.Save(kept))
+
+	first := NewRefreshToken(t, revoked.ID, uuid.New())
+	second := NewRefreshToken(t, revoked.ID, uuid.New())
+	rotated := NewRefreshToken(t, revoked.ID, first.FamilyID)
+	keptToken := NewRefreshToken(t, kept.ID, uuid.New())
+	require.NoError(t, sut.SaveRefreshToken(first))
+	require.NoError(t, sut.SaveRefreshToken(second))
+	require.NoError(t, sut.RotateRefreshToken(first.Hash, rotated))
+	require.NoError(t, sut.SaveRefreshToken(keptToken))
+
+	require.NoError(t, sut.DeleteRefreshTokensOfUser(revoked.ID))
+
+	for _, token := range []*models.RefreshToken{first, second, rotated} {
+		_, err := sut.GetRefreshToken(token.Hash)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	}
+
+	_, err := sut.GetRefreshToken(keptToken.Hash)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sut.DeleteRefreshTokensOfUser(revoked.ID), "a user without tokens is not an error")
+	assert.NoError(t, sut.DeleteRefreshTokensOfUser(uuid.New()), "a missing user is not an error")
+}
+
+// NewRefreshToken returns a refresh token of the user with a random hash.
+func NewRefreshToken(t *testing.T, userID uuid.UUID, familyID uuid.UUID) *models.RefreshToken {
+	t.Helper()
+
+	const hashSize = 32
+
+	hash := make([]byte, hashSize)
+	_, err := rand.Read(hash)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	return &models.RefreshToken{
+		Hash:      hash,
+		FamilyID:  familyID,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+		Rotated:   false,
+	}
+}
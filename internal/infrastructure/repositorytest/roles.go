@@ -0,0 +1,71 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testRoles(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	roles, err := sut.ListRoles()
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	_, err = sut.GetRole("support")
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	support := NewRole("support", models.PermissionUsersRead)
+	auditor := NewRole("auditor", models.PermissionUsersRead, models.PermissionRolesManage)
+
+	require.NoError(t, sut.SaveRole(support))
+	require.NoError(t, sut.SaveRole(auditor))
+	assert.ErrorIs(t, sut.SaveRole(NewRole("support")), common.ErrAlreadyExists)
+
+	support.Permissions[0] = models.PermissionUsersDelete
+
+	actual, err := sut.GetRole("support")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Permission{models.PermissionUsersRead}, actual.Permissions,
+		"stored role must not alias the argument")
+
+	roles, err = sut.ListRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	assert.Equal(t, auditor, roles[0])
+	assert.Equal(t, "support", roles[1].Name)
+}
+
+func testUserRoles(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	user := NewUser(t)
+	user.Roles = []string{models.SuperuserRole, "support"}
+	require.NoError(t, sut.Save(user))
+
+	user.Roles[1] = "auditor"
+
+	actual, err := sut.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.SuperuserRole, "support"}, actual.Roles,
+		"stored user must not alias the argument")
+}
+
+// NewRole returns a custom role with the permissions.
+func NewRole(name string, permissions ...models.Permission) *models.Role {
+	return &models.Role{
+		Name:        name,
+		Permissions: permissions,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
@@ -0,0 +1,681 @@
+// Package repositorytest contains the conformance suite that every repository backend must pass.
+package repositorytest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+type Repository interface {
+	usecases.UserRepository
+	usecases.RefreshTokenRepository
+	usecases.APIKeyRepository
+	usecases.RoleRepository
+	usecases.OrganizationRepository
+	usecases.TupleRepository
+	usecases.GroupRepository
+}
+
+// Factory returns a new empty repository. It is responsible for releasing the repository with t.Cleanup.
+type Factory func(t *testing.T) Repository
+
+func Run(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	t.Run("Save", func(t *testing.T) {
+		t.Parallel()
+
+		testSave(t, newRepository)
+	})
+	t.Run("GetByID", func(t *testing.T) {
+		t.Parallel()
+
+		testGetByID(t, newRepository)
+	})
+	t.Run("GetAll", func(t *testing.T) {
+		t.Parallel()
+
+		testGetAll(t, newRepository)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		t.Parallel()
+
+		testDelete(t, newRepository)
+	})
+	t.Run("GetByUsername", func(t *testing.T) {
+		t.Parallel()
+
+		testGetByUsername(t, newRepository)
+	})
+	t.Run("Uniqueness", func(t *testing.T) {
+		t.Parallel()
+
+		testUniqueness(t, newRepository)
+	})
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		t.Parallel()
+
+		testConcurrentCreate(t, newRepository)
+	})
+	t.Run("Versioning", func(t *testing.T) {
+		t.Parallel()
+
+		testVersioning(t, newRepository)
+	})
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		testList(t, newRepository)
+	})
+	t.Run("ListCursorAfterDelete", func(t *testing.T) {
+		t.Parallel()
+
+		testListCursorAfterDelete(t, newRepository)
+	})
+	t.Run("RefreshTokens", func(t *testing.T) {
+		t.Parallel()
+
+		testRefreshTokens(t, newRepository)
+	})
+	t.Run("RefreshTokensOfDeletedUser", func(t *testing.T) {
+		t.Parallel()
+
+		testRefreshTokensOfDeletedUser(t, newRepository)
+	})
+	t.Run("RefreshTokensOfUser", func(t *testing.T) {
+		t.Parallel()
+
+		testRefreshTokensOfUser(t, newRepository)
+	})
+	t.Run("APIKeys", func(t *testing.T) {
+		t.Parallel()
+
+		testAPIKeys(t, newRepository)
+	})
+	t.Run("APIKeysOfDeletedUser", func(t *testing.T) {
+		t.Parallel()
+
+		testAPIKeysOfDeletedUser(t, newRepository)
+	})
+	t.Run("Roles", func(t *testing.T) {
+		t.Parallel()
+
+		testRoles(t, newRepository)
+	})
+	t.Run("UserRoles", func(t *testing.T) {
+		t.Parallel()
+
+		testUserRoles(t, newRepository)
+	})
+	t.Run("Organizations", func(t *testing.T) {
+		t.Parallel()
+
+		testOrganizations(t, newRepository)
+	})
+	t.Run("UsersOfOrganizations", func(t *testing.T) {
+		t.Parallel()
+
+		testUsersOfOrganizations(t, newRepository)
+	})
+	t.Run("Tuples", func(t *testing.T) {
+		t.Parallel()
+
+		testTuples(t, newRepository)
+	})
+	t.Run("TuplesOfDeletedUser", func(t *testing.T) {
+		t.Parallel()
+
+		testTuplesOfDeletedUser(t, newRepository)
+	})
+	t.Run("Groups", func(t *testing.T) {
+		t.Parallel()
+
+		testGroups(t, newRepository)
+	})
+	t.Run("GroupsOfDeletedMembers", func(t *testing.T) {
+		t.Parallel()
+
+		testGroupsOfDeletedMembers(t, newRepository)
+	})
+}
+
+func testSave(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	testUser := NewUser(t)
+	sut := newRepository(t)
+
+	err := sut.Save(testUser)
+	assert.NoError(t, err)
+}
+
+func testGetByID(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		testUser := NewUser(t)
+		err := sut.Save(testUser)
+		require.NoError(t, err)
+
+		user, err := sut.GetByID(testUser.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, testUser, user)
+	})
+
+	t.Run("pending email verification", func(t *testing.T) {
+		t.Parallel()
+
+		testUser := NewUser(t)
+		testUser.EmailVerification = &models.EmailVerification{
+			TokenHash: []byte("hash"),
+			ExpiresAt: time.Now().Add(time.Hour).UTC(),
+		}
+		err := sut.Save(testUser)
+		require.NoError(t, err)
+
+		user, err := sut.GetByID(testUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, testUser.EmailVerification, user.EmailVerification)
+		assert.NotSame(t, testUser.EmailVerification, user.EmailVerification)
+	})
+
+	t.Run("pending password reset", func(t *testing.T) {
+		t.Parallel()
+
+		testUser := NewUser(t)
+		testUser.PasswordReset = &models.PasswordReset{
+			TokenHash: []byte("hash"),
+			ExpiresAt: time.Now().Add(time.Hour).UTC(),
+		}
+		err := sut.Save(testUser)
+		require.NoError(t, err)
+
+		user, err := sut.GetByID(testUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, testUser.PasswordReset, user.PasswordReset)
+		assert.NotSame(t, testUser.PasswordReset, user.PasswordReset)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		user, err := sut.GetByID(uuid.New())
+
+		assert.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, user)
+	})
+}
+
+func testGetAll(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	const usersCount = 10
+	savedIDs := make([]uuid.UUID, usersCount)
+
+	sut := newRepository(t)
+
+	for i := 0; i < usersCount; i++ {
+		user := NewUser(t)
+		err := sut.Save(user)
+		require.NoError(t, err)
+		savedIDs[i] = user.ID
+	}
+
+	users, err := sut.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, users, usersCount)
+
+	for _, user := range users {
+		assert.Contains(t, savedIDs, user.ID)
+	}
+}
+
+func testDelete(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		testUser := NewUser(t)
+		err := sut.Save(testUser)
+		require.NoError(t, err)
+
+		actual, err := sut.GetByID(testUser.ID)
+		require.NoError(t, err)
+		require.Equal(t, testUser, actual)
+
+		err = sut.Delete(testUser.ID, testUser.Version)
+		assert.NoError(t, err)
+
+		actual, err = sut.GetByID(testUser.ID)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, actual)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		err := sut.Delete(uuid.New(), 0)
+
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+}
+
+func testGetByUsername(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		testUser := NewUser(t)
+		err := sut.Save(testUser)
+		require.NoError(t, err)
+
+		user, err := sut.GetByUsername(testUser.Organization, testUser.Username)
+		assert.NoError(t, err)
+		assert.Equal(t, testUser, user)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		user, err := sut.GetByUsername(models.DefaultOrganization, "not_found")
+
+		assert.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, user)
+	})
+}
+
+func testUniqueness(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	existing := NewUser(t)
+	require.NoError(t, sut.Save(existing))
+
+	t.Run("duplicate username", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		user.Username = existing.Username
+
+		err := sut.Save(user)
+		assert.ErrorIs(t, err, common.ErrAlreadyExists)
+
+		_, err = sut.GetByID(user.ID)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		user.Email = strings.ToUpper(existing.Email)
+
+		err := sut.Save(user)
+		assert.ErrorIs(t, err, common.ErrAlreadyExists)
+	})
+
+	t.Run("rename to taken username", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		renamed := *user
+		renamed.Username = existing.Username
+
+		err := sut.Save(&renamed)
+		assert.ErrorIs(t, err, common.ErrAlreadyExists)
+
+		actual, err := sut.GetByUsername(user.Organization, user.Username)
+		assert.NoError(t, err)
+		assert.Equal(t, user, actual)
+	})
+
+	t.Run("rename releases old username", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		renamed := *user
+		renamed.Username = "renamed_" + user.Username
+		require.NoError(t, sut.Save(&renamed))
+
+		_, err := sut.GetByUsername(user.Organization, user.Username)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+
+		actual, err := sut.GetByUsername(renamed.Organization, renamed.Username)
+		assert.NoError(t, err)
+		assert.Equal(t, &renamed, actual)
+
+		another := NewUser(t)
+		another.Username = user.Username
+		assert.NoError(t, sut.Save(another))
+	})
+
+	t.Run("delete releases username", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+		require.NoError(t, sut.Delete(user.ID, 0))
+
+		another := NewUser(t)
+		another.Username = user.Username
+		another.Email = user.Email
+		assert.NoError(t, sut.Save(another))
+	})
+}
+
+func testConcurrentCreate(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	const attempts = 16
+
+	sut := newRepository(t)
+	template := NewUser(t)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			user := *template
+			user.ID = uuid.New()
+
+			err := sut.Save(&user)
+			if err == nil {
+				succeeded.Add(1)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, common.ErrAlreadyExists)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded.Load())
+
+	users, err := sut.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func testVersioning(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	t.Run("save increments version", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+		assert.Equal(t, uint64(1), user.Version)
+
+		require.NoError(t, sut.Save(user))
+		assert.Equal(t, uint64(2), user.Version)
+
+		actual, err := sut.GetByID(user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), actual.Version)
+	})
+
+	t.Run("stale save", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		first, second := *user, *user
+		require.NoError(t, sut.Save(&first))
+
+		second.Username = "stale_" + second.Username
+		err := sut.Save(&second)
+		assert.ErrorIs(t, err, common.ErrConflict)
+		assert.Equal(t, user.Version, second.Version)
+
+		actual, err := sut.GetByID(user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &first, actual)
+	})
+
+	t.Run("create existing", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		duplicate := *user
+		duplicate.Version = 0
+
+		err := sut.Save(&duplicate)
+		assert.ErrorIs(t, err, common.ErrConflict)
+	})
+
+	t.Run("stale delete", func(t *testing.T) {
+		t.Parallel()
+
+		user := NewUser(t)
+		require.NoError(t, sut.Save(user))
+
+		staleVersion := user.Version
+		require.NoError(t, sut.Save(user))
+
+		err := sut.Delete(user.ID, staleVersion)
+		assert.ErrorIs(t, err, common.ErrConflict)
+
+		_, err = sut.GetByID(user.ID)
+		assert.NoError(t, err)
+
+		err = sut.Delete(user.ID, user.Version)
+		assert.NoError(t, err)
+	})
+}
+
+func testList(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+	createdAt := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// Usernames, emails and creation times are ordered differently to tell the orders apart.
+	for i, username := range []string{"c", "a", "d", "b", "e"} {
+		user := NewUser(t)
+		user.Username = username
+		user.Email = fmt.Sprintf("%d@example.com", 4-i)
+		if i%2 == 0 {
+			user.Roles = []string{models.SuperuserRole}
+		}
+		user.CreatedAt = createdAt.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, sut.Save(user))
+	}
+
+	testCases := []struct {
+		name       string
+		orderBy    models.UserOrderField
+		descending bool
+		match      func(user *models.User) (bool, error)
+		expected   []string
+	}{
+		{
+			name:     "by username",
+			orderBy:  models.UserOrderByUsername,
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:       "by username descending",
+			orderBy:    models.UserOrderByUsername,
+			descending: true,
+			expected:   []string{"e", "d", "c", "b", "a"},
+		},
+		{
+			name:     "by email",
+			orderBy:  models.UserOrderByEmail,
+			expected: []string{"e", "b", "d", "a", "c"},
+		},
+		{
+			name:     "by create time",
+			orderBy:  models.UserOrderByCreateTime,
+			expected: []string{"c", "a", "d", "b", "e"},
+		},
+		{
+			name:    "filtered",
+			orderBy: models.UserOrderByUsername,
+			match: func(user *models.User) (bool, error) {
+				return slices.Contains(user.Roles, models.SuperuserRole), nil
+			},
+			expected: []string{"c", "d", "e"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, limit := range []int{1, 2, 5, 10} {
+				actual := make([]string, 0, len(tc.expected))
+				query := &models.UserListQuery{
+					Organization: models.DefaultOrganization,
+					OrderBy:      tc.orderBy,
+					Descending:   tc.descending,
+					After:        nil,
+					Limit:        limit,
+					Match:        tc.match,
+				}
+
+				for {
+					page, err := sut.List(query)
+					require.NoError(t, err)
+					assert.LessOrEqual(t, len(page.Users), limit)
+
+					for _, user := range page.Users {
+						actual = append(actual, user.Username)
+					}
+
+					if page.Next == nil {
+						break
+					}
+
+					query.After = page.Next
+				}
+
+				assert.Equal(t, tc.expected, actual, "limit %d", limit)
+			}
+		})
+	}
+
+	t.Run("match error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("match error")
+
+		_, err := sut.List(&models.UserListQuery{
+			Organization: models.DefaultOrganization,
+			OrderBy:      models.UserOrderByUsername,
+			Descending:   false,
+			After:        nil,
+			Limit:        1,
+			Match: func(*models.User) (bool, error) {
+				return false, expectedErr
+			},
+		})
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func testListCursorAfterDelete(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	users := make([]*models.User, 3)
+	for i, username := range []string{"a", "b", "c"} {
+		users[i] = NewUser(t)
+		users[i].Username = username
+		require.NoError(t, sut.Save(users[i]))
+	}
+
+	query := &models.UserListQuery{
+		Organization: models.DefaultOrganization,
+		OrderBy:      models.UserOrderByUsername,
+		Descending:   false,
+		After:        nil,
+		Limit:        1,
+		Match:        nil,
+	}
+
+	page, err := sut.List(query)
+	require.NoError(t, err)
+	require.NotNil(t, page.Next)
+
+	// The user the cursor points to is gone, but the position is still well-defined.
+	require.NoError(t, sut.Delete(users[0].ID, 0))
+
+	query.After = page.Next
+	query.Limit = 10
+
+	page, err = sut.List(query)
+	require.NoError(t, err)
+	require.Len(t, page.Users, 2)
+	assert.Equal(t, "b", page.Users[0].Username)
+	assert.Equal(t, "c", page.Users[1].Username)
+}
+
+var testPasswordHash = sync.OnceValues(func() ([]byte, error) { //nolint:gochecknoglobals
+	return bcrypt.GenerateFromPassword([]byte("test"), bcrypt.DefaultCost)
+})
+
+// NewUser returns a user with a unique username and email.
+func NewUser(t *testing.T) *models.User {
+	t.Helper()
+
+	passwordHash, err := testPasswordHash()
+	require.NoError(t, err)
+
+	id := uuid.New()
+
+	return &models.User{
+		ID:           id,
+		Organization: models.DefaultOrganization,
+		Username:     "test_" + id.String(),
+		Email:        "test." + id.String() + "@gmail.com",
+		PasswordHash: passwordHash,
+		Roles:        nil,
+	}
+}
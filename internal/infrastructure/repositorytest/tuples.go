@@ -0,0 +1,157 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func testTuples(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	revision, err := sut.TupleRevision()
+	require.NoError(t, err)
+	assert.Zero(t, revision)
+
+	ownerOfReadme := NewTuple("document", "readme", "owner", "user", "alice", "")
+	editorOfReadme := NewTuple("document", "readme", "editor", "group", "eng", "member")
+	editorOfGuide := NewTuple("document", "guide", "editor", "group", "eng", "member")
+	memberOfEng := NewTuple("group", "eng", "member", "user", "bob", "")
+
+	written, err := sut.WriteTuples([]*models.Tuple{ownerOfReadme, editorOfReadme, editorOfGuide, memberOfEng}, nil)
+	require.NoError(t, err)
+	assert.Greater(t, written, revision)
+
+	revision, err = sut.TupleRevision()
+	require.NoError(t, err)
+	assert.Equal(t, written, revision)
+
+	t.Run("rewriting existing tuples does not change the revision", func(t *testing.T) {
+		unchanged, err := sut.WriteTuples([]*models.Tuple{ownerOfReadme}, []*models.Tuple{
+			NewTuple("document", "missing", "owner", "user", "alice", ""),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, written, unchanged)
+	})
+
+	t.Run("read by object and relation", func(t *testing.T) {
+		tuples, err := sut.ReadTuples(&models.TupleFilter{
+			Organization: models.DefaultOrganization,
+			Namespace:    "document",
+			ObjectID:     "readme",
+			Relation:     "editor",
+			Subject:      nil,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.Tuple{editorOfReadme}, tuples)
+	})
+
+	t.Run("read by namespace and subject", func(t *testing.T) {
+		tuples, err := sut.ReadTuples(&models.TupleFilter{
+			Organization: models.DefaultOrganization,
+			Namespace:    "document",
+			ObjectID:     "",
+			Relation:     "",
+			Subject:      &editorOfReadme.Subject,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.Tuple{editorOfGuide, editorOfReadme}, tuples)
+	})
+
+	t.Run("organizations have their own tuples", func(t *testing.T) {
+		other := NewTuple("document", "readme", "editor", "group", "eng", "member")
+		other.Organization = "acme"
+
+		tuples, err := sut.ReadTuples(&models.TupleFilter{
+			Organization: other.Organization,
+			Namespace:    "document",
+			ObjectID:     "readme",
+			Relation:     "editor",
+			Subject:      nil,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, tuples)
+
+		unchanged, err := sut.WriteTuples(nil, []*models.Tuple{other})
+		require.NoError(t, err)
+		assert.Equal(t, written, unchanged, "deleting a tuple of another organization deletes nothing")
+	})
+
+	t.Run("deletes apply before writes", func(t *testing.T) {
+		viewerOfReadme := NewTuple("document", "readme", "viewer", "user", "bob", "")
+
+		next, err := sut.WriteTuples(
+			[]*models.Tuple{viewerOfReadme, editorOfReadme},
+			[]*models.Tuple{editorOfReadme, ownerOfReadme},
+		)
+		require.NoError(t, err)
+		assert.Greater(t, next, written)
+
+		tuples, err := sut.ReadTuples(&models.TupleFilter{
+			Organization: models.DefaultOrganization,
+			Namespace:    "document",
+			ObjectID:     "readme",
+			Relation:     "",
+			Subject:      nil,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.Tuple{editorOfReadme, viewerOfReadme}, tuples)
+	})
+}
+
+func testTuplesOfDeletedUser(t *testing.T, newRepository Factory) {
+	t.Helper()
+
+	sut := newRepository(t)
+
+	deleted := NewUser(t)
+	kept := NewUser(t)
+	require.NoError(t, sut.Save(deleted))
+	require.NoError(t, sut.Save(kept))
+
+	deletedTuple := NewTuple("group", "eng", "member", models.UserNamespace, deleted.ID.String(), "")
+	keptTuple := NewTuple("group", "eng", "member", models.UserNamespace, kept.ID.String(), "")
+	written, err := sut.WriteTuples([]*models.Tuple{deletedTuple, keptTuple}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, sut.Delete(deleted.ID, 0))
+
+	tuples, err := sut.ReadTuples(&models.TupleFilter{
+		Organization: models.DefaultOrganization,
+		Namespace:    "group",
+		ObjectID:     "eng",
+		Relation:     "member",
+		Subject:      nil,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.Tuple{keptTuple}, tuples)
+
+	revision, err := sut.TupleRevision()
+	require.NoError(t, err)
+	assert.Greater(t, revision, written, "deleting tuples with the user must advance the revision")
+}
+
+// NewTuple returns the tuple namespace:id#relation@subjectNamespace:subjectID#subjectRelation of the default organization.
+func NewTuple(
+	namespace string,
+	id string,
+	relation string,
+	subjectNamespace string,
+	subjectID string,
+	subjectRelation string,
+) *models.Tuple {
+	return &models.Tuple{
+		Organization: models.DefaultOrganization,
+		Object:       models.Object{Namespace: namespace, ID: id},
+		Relation:     relation,
+		Subject: models.Subject{
+			Object:   models.Object{Namespace: subjectNamespace, ID: subjectID},
+			Relation: subjectRelation,
+		},
+	}
+}
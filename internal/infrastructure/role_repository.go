@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// SaveRole creates the role. It fails with common.ErrAlreadyExists if a role with the same name exists.
+func (r *Repository) SaveRole(role *models.Role) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	_, exists := r.roles[role.Name]
+	r.stateMu.RUnlock()
+
+	if exists {
+		return fmt.Errorf("%w: role %q already exists", common.ErrAlreadyExists, role.Name)
+	}
+
+	stored := *role
+	stored.Permissions = slices.Clone(role.Permissions)
+
+	return r.commit(&walRecord{
+		Op:   walOpSaveRole,
+		Role: &stored,
+	})
+}
+
+func (r *Repository) GetRole(name string) (*models.Role, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if role, ok := r.roles[name]; ok {
+		return role, nil
+	}
+
+	return nil, fmt.Errorf("%w: role %q not found", common.ErrNotFound, name)
+}
+
+// ListRoles returns all roles ordered by name.
+func (r *Repository) ListRoles() ([]*models.Role, error) {
+	roles := r.getAllRoles()
+
+	slices.SortFunc(roles, func(a, b *models.Role) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return roles, nil
+}
+
+func (r *Repository) getAllRoles() []*models.Role {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	roles := make([]*models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+func (r *Repository) storeRole(role *models.Role) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.roles[role.Name] = role
+}
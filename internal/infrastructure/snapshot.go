@@ -0,0 +1,105 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// snapshot is a point-in-time copy of the repository that covers all log records up to LSN.
+// It is stored as a single frame in the same format as the log records.
+type snapshot struct {
+	LSN           uint64                 `json:"lsn"`
+	Users         []*models.User         `json:"users"`
+	RefreshTokens []*models.RefreshToken `json:"refreshTokens"`
+	APIKeys       []*models.APIKey       `json:"apiKeys"`
+	Roles         []*models.Role         `json:"roles"`
+	Organizations []*models.Organization `json:"organizations"`
+	Groups        []*models.Group        `json:"groups"`
+	Tuples        []*models.Tuple        `json:"tuples"`
+	TupleRevision uint64                 `json:"tupleRevision"`
+}
+
+func readSnapshot(path string) (*snapshot, error) {
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil //nolint:nilnil
+	default:
+		return nil, fmt.Errorf("failed to read snapshot %q: %w", path, err)
+	}
+
+	// Snapshots are swapped in atomically, so unlike the log tail a damaged snapshot is not expected
+	// and is reported instead of being silently dropped.
+	payload, _, err := readFrame(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %q is corrupted: %w", path, err)
+	}
+
+	snap := new(snapshot)
+	if err := json.Unmarshal(payload, snap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot %q: %w", path, err)
+	}
+
+	return snap, nil
+}
+
+func writeSnapshot(path string, snap *snapshot) error {
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gomnd
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	if _, err := tmp.Write(encodeFrame(payload)); err != nil {
+		return errors.Join(fmt.Errorf("failed to write snapshot: %w", err), tmp.Close(), os.Remove(tmpPath))
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync snapshot: %w", err), tmp.Close(), os.Remove(tmpPath))
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to close snapshot: %w", err), os.Remove(tmpPath))
+	}
+
+	if err := renameFile(tmpPath, path); err != nil {
+		return errors.Join(err, os.Remove(tmpPath))
+	}
+
+	return nil
+}
+
+// renameFile atomically replaces the destination and makes the rename itself durable.
+func renameFile(from string, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %w", from, to, err)
+	}
+
+	dir, err := os.Open(filepath.Dir(to))
+	if err != nil {
+		return fmt.Errorf("failed to open directory of %q: %w", to, err)
+	}
+
+	if err := dir.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync directory of %q: %w", to, err), dir.Close())
+	}
+
+	if err := dir.Close(); err != nil {
+		return fmt.Errorf("failed to close directory of %q: %w", to, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,424 @@
+package infrastructure_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure/repositorytest"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+func TestRepository_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "users.wal")
+
+	beforeSnapshot := createTestUser(t)
+	deletedBeforeSnapshot := createTestUser(t)
+	afterSnapshot := createTestUser(t)
+
+	repo := openDurableRepository(t, dir)
+	require.NoError(t, repo.Save(beforeSnapshot))
+	require.NoError(t, repo.Save(deletedBeforeSnapshot))
+	require.NoError(t, repo.Delete(deletedBeforeSnapshot.ID, 0))
+
+	require.NoError(t, repo.Snapshot())
+
+	info, err := os.Stat(walPath)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "log records covered by the snapshot must be truncated")
+
+	require.NoError(t, repo.Save(afterSnapshot))
+	require.NoError(t, repo.Close())
+
+	repo = openDurableRepository(t, dir)
+	defer func() {
+		assert.NoError(t, repo.Close())
+	}()
+
+	actual, err := repo.GetByID(beforeSnapshot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, beforeSnapshot, actual)
+
+	actual, err = repo.GetByID(afterSnapshot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, afterSnapshot, actual)
+
+	_, err = repo.GetByID(deletedBeforeSnapshot.ID)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+}
+
+func TestRepository_SnapshotThreshold(t *testing.T) {
+	t.Parallel()
+
+	const threshold = 3
+
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "users.snapshot")
+
+	repo := openDurableRepository(t, dir, infrastructure.WithSnapshotThreshold(threshold))
+	defer func() {
+		assert.NoError(t, repo.Close())
+	}()
+
+	for i := 0; i < threshold-1; i++ {
+		require.NoError(t, repo.Save(createTestUser(t)))
+	}
+
+	assert.NoFileExists(t, snapshotPath)
+
+	require.NoError(t, repo.Save(createTestUser(t)))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(snapshotPath)
+
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRepository_SnapshotInterval(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "users.wal")
+
+	repo := openDurableRepository(t, dir, infrastructure.WithSnapshotInterval(10*time.Millisecond))
+	require.NoError(t, repo.Save(createTestUser(t)))
+
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(walPath)
+
+		return err == nil && info.Size() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, repo.Close())
+
+	repo = openDurableRepository(t, dir)
+	defer func() {
+		assert.NoError(t, repo.Close())
+	}()
+
+	users, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestRepository_RefreshTokensRecovery(t *testing.T) {
+	t.Parallel()
+
+	for name, snapshot := range map[string]bool{
+		"from log":      false,
+		"from snapshot": true,
+	} {
+		snapshot := snapshot
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			user := createTestUser(t)
+			rotated := repositorytest.NewRefreshToken(t, user.ID, uuid.New())
+			next := repositorytest.NewRefreshToken(t, user.ID, rotated.FamilyID)
+			revoked := repositorytest.NewRefreshToken(t, user.ID, uuid.New())
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.Save(user))
+			require.NoError(t, repo.SaveRefreshToken(rotated))
+			require.NoError(t, repo.RotateRefreshToken(rotated.Hash, next))
+			require.NoError(t, repo.SaveRefreshToken(revoked))
+			require.NoError(t, repo.DeleteRefreshTokenFamily(revoked.FamilyID))
+
+			if snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			actual, err := repo.GetRefreshToken(rotated.Hash)
+			require.NoError(t, err)
+			assert.True(t, actual.Rotated)
+
+			actual, err = repo.GetRefreshToken(next.Hash)
+			require.NoError(t, err)
+			assert.Equal(t, next, actual)
+
+			_, err = repo.GetRefreshToken(revoked.Hash)
+			assert.ErrorIs(t, err, common.ErrNotFound)
+
+			// The family index is recovered too, so deleting the user still drops its tokens.
+			require.NoError(t, repo.Delete(user.ID, 0))
+
+			_, err = repo.GetRefreshToken(next.Hash)
+			assert.ErrorIs(t, err, common.ErrNotFound)
+		})
+	}
+}
+
+func TestRepository_APIKeysRecovery(t *testing.T) {
+	t.Parallel()
+
+	for name, snapshot := range map[string]bool{
+		"from log":      false,
+		"from snapshot": true,
+	} {
+		snapshot := snapshot
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			user := createTestUser(t)
+			kept := repositorytest.NewAPIKey(t, user.ID)
+			revoked := repositorytest.NewAPIKey(t, user.ID)
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.Save(user))
+			require.NoError(t, repo.SaveAPIKey(kept))
+			require.NoError(t, repo.SaveAPIKey(revoked))
+			require.NoError(t, repo.DeleteAPIKey(revoked.ID))
+
+			if snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			actual, err := repo.GetAPIKeyByHash(kept.Hash)
+			require.NoError(t, err)
+			assert.Equal(t, kept, actual)
+
+			_, err = repo.GetAPIKeyByHash(revoked.Hash)
+			assert.ErrorIs(t, err, common.ErrNotFound)
+		})
+	}
+}
+
+func TestRepository_RolesRecovery(t *testing.T) {
+	t.Parallel()
+
+	for name, snapshot := range map[string]bool{
+		"from log":      false,
+		"from snapshot": true,
+	} {
+		snapshot := snapshot
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			role := repositorytest.NewRole("support", models.PermissionUsersRead)
+			user := createTestUser(t)
+			user.Roles = []string{role.Name}
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.SaveRole(role))
+			require.NoError(t, repo.Save(user))
+
+			if snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			actualRole, err := repo.GetRole(role.Name)
+			require.NoError(t, err)
+			assert.Equal(t, role, actualRole)
+
+			actualUser, err := repo.GetByID(user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, user.Roles, actualUser.Roles)
+		})
+	}
+}
+
+func TestRepository_GroupsRecovery(t *testing.T) {
+	t.Parallel()
+
+	for name, snapshot := range map[string]bool{
+		"from log":      false,
+		"from snapshot": true,
+	} {
+		snapshot := snapshot
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			user := createTestUser(t)
+			nested := repositorytest.NewGroup("nested", "support")
+			group := repositorytest.NewGroup("eng")
+			group.UserIDs = []uuid.UUID{user.ID}
+			group.GroupIDs = []uuid.UUID{nested.ID}
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.Save(user))
+			require.NoError(t, repo.SaveGroup(nested))
+			require.NoError(t, repo.SaveGroup(group))
+
+			if snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			actual, err := repo.GetGroup(group.ID)
+			require.NoError(t, err)
+			assert.Equal(t, group, actual)
+
+			groups, err := repo.GroupsOfMember(nested.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []*models.Group{group}, groups)
+		})
+	}
+}
+
+func TestRepository_TuplesRecovery(t *testing.T) {
+	t.Parallel()
+
+	for name, snapshot := range map[string]bool{
+		"from log":      false,
+		"from snapshot": true,
+	} {
+		snapshot := snapshot
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			kept := repositorytest.NewTuple("document", "readme", "editor", "group", "eng", "member")
+			deleted := repositorytest.NewTuple("document", "readme", "owner", "user", "alice", "")
+
+			repo := openDurableRepository(t, dir)
+			_, err := repo.WriteTuples([]*models.Tuple{kept, deleted}, nil)
+			require.NoError(t, err)
+			revision, err := repo.WriteTuples(nil, []*models.Tuple{deleted})
+			require.NoError(t, err)
+
+			if snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			tuples, err := repo.ReadTuples(&models.TupleFilter{
+				Organization: models.DefaultOrganization,
+				Namespace:    "document",
+				ObjectID:     "",
+				Relation:     "",
+				Subject:      nil,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []*models.Tuple{kept}, tuples)
+
+			actualRevision, err := repo.TupleRevision()
+			require.NoError(t, err)
+			assert.Equal(t, revision, actualRevision)
+		})
+	}
+}
+
+func TestRepository_LegacyAdminMigration(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	userID := uuid.New()
+	legacyUsers := fmt.Sprintf(
+		`[{"ID":%q,"Username":"admin","Email":"admin@example.com","Admin":true,"Version":1},`+
+			`{"ID":%q,"Username":"user","Email":"user@example.com","Admin":false,"Version":1}]`,
+		adminID, userID,
+	)
+
+	for name, write := range map[string]func(t *testing.T, dir string){
+		"from log": func(t *testing.T, dir string) {
+			t.Helper()
+
+			var users []json.RawMessage
+			require.NoError(t, json.Unmarshal([]byte(legacyUsers), &users))
+
+			records := make([]string, 0, len(users))
+			for i, user := range users {
+				records = append(records, fmt.Sprintf(`{"lsn":%d,"op":"save_user","user":%s}`, i+1, user))
+			}
+
+			writeFrames(t, filepath.Join(dir, "users.wal"), records...)
+		},
+		"from snapshot": func(t *testing.T, dir string) {
+			t.Helper()
+
+			writeFrames(t, filepath.Join(dir, "users.snapshot"), fmt.Sprintf(`{"lsn":2,"users":%s}`, legacyUsers))
+		},
+	} {
+		write := write
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			write(t, dir)
+
+			repo := openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			admin, err := repo.GetByID(adminID)
+			require.NoError(t, err)
+			assert.Equal(t, []string{models.SuperuserRole}, admin.Roles)
+
+			user, err := repo.GetByID(userID)
+			require.NoError(t, err)
+			assert.Empty(t, user.Roles)
+		})
+	}
+}
+
+// writeFrames writes the payloads in the format of the log records and snapshots.
+func writeFrames(t *testing.T, path string, payloads ...string) {
+	t.Helper()
+
+	const headerSize = 8
+
+	var content []byte
+
+	for _, payload := range payloads {
+		header := make([]byte, headerSize)
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.Checksum([]byte(payload), crc32.MakeTable(crc32.Castagnoli)))
+		content = append(content, header...)
+		content = append(content, payload...)
+	}
+
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
@@ -0,0 +1,173 @@
+package infrastructure
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// WriteTuples atomically deletes and then writes the tuples, and returns the revision of the tuples after the change.
+// Writing an existing tuple or deleting a missing one is not an error, and a call that changes nothing
+// returns the current revision.
+func (r *Repository) WriteTuples(writes []*models.Tuple, deletes []*models.Tuple) (uint64, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.stateMu.RLock()
+	changed := slices.ContainsFunc(deletes, r.hasTupleLocked) ||
+		slices.ContainsFunc(writes, func(tuple *models.Tuple) bool { return !r.hasTupleLocked(tuple) })
+	revision := r.tupleRevision
+	r.stateMu.RUnlock()
+
+	if !changed {
+		return revision, nil
+	}
+
+	record := &walRecord{
+		Op:           walOpWriteTuples,
+		TupleWrites:  cloneTuples(writes),
+		TupleDeletes: cloneTuples(deletes),
+	}
+	if err := r.commit(record); err != nil {
+		return 0, err
+	}
+
+	return record.LSN, nil
+}
+
+// ReadTuples returns the tuples that match the filter, ordered by object, relation and subject.
+func (r *Repository) ReadTuples(filter *models.TupleFilter) ([]*models.Tuple, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	tuples := make([]*models.Tuple, 0)
+
+	if filter.ObjectID != "" && filter.Relation != "" {
+		object := models.Object{Namespace: filter.Namespace, ID: filter.ObjectID}
+		key := usersetKey(filter.Organization, object, filter.Relation)
+
+		for _, tuple := range r.tuples[key] {
+			if filter.Matches(tuple) {
+				tuples = append(tuples, tuple)
+			}
+		}
+	} else {
+		for _, subjects := range r.tuples {
+			for _, tuple := range subjects {
+				if filter.Matches(tuple) {
+					tuples = append(tuples, tuple)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(tuples, func(a, b *models.Tuple) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return tuples, nil
+}
+
+// TupleRevision returns the revision of the last change of the tuples, which is zero before the first change.
+func (r *Repository) TupleRevision() (uint64, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	return r.tupleRevision, nil
+}
+
+func (r *Repository) getAllTuples() []*models.Tuple {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	tuples := make([]*models.Tuple, 0)
+	for _, subjects := range r.tuples {
+		for _, tuple := range subjects {
+			tuples = append(tuples, tuple)
+		}
+	}
+
+	return tuples
+}
+
+func (r *Repository) writeTuples(writes []*models.Tuple, deletes []*models.Tuple, revision uint64) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	for _, tuple := range deletes {
+		r.deleteTupleLocked(tuple)
+	}
+
+	for _, tuple := range writes {
+		r.storeTupleLocked(tuple)
+	}
+
+	r.tupleRevision = revision
+}
+
+func (r *Repository) hasTupleLocked(tuple *models.Tuple) bool {
+	_, ok := r.tuples[tupleUsersetKey(tuple)][tuple.Subject.String()]
+
+	return ok
+}
+
+func (r *Repository) storeTupleLocked(tuple *models.Tuple) {
+	key := tupleUsersetKey(tuple)
+
+	subjects, ok := r.tuples[key]
+	if !ok {
+		subjects = make(map[string]*models.Tuple)
+		r.tuples[key] = subjects
+	}
+
+	subjects[tuple.Subject.String()] = tuple
+}
+
+func (r *Repository) deleteTupleLocked(tuple *models.Tuple) {
+	key := tupleUsersetKey(tuple)
+
+	delete(r.tuples[key], tuple.Subject.String())
+
+	if len(r.tuples[key]) == 0 {
+		delete(r.tuples, key)
+	}
+}
+
+// deleteUserTuplesLocked deletes the tuples of the user as an object or a subject,
+// and reports whether there were any.
+func (r *Repository) deleteUserTuplesLocked(user models.Object) bool {
+	deleted := false
+
+	for _, subjects := range r.tuples {
+		for _, tuple := range subjects {
+			if tuple.Object == user || tuple.Subject.Object == user {
+				r.deleteTupleLocked(tuple)
+
+				deleted = true
+			}
+		}
+	}
+
+	return deleted
+}
+
+func tupleUsersetKey(tuple *models.Tuple) string {
+	return usersetKey(tuple.Organization, tuple.Object, tuple.Relation)
+}
+
+// usersetKey keys the tuples of the object and relation in the organization.
+// Organization names cannot contain a slash, so keys of different organizations never collide.
+func usersetKey(organization string, object models.Object, relation string) string {
+	return organization + "/" + object.String() + "#" + relation
+}
+
+func cloneTuples(tuples []*models.Tuple) []*models.Tuple {
+	cloned := make([]*models.Tuple, len(tuples))
+	for i, tuple := range tuples {
+		stored := *tuple
+		cloned[i] = &stored
+	}
+
+	return cloned
+}
@@ -0,0 +1,456 @@
+package infrastructure
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs the log after every record, so an acknowledged write survives a power loss.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsyncs the log periodically, so a crash may lose writes from the last interval.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+func ParseSyncPolicy(value string) (SyncPolicy, error) {
+	switch policy := SyncPolicy(value); policy {
+	case SyncAlways, SyncInterval, SyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown sync policy %q", value)
+	}
+}
+
+const (
+	walFrameHeaderSize = 8
+	walMaxRecordSize   = 16 << 20
+)
+
+var (
+	errTornRecord    = errors.New("torn record")
+	errCorruptRecord = errors.New("corrupted record")
+
+	crc32cTable = crc32.MakeTable(crc32.Castagnoli) //nolint:gochecknoglobals
+)
+
+type walOp string
+
+const (
+	walOpSaveUser                 walOp = "save_user"
+	walOpDeleteUser               walOp = "delete_user"
+	walOpSaveRefreshToken         walOp = "save_refresh_token"
+	walOpRotateRefreshToken       walOp = "rotate_refresh_token"
+	walOpDeleteRefreshTokenFamily walOp = "delete_refresh_token_family"
+	walOpDeleteUserRefreshTokens  walOp = "delete_user_refresh_tokens"
+	walOpSaveAPIKey               walOp = "save_api_key"
+	walOpDeleteAPIKey             walOp = "delete_api_key"
+	walOpSaveRole                 walOp = "save_role"
+	walOpSaveOrganization         walOp = "save_organization"
+	walOpSaveGroup                walOp = "save_group"
+	walOpDeleteGroup              walOp = "delete_group"
+	walOpWriteTuples              walOp = "write_tuples"
+)
+
+// walRecord is a change of the repository. Only the fields of its operation are set,
+// and LSN is assigned on commit.
+type walRecord struct {
+	LSN          uint64               `json:"lsn"`
+	Op           walOp                `json:"op"`
+	User         *models.User         `json:"user,omitempty"`
+	UserID       *uuid.UUID           `json:"userId,omitempty"`
+	RefreshToken *models.RefreshToken `json:"refreshToken,omitempty"`
+	// RefreshTokenHash is the hash of the rotated token.
+	RefreshTokenHash []byte     `json:"refreshTokenHash,omitempty"`
+	FamilyID         *uuid.UUID `json:"familyId,omitempty"`
+
+	APIKey   *models.APIKey `json:"apiKey,omitempty"`
+	APIKeyID *uuid.UUID     `json:"apiKeyId,omitempty"`
+
+	Role *models.Role `json:"role,omitempty"`
+
+	Organization *models.Organization `json:"organization,omitempty"`
+
+	Group   *models.Group `json:"group,omitempty"`
+	GroupID *uuid.UUID    `json:"groupId,omitempty"`
+
+	TupleWrites  []*models.Tuple `json:"tupleWrites,omitempty"`
+	TupleDeletes []*models.Tuple `json:"tupleDeletes,omitempty"`
+}
+
+// wal is an append-only log of framed records.
+// Every frame is a big-endian payload length, a CRC-32C of the payload and the JSON payload itself.
+type wal struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	size   int64
+	policy SyncPolicy
+	dirty  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type walReplayResult struct {
+	records   []*walRecord
+	validSize int64
+	tornBytes int64
+}
+
+func openWAL(path string, policy SyncPolicy, syncInterval time.Duration) (*wal, *walReplayResult, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gomnd
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open wal %q: %w", path, err)
+	}
+
+	result, err := replayWAL(file)
+	if err != nil {
+		return nil, nil, errors.Join(err, file.Close())
+	}
+
+	if result.tornBytes > 0 {
+		if err := truncateFile(file, result.validSize); err != nil {
+			return nil, nil, errors.Join(err, file.Close())
+		}
+	}
+
+	if _, err := file.Seek(result.validSize, io.SeekStart); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to seek wal: %w", err), file.Close())
+	}
+
+	w := &wal{
+		mu:     sync.Mutex{},
+		path:   path,
+		file:   file,
+		size:   result.validSize,
+		policy: policy,
+		dirty:  false,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if policy == SyncInterval {
+		go w.syncLoop(syncInterval)
+	} else {
+		close(w.done)
+	}
+
+	return w, result, nil
+}
+
+func replayWAL(file *os.File) (*walReplayResult, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat wal: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek wal: %w", err)
+	}
+
+	result := &walReplayResult{
+		records:   make([]*walRecord, 0),
+		validSize: 0,
+		tornBytes: 0,
+	}
+	reader := bufio.NewReader(file)
+
+	for {
+		payload, frameSize, err := readFrame(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err == nil {
+			record := new(walRecord)
+			if err = json.Unmarshal(payload, record); err == nil {
+				result.records = append(result.records, record)
+				result.validSize += frameSize
+
+				continue
+			}
+
+			err = fmt.Errorf("%w: %w", errCorruptRecord, err)
+		}
+
+		if !errors.Is(err, errTornRecord) && !errors.Is(err, errCorruptRecord) {
+			return nil, fmt.Errorf("failed to read wal: %w", err)
+		}
+
+		// Only the last frame can be torn by a crash, since every frame is written with a single call.
+		// A bad frame followed by more records is corruption, and dropping it would drop them as well.
+		torn, tailErr := isTornTail(file, result.validSize, info.Size())
+		if tailErr != nil {
+			return nil, tailErr
+		}
+
+		if !torn {
+			return nil, fmt.Errorf("wal is corrupted at offset %d: %w", result.validSize, err)
+		}
+
+		result.tornBytes = info.Size() - result.validSize
+
+		break
+	}
+
+	return result, nil
+}
+
+func (w *wal) append(record *walRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal wal record: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The frame is written with a single call, so a crash can only tear the last record.
+	frame := encodeFrame(payload)
+	if _, err := w.file.Write(frame); err != nil {
+		// A partially written frame is dropped, so it does not hide the records appended after it.
+		return errors.Join(fmt.Errorf("failed to write wal record: %w", err), w.rewind())
+	}
+
+	w.size += int64(len(frame))
+
+	if w.policy == SyncAlways {
+		if err := w.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync wal: %w", err)
+		}
+
+		return nil
+	}
+
+	w.dirty = true
+
+	return nil
+}
+
+func (w *wal) rewind() error {
+	if err := w.file.Truncate(w.size); err != nil {
+		return fmt.Errorf("failed to truncate wal: %w", err)
+	}
+
+	if _, err := w.file.Seek(w.size, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek wal: %w", err)
+	}
+
+	return nil
+}
+
+func (w *wal) currentSize() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.size
+}
+
+// compact drops the first offset bytes of the log, which must end on a record boundary.
+// The remaining tail is copied to a new file that atomically replaces the log.
+func (w *wal) compact(offset int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tmpPath := w.path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gomnd
+	if err != nil {
+		return fmt.Errorf("failed to create compacted wal: %w", err)
+	}
+
+	if err := copyTail(w.file, tmp, offset); err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmpPath))
+	}
+
+	if err := renameFile(tmpPath, w.path); err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmpPath))
+	}
+
+	if err := w.file.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to close compacted wal: %w", err), tmp.Close())
+	}
+
+	w.file = tmp
+	w.size -= offset
+	w.dirty = false
+
+	return nil
+}
+
+func copyTail(src *os.File, dst *os.File, offset int64) error {
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek wal: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to copy wal tail: %w", err)
+	}
+
+	if err := dst.Sync(); err != nil {
+		return fmt.Errorf("failed to sync compacted wal: %w", err)
+	}
+
+	// The source is opened without O_APPEND, so it has to be positioned back at its end.
+	if _, err := src.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("failed to seek wal: %w", err)
+	}
+
+	return nil
+}
+
+func (w *wal) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.dirty {
+		return nil
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+
+	w.dirty = false
+
+	return nil
+}
+
+func (w *wal) syncLoop(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			// A failed background sync is retried on the next tick and reported by close.
+			_ = w.sync()
+		}
+	}
+}
+
+func (w *wal) close() error {
+	close(w.stop)
+	<-w.done
+
+	syncErr := w.sync()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Close(); err != nil {
+		return errors.Join(syncErr, fmt.Errorf("failed to close wal: %w", err))
+	}
+
+	return syncErr
+}
+
+func encodeFrame(payload []byte) []byte {
+	frame := make([]byte, walFrameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crc32cTable))
+	copy(frame[walFrameHeaderSize:], payload)
+
+	return frame
+}
+
+// readFrame returns the payload of the next frame and the size of the whole frame.
+// The size is known as soon as the header is read, so it is also returned with errors of the payload.
+func readFrame(reader io.Reader) ([]byte, int64, error) {
+	header := make([]byte, walFrameHeaderSize)
+
+	_, err := io.ReadFull(reader, header)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		return nil, 0, io.EOF
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, 0, errTornRecord
+	default:
+		return nil, 0, fmt.Errorf("failed to read frame header: %w", err)
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	frameSize := int64(walFrameHeaderSize) + int64(size)
+
+	if size > walMaxRecordSize {
+		return nil, frameSize, fmt.Errorf("%w: frame size %d exceeds the limit", errCorruptRecord, size)
+	}
+
+	payload := make([]byte, size)
+
+	_, err = io.ReadFull(reader, payload)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, frameSize, errTornRecord
+	default:
+		return nil, frameSize, fmt.Errorf("failed to read frame payload: %w", err)
+	}
+
+	if crc32.Checksum(payload, crc32cTable) != checksum {
+		return nil, frameSize, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+	}
+
+	return payload, frameSize, nil
+}
+
+// isTornTail reports whether the bad frame at offset is the torn tail of the log: either the rest of the file
+// is shorter than a frame header, or it is zeros, which file systems may leave after a crash extended the file
+// but did not write its data. The length of a bad frame is not trusted, since a damaged length of a frame
+// in the middle of the log may as well point past the end of the file and hide the valid records after it.
+func isTornTail(file *os.File, offset int64, fileSize int64) (bool, error) {
+	if fileSize-offset < walFrameHeaderSize {
+		return true, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, fileSize-offset))
+
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("failed to read wal tail: %w", err)
+		}
+
+		if b != 0 {
+			return false, nil
+		}
+	}
+}
+
+func truncateFile(file *os.File, size int64) error {
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to truncate %q: %w", file.Name(), err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %q: %w", file.Name(), err)
+	}
+
+	return nil
+}
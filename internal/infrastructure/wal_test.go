@@ -0,0 +1,220 @@
+package infrastructure_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+)
+
+func TestRepository_WALReplay(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []infrastructure.SyncPolicy{
+		infrastructure.SyncAlways,
+		infrastructure.SyncInterval,
+		infrastructure.SyncNever,
+	} {
+		policy := policy
+		t.Run(string(policy), func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			kept := createTestUser(t)
+			deleted := createTestUser(t)
+
+			repo := openDurableRepository(t, dir, infrastructure.WithSyncPolicy(policy))
+			require.NoError(t, repo.Save(kept))
+			require.NoError(t, repo.Save(deleted))
+			require.NoError(t, repo.Delete(deleted.ID, 0))
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir, infrastructure.WithSyncPolicy(policy))
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			actual, err := repo.GetByID(kept.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, kept, actual)
+
+			_, err = repo.GetByID(deleted.ID)
+			assert.ErrorIs(t, err, common.ErrNotFound)
+		})
+	}
+}
+
+func TestRepository_WALTornTail(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		tail []byte
+	}{
+		{
+			name: "partial header",
+			tail: []byte{0x00, 0x00},
+		},
+		{
+			name: "partial header with zeros",
+			tail: []byte{0x00, 0x00, 0x00, 0x10, 0x00, 0x00},
+		},
+		{
+			name: "zeros",
+			tail: make([]byte, 64),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			walPath := filepath.Join(dir, "users.wal")
+			user := createTestUser(t)
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.Save(user))
+			require.NoError(t, repo.Close())
+
+			info, err := os.Stat(walPath)
+			require.NoError(t, err)
+			validSize := info.Size()
+
+			file, err := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0)
+			require.NoError(t, err)
+			_, err = file.Write(tc.tail)
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+
+			repo = openDurableRepository(t, dir)
+
+			actual, err := repo.GetByID(user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, user, actual)
+
+			info, err = os.Stat(walPath)
+			require.NoError(t, err)
+			assert.Equal(t, validSize, info.Size())
+
+			// Records written after the truncation must survive the next restart.
+			another := createTestUser(t)
+			require.NoError(t, repo.Save(another))
+			require.NoError(t, repo.Close())
+
+			repo = openDurableRepository(t, dir)
+			defer func() {
+				assert.NoError(t, repo.Close())
+			}()
+
+			users, err := repo.GetAll()
+			assert.NoError(t, err)
+			assert.Len(t, users, 2)
+		})
+	}
+}
+
+func TestRepository_WALCorruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// damage damages the log of two records, the first one of firstSize bytes,
+		// and returns the offset of the damaged record.
+		damage func(content []byte, firstSize int) ([]byte, int)
+	}{
+		{
+			name: "damaged payload",
+			damage: func(content []byte, _ int) ([]byte, int) {
+				content[10] ^= 0xff
+
+				return content, 0
+			},
+		},
+		{
+			name: "length past the end",
+			damage: func(content []byte, _ int) ([]byte, int) {
+				binary.BigEndian.PutUint32(content[0:4], uint32(len(content)))
+
+				return content, 0
+			},
+		},
+		{
+			name: "oversized length",
+			damage: func(content []byte, _ int) ([]byte, int) {
+				binary.BigEndian.PutUint32(content[0:4], 1<<31)
+
+				return content, 0
+			},
+		},
+		{
+			name: "damaged last record",
+			damage: func(content []byte, firstSize int) ([]byte, int) {
+				content[len(content)-1] ^= 0xff
+
+				return content, firstSize
+			},
+		},
+		{
+			name: "partial last record",
+			damage: func(content []byte, firstSize int) ([]byte, int) {
+				return content[:len(content)-1], firstSize
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			walPath := filepath.Join(dir, "users.wal")
+
+			repo := openDurableRepository(t, dir)
+			require.NoError(t, repo.Save(createTestUser(t)))
+
+			info, err := os.Stat(walPath)
+			require.NoError(t, err)
+			firstSize := int(info.Size())
+
+			require.NoError(t, repo.Save(createTestUser(t)))
+			require.NoError(t, repo.Close())
+
+			content, err := os.ReadFile(walPath)
+			require.NoError(t, err)
+
+			content, offset := tc.damage(content, firstSize)
+			require.NoError(t, os.WriteFile(walPath, content, 0o600))
+
+			_, err = infrastructure.NewRepository(infrastructure.WithDataDir(dir))
+			assert.ErrorContains(t, err, fmt.Sprintf("wal is corrupted at offset %d", offset))
+
+			actual, err := os.ReadFile(walPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, actual, "a corrupted log is not truncated")
+		})
+	}
+}
+
+func openDurableRepository(
+	t *testing.T,
+	dir string,
+	opts ...infrastructure.RepositoryOption,
+) *infrastructure.Repository {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository(append(opts, infrastructure.WithDataDir(dir))...)
+	require.NoError(t, err)
+
+	return repo
+}
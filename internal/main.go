@@ -2,15 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"math"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
 	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
 	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/policy"
+	"github.com/ScareTrow/grpc_user_auth/internal/relation"
 	"github.com/ScareTrow/grpc_user_auth/internal/transport"
 	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
 )
@@ -20,6 +35,55 @@ const (
 	AdminUsernameEnv = "ADMIN_USERNAME"
 	AdminEmailEnv    = "ADMIN_EMAIL"
 	AdminPasswordEnv = "ADMIN_PASSWORD"
+
+	StorageBackendEnv    = "STORAGE_BACKEND"
+	DataDirEnv           = "DATA_DIR"
+	WALSyncPolicyEnv     = "WAL_SYNC_POLICY"
+	WALSyncIntervalEnv   = "WAL_SYNC_INTERVAL"
+	SnapshotIntervalEnv  = "SNAPSHOT_INTERVAL"
+	SnapshotThresholdEnv = "SNAPSHOT_THRESHOLD"
+
+	PageTokenSecretEnv = "PAGE_TOKEN_SECRET"
+
+	JWTIssuerEnv         = "JWT_ISSUER"
+	JWTAudienceEnv       = "JWT_AUDIENCE"
+	JWTAccessTokenTTLEnv = "JWT_ACCESS_TOKEN_TTL"
+	JWTPrivateKeyPathEnv = "JWT_PRIVATE_KEY_PATH"
+	RefreshTokenTTLEnv   = "REFRESH_TOKEN_TTL"
+
+	TLSCertPathEnv          = "TLS_CERT_PATH"
+	TLSKeyPathEnv           = "TLS_KEY_PATH"
+	TLSClientCAPathEnv      = "TLS_CLIENT_CA_PATH"
+	TLSRequireClientCertEnv = "TLS_REQUIRE_CLIENT_CERT"
+	ClientCertIdentityEnv   = "CLIENT_CERT_IDENTITY"
+
+	ExtAuthzTrustedProxiesEnv = "EXT_AUTHZ_TRUSTED_PROXIES"
+
+	PolicyPathEnv           = "POLICY_PATH"
+	PolicyReloadIntervalEnv = "POLICY_RELOAD_INTERVAL"
+
+	TupleSchemaPathEnv = "TUPLE_SCHEMA_PATH"
+
+	RegistrationEnabledEnv   = "REGISTRATION_ENABLED"
+	EmailVerificationTTLEnv  = "EMAIL_VERIFICATION_TTL"
+	MailOutboxPathEnv        = "MAIL_OUTBOX_PATH"
+	PasswordResetTTLEnv      = "PASSWORD_RESET_TTL"
+	PasswordResetCooldownEnv = "PASSWORD_RESET_COOLDOWN"
+
+	LockoutMaxFailuresEnv   = "LOCKOUT_MAX_FAILURES"
+	LockoutMaxIPFailuresEnv = "LOCKOUT_MAX_IP_FAILURES"
+	LockoutBaseDurationEnv  = "LOCKOUT_BASE_DURATION"
+	LockoutMaxDurationEnv   = "LOCKOUT_MAX_DURATION"
+
+	PasswordHashAlgorithmEnv = "PASSWORD_HASH_ALGORITHM"
+	PasswordHashCostEnv      = "PASSWORD_HASH_COST"
+	PasswordHashMemoryEnv    = "PASSWORD_HASH_MEMORY"
+
+	PasswordWorkersEnv   = "PASSWORD_WORKERS"
+	PasswordQueueSizeEnv = "PASSWORD_QUEUE_SIZE"
+
+	CredentialCacheTTLEnv  = "CREDENTIAL_CACHE_TTL"
+	CredentialCacheSizeEnv = "CREDENTIAL_CACHE_SIZE"
 )
 
 func main() {
@@ -37,13 +101,174 @@ func run() error {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	ctx = common.InjectLogger(ctx, logger)
 
-	repo := infrastructure.NewRepository()
-	userUseCases := usecases.NewUserUseCases(repo)
-	authenticator := transport.NewAuthenticator(userUseCases.AuthenticateUser)
-	handlers := transport.NewGRPCHandlers(userUseCases, authenticator)
-	grpcServer := transport.NewGRPCServer(logger, authenticator, handlers)
+	repo, err := newRepository(logger)
+	if err != nil {
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			logger.ErrorContext(ctx, "failed to close repository", slog.String("error", err.Error()))
+		}
+	}()
+
+	pageTokenSecret, err := getPageTokenSecret()
+	if err != nil {
+		return err
+	}
+
+	jwtIssuer, err := newJWTIssuer(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create JWT issuer: %w", err)
+	}
+
+	refreshTokenTTL, err := getDurationEnvOrDefault(RefreshTokenTTLEnv, defaultRefreshTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	passwordHasher, err := newPasswordHasher()
+	if err != nil {
+		return fmt.Errorf("failed to create password hasher: %w", err)
+	}
+
+	passwordPool, err := newPasswordPool(passwordHasher)
+	if err != nil {
+		return err
+	}
+
+	credentialCache, err := newCredentialCache()
+	if err != nil {
+		return fmt.Errorf("failed to create credential cache: %w", err)
+	}
+
+	mail := newMailSender(ctx)
+
+	verificationTTL, err := getDurationEnvOrDefault(EmailVerificationTTLEnv, defaultEmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	emailVerifications := usecases.NewEmailVerifications(mail, verificationTTL)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec(pageTokenSecret),
+		passwordPool,
+		credentialCache,
+		emailVerifications,
+	)
+	authUseCases := usecases.NewAuthUseCases(userUseCases, jwtIssuer, repo, refreshTokenTTL)
+	apiKeyUseCases := usecases.NewAPIKeyUseCases(repo, repo)
+	roleUseCases := usecases.NewRoleUseCases(repo, repo, repo)
+	groupUseCases := usecases.NewGroupUseCases(repo, repo, roleUseCases)
+	organizationUseCases := usecases.NewOrganizationUseCases(repo)
+	authorization := usecases.NewAuthorizationUseCases(roleUseCases, repo)
+
+	tupleSchema, err := loadTupleSchema()
+	if err != nil {
+		return err
+	}
+
+	relationUseCases := usecases.NewRelationUseCases(repo, tupleSchema)
+
+	registrationUseCases, err := newRegistrationUseCases(userUseCases, organizationUseCases, emailVerifications)
+	if err != nil {
+		return err
+	}
+
+	passwordResetUseCases, err := newPasswordResetUseCases(userUseCases, repo, mail)
+	if err != nil {
+		return err
+	}
+
+	go passwordResetUseCases.Run(ctx)
+
+	lockoutUseCases, err := newLockoutUseCases()
+	if err != nil {
+		return err
+	}
+
+	authenticatorOpts := []transport.AuthenticatorOption[*models.User]{
+		transport.WithBearerAuth(authUseCases.AuthenticateAccessToken),
+		transport.WithAPIKeyAuth(apiKeyUseCases.AuthenticateAPIKey),
+		transport.WithPublicMethods[*models.User](
+			transport.AuthServiceLoginMethod,
+			transport.AuthServiceRefreshMethod,
+			transport.AuthServiceLogoutMethod,
+			transport.RegistrationServiceRegisterMethod,
+			transport.RegistrationServiceVerifyEmailMethod,
+			transport.PasswordResetServiceRequestPasswordResetMethod,
+			transport.PasswordResetServiceResetPasswordMethod,
+			transport.ExtAuthzCheckMethod,
+		),
+		transport.WithVerifiedEmail(
+			transport.UserServiceGetMeMethod,
+			transport.UserServiceUpdateMeMethod,
+			transport.UserServiceChangePasswordMethod,
+			transport.RegistrationServiceResendVerificationEmailMethod,
+		),
+		transport.WithLockout[*models.User](lockoutUseCases),
+	}
+
+	creds, clientCertificates, err := newServerCredentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create server credentials: %w", err)
+	}
+
+	if clientCertificates {
+		identity, err := transport.ParseCertificateIdentity(
+			getEnvOrDefault(ClientCertIdentityEnv, string(transport.CertificateSubjectCommonName)),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", ClientCertIdentityEnv, err)
+		}
+
+		authenticatorOpts = append(
+			authenticatorOpts,
+			transport.WithClientCertificateAuth(identity, authUseCases.AuthenticateUsername),
+		)
+	}
+
+	authenticator := transport.NewAuthenticator(userUseCases.AuthenticateUser, authenticatorOpts...)
+
+	trustedProxies, err := transport.ParseTrustedProxies(os.Getenv(ExtAuthzTrustedProxiesEnv))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", ExtAuthzTrustedProxiesEnv, err)
+	}
+
+	policyInterceptors, err := newPolicyInterceptors(ctx, authenticator, userUseCases, roleUseCases)
+	if err != nil {
+		return fmt.Errorf("failed to load policy: %w", err)
+	}
+
+	interceptors := append(
+		[]grpc.UnaryServerInterceptor{
+			transport.NewOrganizationResolver(authenticator, organizationUseCases, authorization).UnaryInterceptor,
+		},
+		policyInterceptors...,
+	)
+
+	grpcServer := transport.NewGRPCServer(
+		logger,
+		authenticator,
+		creds,
+		interceptors,
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+		transport.NewAuthGRPCHandlers(authUseCases, lockoutUseCases),
+		transport.NewAPIKeyGRPCHandlers(apiKeyUseCases, authorization, authenticator),
+		transport.NewRoleGRPCHandlers(roleUseCases, authorization, authenticator),
+		transport.NewGroupGRPCHandlers(groupUseCases, authorization, authenticator),
+		transport.NewOrganizationGRPCHandlers(organizationUseCases, authorization, authenticator),
+		transport.ServiceFunc(transport.NewRegistrationGRPCHandlers(registrationUseCases, authenticator).RegisterService),
+		transport.NewPasswordResetGRPCHandlers(passwordResetUseCases),
+		transport.NewLockoutGRPCHandlers(lockoutUseCases, authorization, authenticator),
+		transport.NewAuthorizationGRPCHandlers(authorization, authenticator),
+		transport.NewRelationGRPCHandlers(relationUseCases, authorization, authenticator),
+		transport.NewExtAuthzGRPCHandlers(authenticator, roleUseCases, trustedProxies),
+	)
 
-	if err := createAdmin(userUseCases); err != nil {
+	if err := createAdmin(ctx, userUseCases, roleUseCases); err != nil {
 		return fmt.Errorf("failed to create admin: %w", err)
 	}
 
@@ -57,7 +282,513 @@ func run() error {
 	return nil
 }
 
-func createAdmin(userUseCases *usecases.UserUseCases) error {
+const (
+	MemoryStorageBackend  = "memory"
+	DurableStorageBackend = "durable"
+)
+
+type repository interface {
+	usecases.UserRepository
+	usecases.RefreshTokenRepository
+	usecases.APIKeyRepository
+	usecases.RoleRepository
+	usecases.TupleRepository
+	usecases.GroupRepository
+	usecases.OrganizationRepository
+	io.Closer
+}
+
+func newRepository(logger *slog.Logger) (repository, error) { //nolint:ireturn
+	// Deployments that only set DATA_DIR predate STORAGE_BACKEND and must keep persisting users.
+	backend := os.Getenv(StorageBackendEnv)
+	dataDir := os.Getenv(DataDirEnv)
+
+	switch {
+	case backend == "" && dataDir != "":
+		backend = DurableStorageBackend
+	case backend == "":
+		backend = MemoryStorageBackend
+	}
+
+	switch backend {
+	case MemoryStorageBackend:
+		if dataDir != "" {
+			return nil, fmt.Errorf("%s is set, but the %q storage backend does not persist users", DataDirEnv, backend)
+		}
+
+		repo, err := infrastructure.NewRepository(infrastructure.WithLogger(logger))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-memory repository: %w", err)
+		}
+
+		return repo, nil
+	case DurableStorageBackend:
+		return newDurableRepository(logger)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+func newDurableRepository(logger *slog.Logger) (*infrastructure.Repository, error) {
+	dataDir := os.Getenv(DataDirEnv)
+	if dataDir == "" {
+		return nil, fmt.Errorf("%s must be set for the %q storage backend", DataDirEnv, DurableStorageBackend)
+	}
+
+	opts := []infrastructure.RepositoryOption{
+		infrastructure.WithLogger(logger),
+		infrastructure.WithDataDir(dataDir),
+	}
+
+	if rawPolicy := os.Getenv(WALSyncPolicyEnv); rawPolicy != "" {
+		policy, err := infrastructure.ParseSyncPolicy(rawPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", WALSyncPolicyEnv, err)
+		}
+
+		opts = append(opts, infrastructure.WithSyncPolicy(policy))
+	}
+
+	if rawInterval := os.Getenv(WALSyncIntervalEnv); rawInterval != "" {
+		interval, err := time.ParseDuration(rawInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", WALSyncIntervalEnv, err)
+		}
+
+		if interval <= 0 {
+			return nil, fmt.Errorf("%s must be positive, got %s", WALSyncIntervalEnv, interval)
+		}
+
+		opts = append(opts, infrastructure.WithSyncInterval(interval))
+	}
+
+	if rawInterval := os.Getenv(SnapshotIntervalEnv); rawInterval != "" {
+		interval, err := time.ParseDuration(rawInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", SnapshotIntervalEnv, err)
+		}
+
+		opts = append(opts, infrastructure.WithSnapshotInterval(interval))
+	}
+
+	if rawThreshold := os.Getenv(SnapshotThresholdEnv); rawThreshold != "" {
+		threshold, err := strconv.Atoi(rawThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", SnapshotThresholdEnv, err)
+		}
+
+		opts = append(opts, infrastructure.WithSnapshotThreshold(threshold))
+	}
+
+	repo, err := infrastructure.NewRepository(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open durable repository: %w", err)
+	}
+
+	return repo, nil
+}
+
+// getPageTokenSecret returns the key page tokens are signed with.
+// Without a configured secret a random one is generated, so page tokens do not survive a restart.
+func getPageTokenSecret() ([]byte, error) {
+	if secret := os.Getenv(PageTokenSecretEnv); secret != "" {
+		return []byte(secret), nil
+	}
+
+	const secretSize = 32
+
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate page token secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+const (
+	defaultJWTIssuer         = "grpc_user_auth"
+	defaultJWTAudience       = "grpc_user_auth"
+	defaultJWTAccessTokenTTL = 15 * time.Minute
+	defaultRefreshTokenTTL   = 30 * 24 * time.Hour
+)
+
+// newJWTIssuer signs access tokens with the key from JWT_PRIVATE_KEY_PATH.
+// Without a configured key an Ed25519 key is generated, so access tokens do not survive a restart.
+func newJWTIssuer(ctx context.Context) (*infrastructure.JWTIssuer, error) {
+	config := infrastructure.JWTConfig{
+		Issuer:     getEnvOrDefault(JWTIssuerEnv, defaultJWTIssuer),
+		Audience:   getEnvOrDefault(JWTAudienceEnv, defaultJWTAudience),
+		TTL:        0,
+		PrivateKey: nil,
+	}
+
+	ttl, err := getDurationEnvOrDefault(JWTAccessTokenTTLEnv, defaultJWTAccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	config.TTL = ttl
+
+	if path := os.Getenv(JWTPrivateKeyPathEnv); path != "" {
+		key, err := infrastructure.LoadPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT private key: %w", err)
+		}
+
+		config.PrivateKey = key
+	} else {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JWT private key: %w", err)
+		}
+
+		common.ExtractLogger(ctx).WarnContext(
+			ctx,
+			"JWT private key is not configured, access tokens are signed with an ephemeral key",
+			slog.String("env", JWTPrivateKeyPathEnv),
+		)
+
+		config.PrivateKey = key
+	}
+
+	issuer, err := infrastructure.NewJWTIssuer(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT issuer: %w", err)
+	}
+
+	return issuer, nil
+}
+
+// newServerCredentials enables TLS if TLS_CERT_PATH and TLS_KEY_PATH are set,
+// and reports whether client certificates are verified.
+func newServerCredentials(ctx context.Context) (credentials.TransportCredentials, bool, error) { //nolint:ireturn
+	config := transport.TLSConfig{
+		CertPath:          os.Getenv(TLSCertPathEnv),
+		KeyPath:           os.Getenv(TLSKeyPathEnv),
+		ClientCAPath:      os.Getenv(TLSClientCAPathEnv),
+		RequireClientCert: false,
+	}
+
+	if rawRequire := os.Getenv(TLSRequireClientCertEnv); rawRequire != "" {
+		requireClientCert, err := strconv.ParseBool(rawRequire)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", TLSRequireClientCertEnv, err)
+		}
+
+		config.RequireClientCert = requireClientCert
+	}
+
+	if config.CertPath == "" && config.KeyPath == "" {
+		if config.ClientCAPath != "" || config.RequireClientCert {
+			return nil, false, fmt.Errorf("client certificates require %s and %s", TLSCertPathEnv, TLSKeyPathEnv)
+		}
+
+		common.ExtractLogger(ctx).WarnContext(ctx, "TLS is not configured, the server accepts plaintext connections")
+
+		return insecure.NewCredentials(), false, nil
+	}
+
+	creds, err := transport.NewServerCredentials(config)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+
+	return creds, config.ClientCAPath != "", nil
+}
+
+const (
+	defaultEmailVerificationTTL  = 24 * time.Hour
+	defaultPasswordResetTTL      = time.Hour
+	defaultPasswordResetCooldown = time.Minute
+)
+
+// newMailSender appends mails to MAIL_OUTBOX_PATH for another process to deliver, or only keeps them in memory
+// without it.
+func newMailSender(ctx context.Context) usecases.MailSender { //nolint:ireturn
+	path := os.Getenv(MailOutboxPathEnv)
+	if path == "" {
+		common.ExtractLogger(ctx).WarnContext(
+			ctx,
+			"Mail outbox is not configured, verification and password reset mails are not delivered",
+			slog.String("env", MailOutboxPathEnv),
+		)
+
+		return infrastructure.NewMemoryOutbox()
+	}
+
+	return infrastructure.NewFileOutbox(path)
+}
+
+// newRegistrationUseCases enables registration if REGISTRATION_ENABLED is true.
+func newRegistrationUseCases(
+	userUseCases *usecases.UserUseCases,
+	organizationUseCases *usecases.OrganizationUseCases,
+	verifications *usecases.EmailVerifications,
+) (*usecases.RegistrationUseCases, error) {
+	enabled := false
+	if rawEnabled := os.Getenv(RegistrationEnabledEnv); rawEnabled != "" {
+		var err error
+
+		enabled, err = strconv.ParseBool(rawEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", RegistrationEnabledEnv, err)
+		}
+	}
+
+	return usecases.NewRegistrationUseCases(
+		userUseCases,
+		organizationUseCases,
+		verifications,
+		enabled,
+	), nil
+}
+
+// newPasswordResetUseCases mails reset tokens valid for PASSWORD_RESET_TTL, at most once per
+// PASSWORD_RESET_COOLDOWN for every username. A zero cooldown disables it.
+func newPasswordResetUseCases(
+	userUseCases *usecases.UserUseCases,
+	refreshTokens usecases.RefreshTokenRepository,
+	mail usecases.MailSender,
+) (*usecases.PasswordResetUseCases, error) {
+	resetTTL, err := getDurationEnvOrDefault(PasswordResetTTLEnv, defaultPasswordResetTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	cooldown, err := getDurationEnvOrDefault(PasswordResetCooldownEnv, defaultPasswordResetCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	if cooldown < 0 {
+		return nil, fmt.Errorf("%s must not be negative, got %s", PasswordResetCooldownEnv, cooldown)
+	}
+
+	return usecases.NewPasswordResetUseCases(userUseCases, refreshTokens, mail, resetTTL, cooldown), nil
+}
+
+// loadTupleSchema loads the namespace schema of relation tuples from TUPLE_SCHEMA_PATH.
+// Without a schema no namespaces are declared, so no tuples can be written.
+func loadTupleSchema() (*relation.Schema, error) {
+	path := os.Getenv(TupleSchemaPathEnv)
+	if path == "" {
+		return relation.ParseSchema(nil)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", TupleSchemaPathEnv, err)
+	}
+
+	schema, err := relation.ParseSchema(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tuple schema %q: %w", path, err)
+	}
+
+	return schema, nil
+}
+
+const defaultPolicyReloadInterval = 5 * time.Second
+
+// newPolicyInterceptors enforces the policy from POLICY_PATH, reloading it when the file changes.
+// Without a policy, only the built-in checks of the handlers apply.
+func newPolicyInterceptors(
+	ctx context.Context,
+	authenticator *transport.Authenticator[*models.User],
+	userUseCases *usecases.UserUseCases,
+	roleUseCases *usecases.RoleUseCases,
+) ([]grpc.UnaryServerInterceptor, error) {
+	path := os.Getenv(PolicyPathEnv)
+	if path == "" {
+		return nil, nil
+	}
+
+	reloadInterval, err := getDurationEnvOrDefault(PolicyReloadIntervalEnv, defaultPolicyReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if reloadInterval <= 0 {
+		return nil, fmt.Errorf("%s must be positive, got %s", PolicyReloadIntervalEnv, reloadInterval)
+	}
+
+	source, err := policy.NewSource(path, common.ExtractLogger(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	go source.Watch(ctx, reloadInterval)
+
+	enforcer := transport.NewPolicyEnforcer(source, authenticator, userUseCases, roleUseCases)
+
+	return []grpc.UnaryServerInterceptor{enforcer.UnaryInterceptor}, nil
+}
+
+const (
+	defaultLockoutMaxFailures   = 5
+	defaultLockoutMaxIPFailures = 50
+	defaultLockoutBaseDuration  = 30 * time.Second
+	defaultLockoutMaxDuration   = time.Hour
+)
+
+// newLockoutUseCases locks usernames out after LOCKOUT_MAX_FAILURES and addresses after LOCKOUT_MAX_IP_FAILURES
+// consecutive failed password attempts, for LOCKOUT_BASE_DURATION doubled by every further failure
+// up to LOCKOUT_MAX_DURATION. Zero limits disable the lockouts.
+func newLockoutUseCases() (*usecases.LockoutUseCases, error) {
+	maxFailures, err := getIntEnvOrDefault(LockoutMaxFailuresEnv, defaultLockoutMaxFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	maxIPFailures, err := getIntEnvOrDefault(LockoutMaxIPFailuresEnv, defaultLockoutMaxIPFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDuration, err := getDurationEnvOrDefault(LockoutBaseDurationEnv, defaultLockoutBaseDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDuration, err := getDurationEnvOrDefault(LockoutMaxDurationEnv, defaultLockoutMaxDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecases.NewLockoutUseCases(usecases.LockoutConfig{
+		MaxUsernameFailures: maxFailures,
+		MaxIPFailures:       maxIPFailures,
+		BaseDuration:        baseDuration,
+		MaxDuration:         maxDuration,
+	}), nil
+}
+
+const defaultPasswordQueueSize = 100
+
+const (
+	defaultCredentialCacheTTL  = 30 * time.Second
+	defaultCredentialCacheSize = 10_000
+)
+
+// newCredentialCache remembers up to CREDENTIAL_CACHE_SIZE verified passwords for CREDENTIAL_CACHE_TTL.
+// A zero TTL or size disables the cache.
+func newCredentialCache() (*usecases.CredentialCache, error) {
+	ttl, err := getDurationEnvOrDefault(CredentialCacheTTLEnv, defaultCredentialCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := getIntEnvOrDefault(CredentialCacheSizeEnv, defaultCredentialCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl <= 0 || size <= 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return usecases.NewCredentialCache(ttl, size)
+}
+
+// newPasswordPool hashes and verifies passwords with the hasher on PASSWORD_WORKERS workers, one per CPU by default,
+// and rejects passwords beyond PASSWORD_QUEUE_SIZE waiting ones.
+func newPasswordPool(hasher usecases.PasswordHasher) (*usecases.PasswordPool, error) {
+	workers, err := getIntEnvOrDefault(PasswordWorkersEnv, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+
+	if workers < 1 {
+		return nil, fmt.Errorf("%s must be positive", PasswordWorkersEnv)
+	}
+
+	queueSize, err := getIntEnvOrDefault(PasswordQueueSizeEnv, defaultPasswordQueueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if queueSize < 0 {
+		return nil, fmt.Errorf("%s must not be negative", PasswordQueueSizeEnv)
+	}
+
+	return usecases.NewPasswordPool(hasher, workers, queueSize), nil
+}
+
+// newPasswordHasher hashes new passwords with PASSWORD_HASH_ALGORITHM and PASSWORD_HASH_COST,
+// and argon2id with PASSWORD_HASH_MEMORY KiB. Zero cost and memory are the defaults of the algorithm.
+func newPasswordHasher() (*infrastructure.PasswordHasher, error) {
+	algorithm, err := infrastructure.ParsePasswordHashAlgorithm(
+		getEnvOrDefault(PasswordHashAlgorithmEnv, string(infrastructure.PasswordHashArgon2id)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", PasswordHashAlgorithmEnv, err)
+	}
+
+	cost, err := getIntEnvOrDefault(PasswordHashCostEnv, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	memory, err := getIntEnvOrDefault(PasswordHashMemoryEnv, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if memory < 0 || memory > math.MaxUint32 {
+		return nil, fmt.Errorf("%s %d is out of range", PasswordHashMemoryEnv, memory)
+	}
+
+	return infrastructure.NewPasswordHasher(infrastructure.PasswordHasherConfig{
+		Algorithm: algorithm,
+		Cost:      cost,
+		Memory:    uint32(memory),
+	})
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+func getIntEnvOrDefault(key string, defaultValue int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+func getDurationEnvOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// createAdmin creates the admin from the environment in the default organization with the superuser role,
+// or grants the role again if the admin already exists with ADMIN_PASSWORD. It refuses to grant it
+// to a user that only has ADMIN_USERNAME or ADMIN_EMAIL, such as one who registered it first.
+func createAdmin(
+	ctx context.Context,
+	userUseCases *usecases.UserUseCases,
+	roleUseCases *usecases.RoleUseCases,
+) error {
 	adminUsername := os.Getenv(AdminUsernameEnv)
 	adminEmail := os.Getenv(AdminEmailEnv)
 	adminPassword := os.Getenv(AdminPasswordEnv)
@@ -67,15 +798,42 @@ func createAdmin(userUseCases *usecases.UserUseCases) error {
 	}
 
 	cmd := usecases.NewCreateUserCommand(
+		models.DefaultOrganization,
 		adminUsername,
 		adminEmail,
 		adminPassword,
-		true,
+		[]string{models.SuperuserRole},
 	)
-	_, err := userUseCases.CreateUser(cmd)
-	if err != nil {
+	_, err := userUseCases.CreateUser(ctx, cmd)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, common.ErrAlreadyExists):
+		// The admin survived the restart in the durable repository.
+	default:
 		return fmt.Errorf("failed to create admin: %w", err)
 	}
 
+	admin, err := userUseCases.AuthenticateUser(ctx, models.DefaultOrganization, adminUsername, adminPassword)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrInvalidCredentials):
+		return fmt.Errorf(
+			"the username %q or email %q of the admin belongs to a user without the admin password: %w",
+			adminUsername, adminEmail, err,
+		)
+	default:
+		return fmt.Errorf("failed to authenticate admin: %w", err)
+	}
+
+	assignCmd, err := usecases.NewAssignRoleCommand(admin.ID.String(), models.SuperuserRole)
+	if err != nil {
+		return fmt.Errorf("failed to create AssignRole command: %w", err)
+	}
+
+	if err := roleUseCases.AssignRole(assignCmd); err != nil {
+		return fmt.Errorf("failed to grant superuser role to admin: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is stored by the SHA-256 hash of the key, which is shown to its owner only once.
+type APIKey struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Name   string
+	// Prefix is the beginning of the key, which lets the owner recognize the key.
+	Prefix string
+	Hash   []byte
+	// Scopes are the full gRPC methods the key may call, such as "/users.UserService/GetAllUsers",
+	// or all methods of a service, such as "/users.UserService/*". A key without scopes may call any method.
+	Scopes    []string
+	CreatedAt time.Time
+	// ExpiresAt is zero for keys that never expire.
+	ExpiresAt time.Time
+}
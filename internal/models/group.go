@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group grants its roles to its members. Members are users and nested groups,
+// whose members are in turn members of the group.
+type Group struct {
+	ID uuid.UUID
+	// Organization is the name of the organization the group belongs to. Members belong to it as well.
+	Organization string
+	Name         string
+	// Roles are the names of the roles granted to the members of the group.
+	Roles []string
+	// UserIDs are the users that are direct members of the group.
+	UserIDs []uuid.UUID
+	// GroupIDs are the nested groups.
+	GroupIDs  []uuid.UUID
+	CreatedAt time.Time
+	// Version is incremented by the repository on every change of the group.
+	Version uint64
+}
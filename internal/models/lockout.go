@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+type LockoutKind string
+
+const (
+	// LockoutKindUsername tracks the failed password attempts of a username in an organization,
+	// whether the user exists or not.
+	LockoutKindUsername LockoutKind = "username"
+	// LockoutKindIP tracks the failed password attempts from an IP address.
+	LockoutKindIP LockoutKind = "ip"
+)
+
+// Lockout is the state of the failed password attempts of a username or an IP address.
+type Lockout struct {
+	Kind LockoutKind
+	// Organization is the organization of the username, and empty for IP addresses.
+	Organization string
+	// Subject is the username or the IP address.
+	Subject string
+	// Failures is the number of consecutive failed attempts.
+	Failures      int
+	LastFailureAt time.Time
+	// LockedUntil is when password attempts are allowed again. Zero or past times mean not locked.
+	LockedUntil time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultOrganization is the name of the built-in organization. It is not stored, and it holds the users
+// created before organizations were introduced and the admin from the environment.
+const DefaultOrganization = "default"
+
+// Organization is a tenant. Users and groups belong to exactly one organization,
+// and their names are only unique within it.
+type Organization struct {
+	Name      string
+	CreatedAt time.Time
+}
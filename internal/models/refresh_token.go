@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is stored by the SHA-256 hash of the opaque token, which only its owner knows.
+// Every refresh rotates the token: the presented one is marked as rotated and a new one of the same
+// family is issued, so presenting a rotated token again means the family has leaked.
+type RefreshToken struct {
+	Hash      []byte
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Rotated   bool
+}
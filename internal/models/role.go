@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Permission allows the holder to perform a group of actions, such as reading users.
+type Permission string
+
+const (
+	PermissionUsersRead   Permission = "users.read"
+	PermissionUsersWrite  Permission = "users.write"
+	PermissionUsersDelete Permission = "users.delete"
+	PermissionRolesManage Permission = "roles.manage"
+	PermissionTuplesRead  Permission = "tuples.read"
+	PermissionTuplesWrite Permission = "tuples.write"
+)
+
+// Permissions returns all known permissions.
+func Permissions() []Permission {
+	return []Permission{
+		PermissionUsersRead,
+		PermissionUsersWrite,
+		PermissionUsersDelete,
+		PermissionRolesManage,
+		PermissionTuplesRead,
+		PermissionTuplesWrite,
+	}
+}
+
+// SuperuserRole is the name of the built-in role that grants every permission in every organization,
+// as well as managing organizations and custom roles. It is not stored,
+// and it is granted to the admin from the environment on every start.
+const SuperuserRole = "superuser"
+
+// OrgAdminRole is the name of the built-in role that grants every permission within the organization of its holder.
+const OrgAdminRole = "org_admin"
+
+type Role struct {
+	Name        string
+	Permissions []Permission
+	CreatedAt   time.Time
+}
@@ -0,0 +1,61 @@
+package models
+
+// UserNamespace is the namespace of subjects that are users, such as "user:<id>".
+// Tuples with such subjects are deleted together with the user.
+const UserNamespace = "user"
+
+// Object is an object of a namespace, such as document:readme.
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Subject is either an object, such as user:alice, or, if Relation is set, the set of subjects
+// that have the relation to the object, such as group:eng#member.
+type Subject struct {
+	Object   Object
+	Relation string
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Object.String()
+	}
+
+	return s.Object.String() + "#" + s.Relation
+}
+
+// Tuple states that the subject has the relation to the object, written as object#relation@subject,
+// such as document:readme#editor@group:eng#member. Every organization has its own tuples,
+// so the same object in two organizations is two different objects.
+type Tuple struct {
+	Organization string
+	Object       Object
+	Relation     string
+	Subject      Subject
+}
+
+func (t *Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// TupleFilter selects the tuples of a namespace of an organization. Other empty fields match everything.
+type TupleFilter struct {
+	Organization string
+	Namespace    string
+	ObjectID     string
+	Relation     string
+	Subject      *Subject
+}
+
+func (f *TupleFilter) Matches(tuple *Tuple) bool {
+	return tuple.Organization == f.Organization &&
+		tuple.Object.Namespace == f.Namespace &&
+		(f.ObjectID == "" || tuple.Object.ID == f.ObjectID) &&
+		(f.Relation == "" || tuple.Relation == f.Relation) &&
+		(f.Subject == nil || tuple.Subject == *f.Subject)
+}
@@ -1,13 +1,74 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID           uuid.UUID
+	ID uuid.UUID
+	// Organization is the name of the organization the user belongs to.
+	Organization string
 	Username     string
 	Email        string
 	PasswordHash []byte
-	Admin        bool
+	// Roles are the names of the roles assigned to the user.
+	Roles []string
+	// EmailVerification is pending until the user proves to own the email.
+	// Users without it have a verified email, which is the case for users created by admins.
+	EmailVerification *EmailVerification
+	// PasswordReset is pending from a request to reset the password until the reset or the next request.
+	PasswordReset *PasswordReset
+	// PasswordChangedAt is the time the password was last replaced, or zero if it has not been since the creation.
+	// Access tokens issued before it are rejected.
+	PasswordChangedAt time.Time
+	CreatedAt         time.Time
+	// Version is incremented by the repository on every change of the user.
+	Version uint64
+}
+
+type EmailVerification struct {
+	// TokenHash is the SHA-256 hash of the secret of the token mailed to the user.
+	TokenHash []byte
+	ExpiresAt time.Time
+}
+
+type PasswordReset struct {
+	// TokenHash is the SHA-256 hash of the secret of the token mailed to the user.
+	TokenHash []byte
+	ExpiresAt time.Time
+}
+
+type UserOrderField string
+
+const (
+	UserOrderByUsername   UserOrderField = "username"
+	UserOrderByEmail      UserOrderField = "email"
+	UserOrderByCreateTime UserOrderField = "create_time"
+)
+
+// UserListPosition is a position in the ordered index of users: the index key and the ID of a user.
+// The key is produced by the repository and must be treated as opaque.
+type UserListPosition struct {
+	Key string
+	ID  uuid.UUID
+}
+
+type UserListQuery struct {
+	// Organization limits the page to the users of the organization.
+	Organization string
+	OrderBy      UserOrderField
+	Descending   bool
+	// After is an exclusive position to start from. Nil means the beginning of the index.
+	After *UserListPosition
+	Limit int
+	// Match reports whether the user must be listed. Nil matches all users.
+	Match func(user *User) (bool, error)
+}
+
+type UserListPage struct {
+	Users []*User
+	// Next is the position of the last listed user, or nil if there are no more users.
+	Next *UserListPosition
 }
@@ -0,0 +1,178 @@
+// Package policy implements declarative access rules for gRPC methods.
+// A policy is a YAML document with a mode and a list of rules:
+//
+//	mode: enforce # or audit
+//	rules:
+//	  - name: self-service
+//	    method: /users.UserService/UpdateUser
+//	    effect: allow
+//	    condition: request.id = caller.id AND request.admin = false
+//
+// Conditions are AIP-160 filters (see package filter) whose values may refer to other fields,
+// such as caller.id, request.id or target.roles.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/filter"
+)
+
+var ErrInvalid = errors.New("invalid policy")
+
+type Mode string
+
+const (
+	// ModeEnforce applies the decisions of the policy.
+	ModeEnforce Mode = "enforce"
+	// ModeAudit only reports the decisions, so that a policy can be tried out before it is enforced.
+	ModeAudit Mode = "audit"
+)
+
+type Effect string
+
+const (
+	// EffectNone means that no rule decided, and the built-in checks of the method apply.
+	EffectNone  Effect = ""
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+const methodWildcard = "*"
+
+type Rule struct {
+	Name string
+	// Method is a full gRPC method, such as "/users.UserService/UpdateUser",
+	// or all methods of a service, such as "/users.UserService/*".
+	Method    string
+	Effect    Effect
+	Condition filter.Expr
+}
+
+func (r *Rule) matches(method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+	return r.Method == "/"+service+"/"+methodWildcard
+}
+
+type Policy struct {
+	Mode  Mode
+	Rules []*Rule
+}
+
+type Decision struct {
+	Effect Effect
+	// Rule is the name of the deciding rule.
+	Rule string
+}
+
+type document struct {
+	Mode  Mode `yaml:"mode"`
+	Rules []struct {
+		Name      string `yaml:"name"`
+		Method    string `yaml:"method"`
+		Effect    Effect `yaml:"effect"`
+		Condition string `yaml:"condition"`
+	} `yaml:"rules"`
+}
+
+// Parse parses a policy. The mode defaults to enforce, rule names to their position,
+// and a rule without a condition always applies.
+func Parse(data []byte) (*Policy, error) {
+	var doc document
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	switch doc.Mode {
+	case "":
+		doc.Mode = ModeEnforce
+	case ModeEnforce, ModeAudit:
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalid, doc.Mode)
+	}
+
+	policy := &Policy{
+		Mode:  doc.Mode,
+		Rules: make([]*Rule, len(doc.Rules)),
+	}
+
+	for i, raw := range doc.Rules {
+		name := raw.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i)
+		}
+
+		if !strings.HasPrefix(raw.Method, "/") || strings.Count(raw.Method, "/") != 2 { //nolint:gomnd
+			return nil, fmt.Errorf("%w: %s: method must be a full gRPC method or \"/<service>/*\"", ErrInvalid, name)
+		}
+
+		if raw.Effect != EffectAllow && raw.Effect != EffectDeny {
+			return nil, fmt.Errorf("%w: %s: effect must be %q or %q", ErrInvalid, name, EffectAllow, EffectDeny)
+		}
+
+		condition, err := filter.Parse(raw.Condition, filter.WithFieldReferences())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalid, name, err)
+		}
+
+		policy.Rules[i] = &Rule{
+			Name:      name,
+			Method:    raw.Method,
+			Effect:    raw.Effect,
+			Condition: condition,
+		}
+	}
+
+	return policy, nil
+}
+
+// Evaluate decides the call of the method. A matching deny rule wins over allow rules,
+// and without a matching rule there is no decision. A deny rule that fails to evaluate
+// is treated as matching and an allow rule as not matching; the failures are returned with the decision.
+func (p *Policy) Evaluate(method string, env filter.Env) (*Decision, error) {
+	var (
+		allowedBy string
+		errs      []error
+	)
+
+	for _, rule := range p.Rules {
+		if !rule.matches(method) {
+			continue
+		}
+
+		matched, err := rule.Condition.Eval(env)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to evaluate %s: %w", rule.Name, err))
+			matched = rule.Effect == EffectDeny
+		}
+
+		switch {
+		case !matched:
+		case rule.Effect == EffectDeny:
+			return &Decision{Effect: EffectDeny, Rule: rule.Name}, errors.Join(errs...)
+		case allowedBy == "":
+			allowedBy = rule.Name
+		}
+	}
+
+	if allowedBy != "" {
+		return &Decision{Effect: EffectAllow, Rule: allowedBy}, errors.Join(errs...)
+	}
+
+	return &Decision{Effect: EffectNone, Rule: ""}, errors.Join(errs...)
+}
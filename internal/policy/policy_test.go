@@ -0,0 +1,148 @@
+package policy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/filter"
+	"github.com/ScareTrow/grpc_user_auth/internal/policy"
+)
+
+const updateUserMethod = "/users.UserService/UpdateUser"
+
+const testPolicy = `
+rules:
+  - name: self-service
+    method: /users.UserService/UpdateUser
+    effect: allow
+    condition: request.id = caller.id
+  - name: no-admins
+    method: /users.UserService/*
+    effect: deny
+    condition: request.admin = true AND NOT caller.roles:superuser
+  - name: unconditional
+    method: /users.RoleService/ListRoles
+    effect: allow
+`
+
+func TestPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	p, err := policy.Parse([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.Equal(t, policy.ModeEnforce, p.Mode)
+
+	testCases := []struct {
+		name     string
+		method   string
+		env      filter.Fields
+		expected policy.Decision
+	}{
+		{
+			name:     "allowed",
+			method:   updateUserMethod,
+			env:      filter.Fields{"request.id": "1", "caller.id": "1", "request.admin": false},
+			expected: policy.Decision{Effect: policy.EffectAllow, Rule: "self-service"},
+		},
+		{
+			name:     "deny wins",
+			method:   updateUserMethod,
+			env:      filter.Fields{"request.id": "1", "caller.id": "1", "request.admin": true, "caller.roles": []string{}},
+			expected: policy.Decision{Effect: policy.EffectDeny, Rule: "no-admins"},
+		},
+		{
+			name:     "no matching rule",
+			method:   updateUserMethod,
+			env:      filter.Fields{"request.id": "1", "caller.id": "2", "request.admin": false},
+			expected: policy.Decision{Effect: policy.EffectNone, Rule: ""},
+		},
+		{
+			name:     "other method",
+			method:   "/users.AuthService/Login",
+			env:      filter.Fields{},
+			expected: policy.Decision{Effect: policy.EffectNone, Rule: ""},
+		},
+		{
+			name:     "without condition",
+			method:   "/users.RoleService/ListRoles",
+			env:      filter.Fields{},
+			expected: policy.Decision{Effect: policy.EffectAllow, Rule: "unconditional"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			decision, err := p.Evaluate(tc.method, tc.env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *decision)
+		})
+	}
+
+	t.Run("failing rules", func(t *testing.T) {
+		t.Parallel()
+
+		decision, err := p.Evaluate(updateUserMethod, filter.Fields{})
+		assert.ErrorIs(t, err, filter.ErrInvalid)
+		assert.Equal(t, policy.EffectDeny, decision.Effect, "deny rules must fail closed")
+	})
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for name, source := range map[string]string{
+		"unknown mode":      "mode: permissive",
+		"unknown key":       "rules:\n  - method: /users.UserService/*\n    effect: deny\n    when: true",
+		"invalid method":    "rules:\n  - method: UpdateUser\n    effect: deny",
+		"invalid effect":    "rules:\n  - method: /users.UserService/*\n    effect: permit",
+		"invalid condition": "rules:\n  - method: /users.UserService/*\n    effect: deny\n    condition: admin =",
+		"not yaml":          "rules: [",
+	} {
+		source := source
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := policy.Parse([]byte(source))
+			assert.ErrorIs(t, err, policy.ErrInvalid)
+		})
+	}
+}
+
+func TestSource_Watch(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("mode: audit"), 0o600))
+
+	source, err := policy.NewSource(path, common.NewDisabledLogger())
+	require.NoError(t, err)
+	assert.Equal(t, policy.ModeAudit, source.Policy().Mode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go source.Watch(ctx, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+	assert.Eventually(t, func() bool {
+		return source.Policy().Mode == policy.ModeEnforce
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, source.Policy().Rules, 3)
+
+	require.NoError(t, os.WriteFile(path, []byte("mode: permissive"), 0o600))
+
+	changed, err := source.Reload()
+	assert.ErrorIs(t, err, policy.ErrInvalid)
+	assert.False(t, changed)
+	assert.Len(t, source.Policy().Rules, 3, "an invalid policy must not replace the current one")
+}
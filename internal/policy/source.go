@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Source is the policy in a file, which Watch reloads when the file changes.
+type Source struct {
+	path   string
+	logger *slog.Logger
+
+	policy atomic.Pointer[Policy]
+
+	mu      sync.Mutex
+	content []byte
+}
+
+// NewSource loads the policy from the file, failing if it cannot be read or parsed.
+func NewSource(path string, logger *slog.Logger) (*Source, error) {
+	s := &Source{
+		path:    path,
+		logger:  logger,
+		policy:  atomic.Pointer[Policy]{},
+		mu:      sync.Mutex{},
+		content: nil,
+	}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Source) Policy() *Policy {
+	return s.policy.Load()
+}
+
+// Reload reads the file again and reports whether the policy changed.
+// If the file cannot be read or parsed, the current policy is kept.
+func (s *Source) Reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read policy %q: %w", s.path, err)
+	}
+
+	if s.content != nil && bytes.Equal(content, s.content) {
+		return false, nil
+	}
+
+	policy, err := Parse(content)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse policy %q: %w", s.path, err)
+	}
+
+	s.policy.Store(policy)
+	s.content = content
+
+	return true, nil
+}
+
+// Watch reloads the policy every interval until the context is done.
+func (s *Source) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.Reload()
+		switch {
+		case err != nil:
+			s.logger.ErrorContext(ctx, "failed to reload policy, keeping the current one", slog.String("error", err.Error()))
+		case changed:
+			policy := s.Policy()
+			s.logger.InfoContext(
+				ctx,
+				"policy reloaded",
+				slog.String("path", s.path),
+				slog.String("mode", string(policy.Mode)),
+				slog.Int("rules", len(policy.Rules)),
+			)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package relation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// maxDepth bounds the nesting of usersets and relations that Check and Expand follow.
+const maxDepth = 32
+
+var ErrDepthExceeded = errors.New("relation depth exceeded")
+
+type TupleReader interface {
+	// ReadTuples returns the tuples that match the filter.
+	ReadTuples(filter *models.TupleFilter) ([]*models.Tuple, error)
+}
+
+type NodeKind string
+
+const (
+	// NodeUnion holds the subjects of any of its children.
+	NodeUnion NodeKind = "union"
+	// NodeDirect holds the subjects of the tuples of the userset.
+	NodeDirect NodeKind = "direct"
+	// NodeInherited holds the subjects of the inherited relation of the objects related by the userset.
+	NodeInherited NodeKind = "inherited"
+)
+
+// Node is a node of the tree returned by Expand.
+type Node struct {
+	Kind NodeKind
+	// Userset is the object and relation the node stands for, such as document:readme#viewer.
+	Userset models.Subject
+	// Subjects are the subjects of the tuples of a direct node. Usersets among them are expanded as children.
+	Subjects []models.Subject
+	Children []*Node
+}
+
+// Evaluator answers Check and Expand from the tuples of an organization and the schema.
+// Cycles of usersets and relations are cut off rather than reported.
+type Evaluator struct {
+	schema       *Schema
+	tuples       TupleReader
+	organization string
+}
+
+// NewEvaluator evaluates the tuples that do not belong to any organization, see InOrganization.
+func NewEvaluator(schema *Schema, tuples TupleReader) *Evaluator {
+	return &Evaluator{
+		schema:       schema,
+		tuples:       tuples,
+		organization: "",
+	}
+}
+
+// InOrganization returns an evaluator of the tuples of the organization only.
+func (e *Evaluator) InOrganization(organization string) *Evaluator {
+	return &Evaluator{
+		schema:       e.schema,
+		tuples:       e.tuples,
+		organization: organization,
+	}
+}
+
+// Check reports whether the subject has the relation to the object,
+// directly, through a userset, a computed relation or an inherited relation.
+func (e *Evaluator) Check(object models.Object, relation string, subject models.Subject) (bool, error) {
+	if e.schema.Relation(object.Namespace, relation) == nil {
+		return false, fmt.Errorf("%w: %s has no relation %q", ErrInvalid, object.Namespace, relation)
+	}
+
+	return e.check(object, relation, subject, make(map[string]struct{}), 0)
+}
+
+// check reports whether the userset of the object and relation has the subject. visited holds the usersets
+// checked for the subject during this Check, which are not checked again: a userset that has the subject
+// ends the Check, so a visited one is either on the current path, which cuts off a cycle, or known not to
+// have the subject. This memoizes the results, so that graphs with many paths to the same usersets,
+// such as diamonds of nested groups, take time linear in the usersets rather than in the paths.
+func (e *Evaluator) check(
+	object models.Object,
+	relation string,
+	subject models.Subject,
+	visited map[string]struct{},
+	depth int,
+) (bool, error) {
+	userset := models.Subject{Object: object, Relation: relation}
+	if userset == subject {
+		return true, nil
+	}
+
+	key := userset.String()
+	if _, ok := visited[key]; ok {
+		return false, nil
+	}
+
+	if depth > maxDepth {
+		return false, fmt.Errorf("%w: %s", ErrDepthExceeded, key)
+	}
+
+	visited[key] = struct{}{}
+
+	definition := e.schema.Relation(object.Namespace, relation)
+	if definition == nil {
+		return false, nil
+	}
+
+	if definition.Direct {
+		tuples, err := e.readTuples(object, relation)
+		if err != nil {
+			return false, err
+		}
+
+		for _, tuple := range tuples {
+			if tuple.Subject == subject {
+				return true, nil
+			}
+		}
+
+		for _, tuple := range tuples {
+			if tuple.Subject.Relation == "" {
+				continue
+			}
+
+			ok, err := e.check(tuple.Subject.Object, tuple.Subject.Relation, subject, visited, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+
+	for _, computed := range definition.Computed {
+		ok, err := e.check(object, computed, subject, visited, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	for _, inherited := range definition.Inherited {
+		tuples, err := e.readTuples(object, inherited.From)
+		if err != nil {
+			return false, err
+		}
+
+		for _, tuple := range tuples {
+			ok, err := e.check(tuple.Subject.Object, inherited.Relation, subject, visited, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Expand returns the tree of the subjects that have the relation to the object.
+func (e *Evaluator) Expand(object models.Object, relation string) (*Node, error) {
+	if e.schema.Relation(object.Namespace, relation) == nil {
+		return nil, fmt.Errorf("%w: %s has no relation %q", ErrInvalid, object.Namespace, relation)
+	}
+
+	return e.expand(object, relation, make(map[string]struct{}), 0)
+}
+
+func (e *Evaluator) expand(
+	object models.Object,
+	relation string,
+	visited map[string]struct{},
+	depth int,
+) (*Node, error) {
+	userset := models.Subject{Object: object, Relation: relation}
+	node := &Node{
+		Kind:     NodeUnion,
+		Userset:  userset,
+		Subjects: nil,
+		Children: nil,
+	}
+
+	key := userset.String()
+	if _, ok := visited[key]; ok {
+		return node, nil
+	}
+
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: %s", ErrDepthExceeded, key)
+	}
+
+	visited[key] = struct{}{}
+	defer delete(visited, key)
+
+	definition := e.schema.Relation(object.Namespace, relation)
+	if definition == nil {
+		return node, nil
+	}
+
+	if definition.Direct {
+		direct, err := e.expandDirect(userset, visited, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, direct)
+	}
+
+	for _, computed := range definition.Computed {
+		child, err := e.expand(object, computed, visited, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	for _, inherited := range definition.Inherited {
+		child, err := e.expandInherited(object, inherited, visited, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func (e *Evaluator) expandDirect(userset models.Subject, visited map[string]struct{}, depth int) (*Node, error) {
+	tuples, err := e.readTuples(userset.Object, userset.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{
+		Kind:     NodeDirect,
+		Userset:  userset,
+		Subjects: make([]models.Subject, len(tuples)),
+		Children: nil,
+	}
+
+	for i, tuple := range tuples {
+		node.Subjects[i] = tuple.Subject
+
+		if tuple.Subject.Relation == "" {
+			continue
+		}
+
+		child, err := e.expand(tuple.Subject.Object, tuple.Subject.Relation, visited, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func (e *Evaluator) expandInherited(
+	object models.Object,
+	inherited Inherited,
+	visited map[string]struct{},
+	depth int,
+) (*Node, error) {
+	tuples, err := e.readTuples(object, inherited.From)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{
+		Kind:     NodeInherited,
+		Userset:  models.Subject{Object: object, Relation: inherited.From},
+		Subjects: nil,
+		Children: make([]*Node, 0, len(tuples)),
+	}
+
+	for _, tuple := range tuples {
+		child, err := e.expand(tuple.Subject.Object, inherited.Relation, visited, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func (e *Evaluator) readTuples(object models.Object, relation string) ([]*models.Tuple, error) {
+	tuples, err := e.tuples.ReadTuples(&models.TupleFilter{
+		Organization: e.organization,
+		Namespace:    object.Namespace,
+		ObjectID:     object.ID,
+		Relation:     relation,
+		Subject:      nil,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tuples of %s#%s: %w", object, relation, err)
+	}
+
+	return tuples, nil
+}
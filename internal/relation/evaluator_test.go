@@ -0,0 +1,328 @@
+package relation_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/relation"
+)
+
+const testSchema = `
+namespaces:
+  user: {}
+  group:
+    relations:
+      member: {}
+  folder:
+    relations:
+      viewer: {}
+  document:
+    relations:
+      parent: {}
+      owner: {}
+      editor:
+        computed: [owner]
+      viewer:
+        computed: [editor]
+        inherited:
+          - from: parent
+            relation: viewer
+`
+
+func TestEvaluator_Check(t *testing.T) {
+	t.Parallel()
+
+	sut := newTestEvaluator(t,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:eng#member",
+		"document:readme#parent@folder:docs",
+		"group:eng#member@user:bob",
+		"group:eng#member@group:leads#member",
+		"group:leads#member@user:carol",
+		"group:leads#member@group:eng#member",
+		"folder:docs#viewer@user:dave",
+	)
+
+	testCases := []struct {
+		name     string
+		relation string
+		subject  string
+		expected bool
+	}{
+		{name: "direct", relation: "owner", subject: "user:alice", expected: true},
+		{name: "computed", relation: "editor", subject: "user:alice", expected: true},
+		{name: "computed twice", relation: "viewer", subject: "user:alice", expected: true},
+		{name: "userset", relation: "editor", subject: "user:bob", expected: true},
+		{name: "nested userset with a cycle", relation: "editor", subject: "user:carol", expected: true},
+		{name: "userset subject", relation: "viewer", subject: "group:leads#member", expected: true},
+		{name: "inherited", relation: "viewer", subject: "user:dave", expected: true},
+		{name: "inherited does not grant editor", relation: "editor", subject: "user:dave", expected: false},
+		{name: "no relation", relation: "owner", subject: "user:bob", expected: false},
+		{name: "unknown subject", relation: "viewer", subject: "user:eve", expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			subject, err := relation.ParseSubject(tc.subject)
+			require.NoError(t, err)
+
+			allowed, err := sut.Check(models.Object{Namespace: "document", ID: "readme"}, tc.relation, subject)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+
+	t.Run("unknown relation", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sut.Check(
+			models.Object{Namespace: "document", ID: "readme"},
+			"commenter",
+			models.Subject{Object: models.Object{Namespace: "user", ID: "alice"}, Relation: ""},
+		)
+		assert.ErrorIs(t, err, relation.ErrInvalid)
+	})
+}
+
+func TestEvaluator_CheckDiamonds(t *testing.T) {
+	t.Parallel()
+
+	const (
+		layers = 30
+		width  = 4
+	)
+
+	// Every group of a layer has all groups of the next layer as members, so there are width^layers paths
+	// from the first group to the last layer, through only layers*width groups.
+	var tuples []string
+	for layer := 0; layer < layers-1; layer++ {
+		for from := 0; from < width; from++ {
+			for to := 0; to < width; to++ {
+				tuples = append(tuples, fmt.Sprintf("group:g%d_%d#member@group:g%d_%d#member", layer, from, layer+1, to))
+			}
+		}
+	}
+
+	tuples = append(tuples, fmt.Sprintf("group:g%d_%d#member@user:alice", layers-1, width-1))
+
+	schema, err := relation.ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	writes := make([]*models.Tuple, len(tuples))
+	for i, raw := range tuples {
+		writes[i] = parseTuple(t, raw)
+	}
+
+	_, err = repo.WriteTuples(writes, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		subject  string
+		expected bool
+	}{
+		{subject: "user:alice", expected: true},
+		{subject: "user:bob", expected: false},
+	} {
+		reader := &countingTupleReader{TupleReader: repo, reads: 0}
+		sut := relation.NewEvaluator(schema, reader)
+
+		subject, err := relation.ParseSubject(tc.subject)
+		require.NoError(t, err)
+
+		allowed, err := sut.Check(models.Object{Namespace: "group", ID: "g0_0"}, "member", subject)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, allowed, tc.subject)
+		assert.LessOrEqual(t, reader.reads, layers*width, "every group is read at most once")
+	}
+}
+
+func TestEvaluator_Expand(t *testing.T) {
+	t.Parallel()
+
+	sut := newTestEvaluator(t,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:eng#member",
+		"group:eng#member@user:bob",
+		"group:eng#member@group:eng#member",
+	)
+
+	tree, err := sut.Expand(models.Object{Namespace: "document", ID: "readme"}, "editor")
+	require.NoError(t, err)
+
+	assert.Equal(t, relation.NodeUnion, tree.Kind)
+	assert.Equal(t, "document:readme#editor", tree.Userset.String())
+	require.Len(t, tree.Children, 2)
+
+	direct := tree.Children[0]
+	assert.Equal(t, relation.NodeDirect, direct.Kind)
+	assert.Equal(t, []string{"group:eng#member"}, subjectStrings(direct.Subjects))
+	require.Len(t, direct.Children, 1)
+
+	group := direct.Children[0]
+	assert.Equal(t, "group:eng#member", group.Userset.String())
+	require.Len(t, group.Children, 1)
+	assert.Equal(t, []string{"group:eng#member", "user:bob"}, subjectStrings(group.Children[0].Subjects))
+	require.Len(t, group.Children[0].Children, 1)
+	assert.Empty(t, group.Children[0].Children[0].Children, "the cycle must be cut off")
+
+	owner := tree.Children[1]
+	assert.Equal(t, "document:readme#owner", owner.Userset.String())
+	require.Len(t, owner.Children, 1)
+	assert.Equal(t, []string{"user:alice"}, subjectStrings(owner.Children[0].Subjects))
+}
+
+func TestParseSchema(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		schema string
+	}{
+		{
+			name:   "unknown field",
+			schema: "namespaces: {document: {relations: {owner: {computes: [editor]}}}}",
+		},
+		{
+			name:   "unknown computed relation",
+			schema: "namespaces: {document: {relations: {editor: {computed: [owner]}}}}",
+		},
+		{
+			name:   "unknown relation to inherit from",
+			schema: "namespaces: {document: {relations: {viewer: {inherited: [{from: parent, relation: viewer}]}}}}",
+		},
+		{
+			name:   "invalid namespace",
+			schema: "namespaces: {Document: {}}",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := relation.ParseSchema([]byte(tc.schema))
+			assert.ErrorIs(t, err, relation.ErrInvalidSchema)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		schema, err := relation.ParseSchema(nil)
+		require.NoError(t, err)
+		assert.Empty(t, schema.Namespaces())
+	})
+}
+
+func TestSchema_ValidateTuple(t *testing.T) {
+	t.Parallel()
+
+	schema, err := relation.ParseSchema([]byte(testSchema + "      auditor:\n        direct: false\n"))
+	require.NoError(t, err)
+
+	valid := []string{
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:eng#member",
+	}
+	invalid := []string{
+		"document:readme#commenter@user:alice",
+		"document:readme#owner@robot:r2",
+		"document:readme#owner@group:eng#admin",
+		"document:readme#auditor@user:alice",
+		"spreadsheet:budget#owner@user:alice",
+	}
+
+	for _, raw := range valid {
+		assert.NoError(t, schema.ValidateTuple(parseTuple(t, raw)), raw)
+	}
+
+	for _, raw := range invalid {
+		assert.ErrorIs(t, schema.ValidateTuple(parseTuple(t, raw)), relation.ErrInvalid, raw)
+	}
+}
+
+func TestParseTuple(t *testing.T) {
+	t.Parallel()
+
+	tuple, err := relation.ParseTuple("document:readme.md", "editor", "group:eng#member")
+	require.NoError(t, err)
+	assert.Equal(t, "document:readme.md#editor@group:eng#member", tuple.String())
+
+	for _, parts := range [][3]string{
+		{"readme", "editor", "user:alice"},
+		{"document:", "editor", "user:alice"},
+		{"Document:readme", "editor", "user:alice"},
+		{"document:readme", "", "user:alice"},
+		{"document:readme", "editor", "user:alice#"},
+		{"document:read me", "editor", "user:alice"},
+	} {
+		_, err := relation.ParseTuple(parts[0], parts[1], parts[2])
+		assert.ErrorIs(t, err, relation.ErrInvalid, parts)
+	}
+}
+
+type countingTupleReader struct {
+	relation.TupleReader
+	reads int
+}
+
+func (r *countingTupleReader) ReadTuples(filter *models.TupleFilter) ([]*models.Tuple, error) {
+	r.reads++
+
+	return r.TupleReader.ReadTuples(filter)
+}
+
+func newTestEvaluator(t *testing.T, tuples ...string) *relation.Evaluator {
+	t.Helper()
+
+	schema, err := relation.ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	writes := make([]*models.Tuple, len(tuples))
+	for i, raw := range tuples {
+		writes[i] = parseTuple(t, raw)
+	}
+
+	_, err = repo.WriteTuples(writes, nil)
+	require.NoError(t, err)
+
+	return relation.NewEvaluator(schema, repo)
+}
+
+// parseTuple parses a tuple written as object#relation@subject.
+func parseTuple(t *testing.T, raw string) *models.Tuple {
+	t.Helper()
+
+	rawObject, rest, _ := strings.Cut(raw, "#")
+	rawRelation, rawSubject, _ := strings.Cut(rest, "@")
+
+	tuple, err := relation.ParseTuple(rawObject, rawRelation, rawSubject)
+	require.NoError(t, err)
+
+	return tuple
+}
+
+func subjectStrings(subjects []models.Subject) []string {
+	raw := make([]string, len(subjects))
+	for i, subject := range subjects {
+		raw[i] = subject.String()
+	}
+
+	return raw
+}
@@ -0,0 +1,212 @@
+package relation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidSchema = errors.New("invalid namespace schema")
+
+// Schema declares the namespaces and their relations. A relation holds the subjects of its own tuples
+// (unless it is not direct), the subjects of the computed relations of the same object,
+// and the subjects that have the inherited relation to the objects related to it by another relation:
+//
+//	namespaces:
+//	  user: {}
+//	  folder:
+//	    relations:
+//	      viewer: {}
+//	  document:
+//	    relations:
+//	      parent: {}
+//	      owner: {}
+//	      editor:
+//	        computed: [owner]
+//	      viewer:
+//	        computed: [editor]
+//	        inherited:
+//	          - from: parent
+//	            relation: viewer
+type Schema struct {
+	namespaces map[string]*namespace
+}
+
+type namespace struct {
+	relations map[string]*Relation
+}
+
+type Relation struct {
+	// Direct relations may be written as tuples.
+	Direct    bool
+	Computed  []string
+	Inherited []Inherited
+}
+
+// Inherited grants the relation to the subjects that have Relation to the objects related by From,
+// such as the viewers of the parent folder of a document.
+type Inherited struct {
+	From     string
+	Relation string
+}
+
+type schemaDocument struct {
+	Namespaces map[string]struct {
+		Relations map[string]struct {
+			Direct    *bool    `yaml:"direct"`
+			Computed  []string `yaml:"computed"`
+			Inherited []struct {
+				From     string `yaml:"from"`
+				Relation string `yaml:"relation"`
+			} `yaml:"inherited"`
+		} `yaml:"relations"`
+	} `yaml:"namespaces"`
+}
+
+// ParseSchema parses a schema. An empty schema has no namespaces, so no tuples can be written.
+func ParseSchema(data []byte) (*Schema, error) {
+	var doc schemaDocument
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	schema := &Schema{
+		namespaces: make(map[string]*namespace, len(doc.Namespaces)),
+	}
+
+	for name, rawNamespace := range doc.Namespaces {
+		if !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid namespace %q", ErrInvalidSchema, name)
+		}
+
+		ns := &namespace{
+			relations: make(map[string]*Relation, len(rawNamespace.Relations)),
+		}
+
+		for relationName, rawRelation := range rawNamespace.Relations {
+			if !namePattern.MatchString(relationName) {
+				return nil, fmt.Errorf("%w: invalid relation %q of %q", ErrInvalidSchema, relationName, name)
+			}
+
+			relation := &Relation{
+				Direct:    rawRelation.Direct == nil || *rawRelation.Direct,
+				Computed:  rawRelation.Computed,
+				Inherited: make([]Inherited, len(rawRelation.Inherited)),
+			}
+
+			for i, inherited := range rawRelation.Inherited {
+				relation.Inherited[i] = Inherited{
+					From:     inherited.From,
+					Relation: inherited.Relation,
+				}
+			}
+
+			ns.relations[relationName] = relation
+		}
+
+		schema.namespaces[name] = ns
+	}
+
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+func (s *Schema) validate() error {
+	for name, ns := range s.namespaces {
+		for relationName, relation := range ns.relations {
+			for _, computed := range relation.Computed {
+				if _, ok := ns.relations[computed]; !ok {
+					return fmt.Errorf(
+						"%w: %s#%s computes unknown relation %q", ErrInvalidSchema, name, relationName, computed,
+					)
+				}
+			}
+
+			for _, inherited := range relation.Inherited {
+				if _, ok := ns.relations[inherited.From]; !ok {
+					return fmt.Errorf(
+						"%w: %s#%s inherits from unknown relation %q", ErrInvalidSchema, name, relationName, inherited.From,
+					)
+				}
+
+				if !s.hasRelation(inherited.Relation) {
+					return fmt.Errorf(
+						"%w: %s#%s inherits unknown relation %q", ErrInvalidSchema, name, relationName, inherited.Relation,
+					)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) hasRelation(relation string) bool {
+	for _, ns := range s.namespaces {
+		if _, ok := ns.relations[relation]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Relation returns the relation of the namespace, or nil if the schema does not declare it.
+func (s *Schema) Relation(namespace string, relation string) *Relation {
+	ns, ok := s.namespaces[namespace]
+	if !ok {
+		return nil
+	}
+
+	return ns.relations[relation]
+}
+
+// Namespaces returns the names of the declared namespaces in order.
+func (s *Schema) Namespaces() []string {
+	names := make([]string, 0, len(s.namespaces))
+	for name := range s.namespaces {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// ValidateTuple checks that the tuple only uses declared namespaces and relations, and that its relation is direct.
+func (s *Schema) ValidateTuple(tuple *models.Tuple) error {
+	relation := s.Relation(tuple.Object.Namespace, tuple.Relation)
+	switch {
+	case relation == nil:
+		return fmt.Errorf("%w: %s has no relation %q", ErrInvalid, tuple.Object.Namespace, tuple.Relation)
+	case !relation.Direct:
+		return fmt.Errorf("%w: %s#%s cannot be written directly", ErrInvalid, tuple.Object.Namespace, tuple.Relation)
+	}
+
+	return s.ValidateSubject(tuple.Subject)
+}
+
+func (s *Schema) ValidateSubject(subject models.Subject) error {
+	if _, ok := s.namespaces[subject.Object.Namespace]; !ok {
+		return fmt.Errorf("%w: unknown namespace %q", ErrInvalid, subject.Object.Namespace)
+	}
+
+	if subject.Relation != "" && s.Relation(subject.Object.Namespace, subject.Relation) == nil {
+		return fmt.Errorf("%w: %s has no relation %q", ErrInvalid, subject.Object.Namespace, subject.Relation)
+	}
+
+	return nil
+}
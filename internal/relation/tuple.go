@@ -0,0 +1,89 @@
+// Package relation implements relationship-based authorization in the style of Zanzibar:
+// tuples such as document:readme#editor@group:eng#member state relations between objects and subjects,
+// and a namespace schema derives further relations from them.
+package relation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid relation tuple")
+
+var (
+	namePattern     = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)       //nolint:gochecknoglobals
+	objectIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.|/@+=-]{1,256}$`) //nolint:gochecknoglobals
+)
+
+// ParseObject parses an object written as namespace:id, such as document:readme.
+func ParseObject(raw string) (models.Object, error) {
+	namespace, id, ok := strings.Cut(raw, ":")
+	if !ok {
+		return models.Object{}, fmt.Errorf("%w: object %q must be written as namespace:id", ErrInvalid, raw)
+	}
+
+	if !namePattern.MatchString(namespace) {
+		return models.Object{}, fmt.Errorf("%w: invalid namespace %q", ErrInvalid, namespace)
+	}
+
+	if !objectIDPattern.MatchString(id) {
+		return models.Object{}, fmt.Errorf("%w: invalid object id %q", ErrInvalid, id)
+	}
+
+	return models.Object{Namespace: namespace, ID: id}, nil
+}
+
+// ParseSubject parses a subject written as namespace:id or namespace:id#relation.
+func ParseSubject(raw string) (models.Subject, error) {
+	rawObject, relation, hasRelation := strings.Cut(raw, "#")
+
+	object, err := ParseObject(rawObject)
+	if err != nil {
+		return models.Subject{}, err
+	}
+
+	if hasRelation {
+		if err := validateRelationName(relation); err != nil {
+			return models.Subject{}, err
+		}
+	}
+
+	return models.Subject{Object: object, Relation: relation}, nil
+}
+
+// ParseTuple parses the parts of a tuple, such as "document:readme", "editor" and "group:eng#member".
+// The organization of the tuple is left empty.
+func ParseTuple(rawObject string, relation string, rawSubject string) (*models.Tuple, error) {
+	object, err := ParseObject(rawObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRelationName(relation); err != nil {
+		return nil, err
+	}
+
+	subject, err := ParseSubject(rawSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Tuple{
+		Organization: "",
+		Object:       object,
+		Relation:     relation,
+		Subject:      subject,
+	}, nil
+}
+
+func validateRelationName(relation string) error {
+	if !namePattern.MatchString(relation) {
+		return fmt.Errorf("%w: invalid relation %q", ErrInvalid, relation)
+	}
+
+	return nil
+}
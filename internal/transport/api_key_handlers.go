@@ -0,0 +1,196 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.ApiKeyServiceServer = (*APIKeyGRPCHandlers)(nil)
+
+type APIKeyGRPCHandlers struct {
+	proto.UnimplementedApiKeyServiceServer
+
+	apiKeyUseCases *usecases.APIKeyUseCases
+	authorization  *usecases.AuthorizationUseCases
+	authenticator  *Authenticator[*models.User]
+}
+
+func NewAPIKeyGRPCHandlers(
+	apiKeyUseCases *usecases.APIKeyUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *APIKeyGRPCHandlers {
+	return &APIKeyGRPCHandlers{
+		UnimplementedApiKeyServiceServer: proto.UnimplementedApiKeyServiceServer{},
+
+		apiKeyUseCases: apiKeyUseCases,
+		authorization:  authorization,
+		authenticator:  authenticator,
+	}
+}
+
+func (h *APIKeyGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterApiKeyServiceServer(registrar, h)
+}
+
+func (h *APIKeyGRPCHandlers) CreateApiKey( //nolint:revive,stylecheck
+	ctx context.Context,
+	request *proto.CreateApiKeyRequest,
+) (*proto.CreateApiKeyResponse, error) {
+	userID, err := h.resolveOwner(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if request.ExpireTime != nil {
+		expiresAt = request.ExpireTime.AsTime()
+	}
+
+	cmd, err := usecases.NewCreateAPIKeyCommand(userID, request.Name, request.Scopes, expiresAt, callerAPIKey(ctx))
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidAPIKey):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create CreateAPIKey command: %w", err)
+	}
+
+	created, err := h.apiKeyUseCases.CreateAPIKey(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrPermissionDenied):
+		return nil, status.Error(codes.PermissionDenied, "API key cannot create a key beyond its own scopes")
+	default:
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return &proto.CreateApiKeyResponse{
+		ApiKey: newProtoAPIKey(created.APIKey),
+		Key:    created.Key,
+	}, nil
+}
+
+func (h *APIKeyGRPCHandlers) ListApiKeys( //nolint:revive,stylecheck
+	ctx context.Context,
+	request *proto.ListApiKeysRequest,
+) (*proto.ListApiKeysResponse, error) {
+	userID, err := h.resolveOwner(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := usecases.NewListAPIKeysQuery(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ListAPIKeys query: %w", err)
+	}
+
+	keys, err := h.apiKeyUseCases.ListAPIKeys(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	response := &proto.ListApiKeysResponse{
+		ApiKeys: make([]*proto.ApiKey, len(keys)),
+	}
+	for i, key := range keys {
+		response.ApiKeys[i] = newProtoAPIKey(key)
+	}
+
+	return response, nil
+}
+
+func (h *APIKeyGRPCHandlers) RevokeApiKey( //nolint:revive,stylecheck
+	ctx context.Context,
+	request *proto.RevokeApiKeyRequest,
+) (*emptypb.Empty, error) {
+	userID, err := h.resolveOwner(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewRevokeAPIKeyCommand(userID, request.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RevokeAPIKey command: %w", err)
+	}
+
+	err = h.apiKeyUseCases.RevokeAPIKey(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "API key not found")
+	default:
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return empty, nil
+}
+
+// resolveOwner returns the ID of the owner of the keys: the caller by default,
+// or another user if the caller has the users.write permission and every permission of that user.
+func (h *APIKeyGRPCHandlers) resolveOwner(ctx context.Context, rawUserID string) (string, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	if rawUserID == "" {
+		return user.ID.String(), nil
+	}
+
+	if id, err := uuid.Parse(rawUserID); err == nil && id == user.ID {
+		return rawUserID, nil
+	}
+
+	if _, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionUsersWrite, usecases.UserResource(rawUserID),
+	); err != nil {
+		return "", err
+	}
+
+	return rawUserID, nil
+}
+
+// callerAPIKey returns the API key that the caller authenticated with, or an empty string.
+func callerAPIKey(ctx context.Context) string {
+	scheme, token, err := extractAuthToken(ctx)
+	if err != nil || scheme != APIKeyAuthScheme {
+		return ""
+	}
+
+	return token
+}
+
+func newProtoAPIKey(key *models.APIKey) *proto.ApiKey {
+	protoKey := &proto.ApiKey{
+		Id:         key.ID.String(),
+		UserId:     key.UserID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreateTime: timestamppb.New(key.CreatedAt),
+		ExpireTime: nil,
+	}
+
+	if !key.ExpiresAt.IsZero() {
+		protoKey.ExpireTime = timestamppb.New(key.ExpiresAt)
+	}
+
+	return protoKey
+}
@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+// The methods of AuthService must be public, since they are how callers get and drop their credentials.
+const (
+	AuthServiceLoginMethod   = "/users.AuthService/Login"
+	AuthServiceRefreshMethod = "/users.AuthService/Refresh"
+	AuthServiceLogoutMethod  = "/users.AuthService/Logout"
+)
+
+var _ proto.AuthServiceServer = (*AuthGRPCHandlers)(nil)
+
+type AuthGRPCHandlers struct {
+	proto.UnimplementedAuthServiceServer
+
+	authUseCases *usecases.AuthUseCases
+	lockouts     *usecases.LockoutUseCases
+}
+
+// NewAuthGRPCHandlers throttles Login with the lockouts, or does not throttle it if they are nil.
+func NewAuthGRPCHandlers(authUseCases *usecases.AuthUseCases, lockouts *usecases.LockoutUseCases) *AuthGRPCHandlers {
+	return &AuthGRPCHandlers{
+		UnimplementedAuthServiceServer: proto.UnimplementedAuthServiceServer{},
+
+		authUseCases: authUseCases,
+		lockouts:     lockouts,
+	}
+}
+
+func (h *AuthGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterAuthServiceServer(registrar, h)
+}
+
+// Login looks the username up in the organization named by the metadata of the request, or in the default one.
+func (h *AuthGRPCHandlers) Login(ctx context.Context, request *proto.LoginRequest) (*proto.LoginResponse, error) {
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := usecases.NewLoginCommand(organization, request.Username, request.Password)
+
+	tokens, err := throttlePasswordAttempt(ctx, h.lockouts, organization, request.Username, func() (*usecases.Tokens, error) {
+		return h.authUseCases.Login(ctx, cmd)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrLockedOut):
+		setRetryAfter(ctx, err)
+
+		return nil, status.Error(codes.ResourceExhausted, "Too many failed attempts, retry later")
+	case errors.Is(err, usecases.ErrPasswordPoolSaturated):
+		return nil, status.Error(codes.Unavailable, "Too many password verifications, retry later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case errors.Is(err, common.ErrInvalidCredentials):
+		return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+	default:
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+
+	return &proto.LoginResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        BearerAuthScheme,
+		ExpiresIn:        secondsUntil(tokens.AccessTokenExpiresAt),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: secondsUntil(tokens.RefreshTokenExpiresAt),
+	}, nil
+}
+
+func (h *AuthGRPCHandlers) Refresh(ctx context.Context, request *proto.RefreshRequest) (*proto.RefreshResponse, error) {
+	cmd := usecases.NewRefreshCommand(request.RefreshToken)
+
+	tokens, err := h.authUseCases.Refresh(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrRefreshTokenReused):
+		logger := common.ExtractLogger(ctx)
+		logger.WarnContext(ctx, "Refresh token reuse detected", slog.String("error", err.Error()))
+
+		return nil, status.Error(codes.Unauthenticated, "Invalid refresh token")
+	case errors.Is(err, common.ErrInvalidToken):
+		return nil, status.Error(codes.Unauthenticated, "Invalid refresh token")
+	default:
+		return nil, fmt.Errorf("failed to refresh tokens: %w", err)
+	}
+
+	return &proto.RefreshResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        BearerAuthScheme,
+		ExpiresIn:        secondsUntil(tokens.AccessTokenExpiresAt),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: secondsUntil(tokens.RefreshTokenExpiresAt),
+	}, nil
+}
+
+func (h *AuthGRPCHandlers) Logout(_ context.Context, request *proto.LogoutRequest) (*emptypb.Empty, error) {
+	cmd := usecases.NewLogoutCommand(request.RefreshToken)
+
+	if err := h.authUseCases.Logout(cmd); err != nil {
+		return nil, fmt.Errorf("failed to logout: %w", err)
+	}
+
+	return empty, nil
+}
+
+func secondsUntil(t time.Time) int64 {
+	return int64(time.Until(t).Round(time.Second).Seconds())
+}
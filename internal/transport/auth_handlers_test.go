@@ -0,0 +1,43 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+func TestAuthenticationErrorsDoNotRevealUsernames(t *testing.T) {
+	t.Parallel()
+
+	conn := startLockoutServer(t)
+	users := proto.NewUserServiceClient(conn)
+	auth := proto.NewAuthServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, wrongPassword := users.GetMe(withBasicAuth(ctx, "alice", "wrong"), &proto.GetMeRequest{})
+	_, unknownUser := users.GetMe(withBasicAuth(ctx, "mallory", "wrong"), &proto.GetMeRequest{})
+
+	assertSameStatus(t, wrongPassword, unknownUser)
+
+	_, wrongPassword = auth.Login(ctx, &proto.LoginRequest{Username: "alice", Password: "wrong"})
+	_, unknownUser = auth.Login(ctx, &proto.LoginRequest{Username: "mallory", Password: "wrong"})
+
+	assertSameStatus(t, wrongPassword, unknownUser)
+}
+
+func assertSameStatus(t *testing.T, wrongPassword error, unknownUser error) {
+	t.Helper()
+
+	require.Equal(t, codes.Unauthenticated, status.Code(wrongPassword))
+	assert.Equal(t, status.Code(wrongPassword), status.Code(unknownUser))
+	assert.Equal(t, status.Convert(wrongPassword).Message(), status.Convert(unknownUser).Message())
+}
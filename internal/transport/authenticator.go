@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+const (
+	BasicAuthScheme  = "Basic"
+	BearerAuthScheme = "Bearer"
+	APIKeyAuthScheme = "ApiKey"
+)
+
+// apiKeyHeaderKey is an alternative to "authorization: ApiKey <key>" for clients that cannot set the scheme.
+const apiKeyHeaderKey = "x-api-key"
+
+// Authenticator authenticates every request, except for public methods,
+// with one of the configured schemes of the authorization header or, without the header,
+// with the client certificate of the connection.
+type Authenticator[UserModel any] struct {
+	schemes               map[string]schemeAuthFn[UserModel]
+	clientCertificateAuth func(ctx context.Context) (UserModel, error)
+	publicMethods         map[string]struct{}
+	methodChecks          []MethodCheckFn[UserModel]
+	lockouts              *usecases.LockoutUseCases
+}
+
+// AuthFn authenticates the username and password of a user of the organization named by the request,
+// or of the default organization.
+type AuthFn[UserModel any] func(ctx context.Context, organization, username, password string) (UserModel, error)
+
+// TokenAuthFn authenticates the credentials that follow the scheme in the authorization header.
+type TokenAuthFn[UserModel any] func(token string) (UserModel, error)
+
+// UsernameAuthFn returns the user with the username in the organization named by the request,
+// or in the default organization, for credentials that have been verified by other means.
+type UsernameAuthFn[UserModel any] func(organization, username string) (UserModel, error)
+
+// ScopedTokenAuthFn authenticates credentials that are only valid for some of the full gRPC methods.
+// It fails with common.ErrPermissionDenied if the credentials are valid, but not for the method.
+type ScopedTokenAuthFn[UserModel any] func(token string, method string) (UserModel, error)
+
+// MethodCheckFn fails if the authenticated user must not call the full gRPC method.
+type MethodCheckFn[UserModel any] func(user UserModel, method string) error
+
+type schemeAuthFn[UserModel any] func(ctx context.Context, token string, method string) (UserModel, error)
+
+type AuthenticatorOption[UserModel any] func(*Authenticator[UserModel])
+
+func WithBearerAuth[UserModel any](authFn TokenAuthFn[UserModel]) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		a.schemes[BearerAuthScheme] = func(_ context.Context, token string, _ string) (UserModel, error) {
+			return authFn(token)
+		}
+	}
+}
+
+// WithAPIKeyAuth accepts keys sent as "authorization: ApiKey <key>" or "x-api-key: <key>".
+func WithAPIKeyAuth[UserModel any](authFn ScopedTokenAuthFn[UserModel]) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		a.schemes[APIKeyAuthScheme] = func(_ context.Context, token string, method string) (UserModel, error) {
+			return authFn(token, method)
+		}
+	}
+}
+
+// WithPublicMethods lets the full gRPC methods, such as "/users.AuthService/Login", be called anonymously.
+func WithPublicMethods[UserModel any](methods ...string) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		for _, method := range methods {
+			a.publicMethods[method] = struct{}{}
+		}
+	}
+}
+
+// WithMethodCheck rejects the calls of the users the check fails for, whatever credentials they authenticate with.
+func WithMethodCheck[UserModel any](check MethodCheckFn[UserModel]) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		a.methodChecks = append(a.methodChecks, check)
+	}
+}
+
+func NewAuthenticator[UserModel any](
+	authFn AuthFn[UserModel],
+	opts ...AuthenticatorOption[UserModel],
+) *Authenticator[UserModel] {
+	a := &Authenticator[UserModel]{
+		schemes:               make(map[string]schemeAuthFn[UserModel]),
+		clientCertificateAuth: nil,
+		publicMethods:         make(map[string]struct{}),
+		methodChecks:          nil,
+		lockouts:              nil,
+	}
+	a.schemes[BasicAuthScheme] = a.basicAuth(authFn)
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type authContextKey struct{}
+
+var (
+	errMissingAuthorization = errors.New("missing authorization")
+	errInvalidScheme        = errors.New("invalid authorization scheme")
+)
+
+func (a *Authenticator[UserModel]) AuthUnaryInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if _, ok := a.publicMethods[info.FullMethod]; ok {
+		return handler(ctx, req)
+	}
+
+	user, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		setRetryAfter(ctx, err)
+
+		return nil, authenticationError(err)
+	}
+
+	ctx = context.WithValue(ctx, authContextKey{}, user)
+
+	return handler(ctx, req)
+}
+
+func (a *Authenticator[UserModel]) authenticate(ctx context.Context, method string) (UserModel, error) { //nolint:ireturn
+	var zero UserModel
+
+	scheme, token, err := extractAuthToken(ctx)
+	switch {
+	case err == nil:
+	case errors.Is(err, errMissingAuthorization) && a.clientCertificateAuth != nil:
+		user, err := a.clientCertificateAuth(ctx)
+		if err != nil {
+			return zero, err
+		}
+
+		return a.checkMethod(user, method)
+	default:
+		return zero, err
+	}
+
+	return a.authenticateToken(ctx, scheme, token, method)
+}
+
+func (a *Authenticator[UserModel]) authenticateToken( //nolint:ireturn
+	ctx context.Context,
+	scheme string,
+	token string,
+	method string,
+) (UserModel, error) {
+	var zero UserModel
+
+	authFn, ok := a.schemes[scheme]
+	if !ok {
+		return zero, fmt.Errorf("%w %q", errInvalidScheme, scheme)
+	}
+
+	user, err := authFn(ctx, token, method)
+	if err != nil {
+		return zero, err
+	}
+
+	return a.checkMethod(user, method)
+}
+
+func (a *Authenticator[UserModel]) checkMethod(user UserModel, method string) (UserModel, error) { //nolint:ireturn
+	for _, check := range a.methodChecks {
+		if err := check(user, method); err != nil {
+			var zero UserModel
+
+			return zero, err
+		}
+	}
+
+	return user, nil
+}
+
+// authenticationError converts an error of authentication into the status returned to the caller.
+func authenticationError(err error) error {
+	switch {
+	case errors.Is(err, errMissingAuthorization):
+		return status.Error(codes.Unauthenticated, "Missing authorization token")
+	case errors.Is(err, errInvalidScheme):
+		return status.Error(codes.Unauthenticated, "Invalid authorization schema")
+	case errors.Is(err, common.ErrNotFound), errors.Is(err, common.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "Invalid credentials")
+	case errors.Is(err, common.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "Invalid authorization token")
+	case errors.Is(err, errEmailNotVerified):
+		return status.Error(codes.PermissionDenied, "Email is not verified")
+	case errors.Is(err, common.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "Credentials are not allowed to call this method")
+	case errors.Is(err, usecases.ErrLockedOut):
+		return status.Error(codes.ResourceExhausted, "Too many failed attempts, retry later")
+	case errors.Is(err, usecases.ErrPasswordPoolSaturated):
+		return status.Error(codes.Unavailable, "Too many password verifications, retry later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return fmt.Errorf("failed to authenticate user: %w", err)
+	}
+}
+
+func extractAuthToken(ctx context.Context) (string, string, error) {
+	const authorizationHeaderKey = "authorization"
+	const authorizationHeaderSeparator = " "
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", "", fmt.Errorf("%w: missing metadata", errMissingAuthorization)
+	}
+
+	authHeader, ok := md[authorizationHeaderKey]
+	if !ok {
+		if apiKey := md[apiKeyHeaderKey]; len(apiKey) == 1 {
+			return APIKeyAuthScheme, apiKey[0], nil
+		}
+	}
+
+	if len(authHeader) != 1 {
+		return "", "", errMissingAuthorization
+	}
+
+	scheme, token, ok := strings.Cut(authHeader[0], authorizationHeaderSeparator)
+	if !ok {
+		return "", "", fmt.Errorf("%w: authorization header without scheme", common.ErrInvalidToken)
+	}
+
+	return scheme, token, nil
+}
+
+func (*Authenticator[UserModel]) GetAuthenticatedUser(ctx context.Context) (UserModel, error) { //nolint:ireturn
+	var zero UserModel
+
+	user, ok := ctx.Value(authContextKey{}).(UserModel)
+	if !ok {
+		return zero, status.Error(codes.Internal, "Failed to get authenticated user")
+	}
+
+	return user, nil
+}
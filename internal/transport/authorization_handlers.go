@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.AuthorizationServiceServer = (*AuthorizationGRPCHandlers)(nil)
+
+type AuthorizationGRPCHandlers struct {
+	proto.UnimplementedAuthorizationServiceServer
+
+	authorization *usecases.AuthorizationUseCases
+	authenticator *Authenticator[*models.User]
+}
+
+func NewAuthorizationGRPCHandlers(
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *AuthorizationGRPCHandlers {
+	return &AuthorizationGRPCHandlers{
+		UnimplementedAuthorizationServiceServer: proto.UnimplementedAuthorizationServiceServer{},
+
+		authorization: authorization,
+		authenticator: authenticator,
+	}
+}
+
+func (h *AuthorizationGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterAuthorizationServiceServer(registrar, h)
+}
+
+func (h *AuthorizationGRPCHandlers) Check(
+	ctx context.Context,
+	request *proto.CheckRequest,
+) (*proto.CheckResponse, error) {
+	query, err := h.newCheckQuery(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := h.authorization.Check(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check: %w", err)
+	}
+
+	return newProtoCheckResponse(decision), nil
+}
+
+func (h *AuthorizationGRPCHandlers) BatchCheck(
+	ctx context.Context,
+	request *proto.BatchCheckRequest,
+) (*proto.BatchCheckResponse, error) {
+	queries := make([]*usecases.CheckQuery, len(request.Checks))
+	for i, check := range request.Checks {
+		query, err := h.newCheckQuery(ctx, check)
+		if err != nil {
+			if s, ok := status.FromError(err); ok {
+				return nil, status.Errorf(s.Code(), "Check %d: %s", i, s.Message())
+			}
+
+			return nil, err
+		}
+
+		queries[i] = query
+	}
+
+	decisions, err := h.authorization.BatchCheck(queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch check: %w", err)
+	}
+
+	response := &proto.BatchCheckResponse{
+		Results: make([]*proto.CheckResponse, len(decisions)),
+	}
+	for i, decision := range decisions {
+		response.Results[i] = newProtoCheckResponse(decision)
+	}
+
+	return response, nil
+}
+
+// newCheckQuery defaults the subject to the caller. Checking other subjects requires users.read on them,
+// so that the service cannot be used to find out what other users may do.
+func (h *AuthorizationGRPCHandlers) newCheckQuery(
+	ctx context.Context,
+	request *proto.CheckRequest,
+) (*usecases.CheckQuery, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	subject := request.Subject
+	if subject == "" {
+		subject = user.ID.String()
+	}
+
+	query, err := usecases.NewCheckQuery(subject, request.Action, request.Resource)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidCheck):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create Check query: %w", err)
+	}
+
+	if query.SubjectID() != user.ID {
+		_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionUsersRead, usecases.UserResource(subject))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func newProtoCheckResponse(decision *usecases.Decision) *proto.CheckResponse {
+	return &proto.CheckResponse{
+		Allowed: decision.Allowed,
+		Reason:  decision.Reason,
+	}
+}
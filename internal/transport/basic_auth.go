@@ -3,92 +3,33 @@ package transport
 import (
 	"context"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"strings"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
-
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
 )
 
-type Authenticator[UserModel any] struct {
-	authFn func(username, password string) (UserModel, error)
-}
-
-type AuthFn[UserModel any] func(username, password string) (UserModel, error)
-
-func NewAuthenticator[UserModel any](authFn AuthFn[UserModel]) *Authenticator[UserModel] {
-	return &Authenticator[UserModel]{
-		authFn: authFn,
-	}
-}
-
-type authContextKey struct{}
-
 type basicAuthCredentials struct {
 	username string
 	password string
 }
 
-func (a *Authenticator[UserModel]) BasicAuthUnaryInterceptor(
-	ctx context.Context,
-	req any,
-	_ *grpc.UnaryServerInfo,
-	handler grpc.UnaryHandler,
-) (any, error) {
-	const expectedSchema = "Basic"
+// basicAuth checks the credentials with authFn, unless the username or the address of the caller is locked out.
+func (a *Authenticator[UserModel]) basicAuth(authFn AuthFn[UserModel]) schemeAuthFn[UserModel] {
+	return func(ctx context.Context, token string, _ string) (UserModel, error) {
+		credentials, err := getBasicAuthCredentialsFromToken(token)
+		if err != nil {
+			var zero UserModel
 
-	token, err := extractAuthToken(ctx, expectedSchema)
-	if err != nil {
-		return nil, err
-	}
+			return zero, err
+		}
 
-	credentials, err := getBasicAuthCredentialsFromToken(token)
-	if err != nil {
-		return nil, err
-	}
+		organization := usernameOrganization(ctx)
 
-	user, err := a.authFn(credentials.username, credentials.password)
-	switch {
-	case err == nil:
-	case errors.Is(err, common.ErrNotFound):
-		return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
-	default:
-		return nil, fmt.Errorf("failed to authenticate user: %w", err)
+		return throttlePasswordAttempt(ctx, a.lockouts, organization, credentials.username, func() (UserModel, error) {
+			return authFn(ctx, organization, credentials.username, credentials.password)
+		})
 	}
-
-	ctx = context.WithValue(ctx, authContextKey{}, user)
-
-	return handler(ctx, req)
-}
-
-func extractAuthToken(ctx context.Context, expectedSchema string) (string, error) {
-	const authorizationHeaderKey = "authorization"
-	const authorizationHeaderSeparator = " "
-	const authorizationHeaderComponentsNumber = 2
-
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return "", status.Error(codes.Unauthenticated, "Missing metadata")
-	}
-
-	authHeader, ok := md[authorizationHeaderKey]
-	if !ok || len(authHeader) != 1 {
-		return "", status.Error(codes.Unauthenticated, "Missing authorization token")
-	}
-
-	components := strings.SplitN(authHeader[0], authorizationHeaderSeparator, authorizationHeaderComponentsNumber)
-	schema, token := components[0], components[1]
-
-	if schema != expectedSchema {
-		return "", status.Error(codes.Unauthenticated, "Invalid authorization schema")
-	}
-
-	return token, nil
 }
 
 func getBasicAuthCredentialsFromToken(token string) (*basicAuthCredentials, error) {
@@ -96,12 +37,12 @@ func getBasicAuthCredentialsFromToken(token string) (*basicAuthCredentials, erro
 
 	decoded, err := base64.StdEncoding.DecodeString(token)
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "Invalid authorization token")
+		return nil, fmt.Errorf("%w: %w", common.ErrInvalidToken, err)
 	}
 
 	credentials := strings.SplitN(string(decoded), ":", credentialsNumber)
 	if len(credentials) != credentialsNumber {
-		return nil, status.Error(codes.Unauthenticated, "Invalid authorization token")
+		return nil, fmt.Errorf("%w: missing password separator", common.ErrInvalidToken)
 	}
 
 	return &basicAuthCredentials{
@@ -109,14 +50,3 @@ func getBasicAuthCredentialsFromToken(token string) (*basicAuthCredentials, erro
 		password: credentials[1],
 	}, nil
 }
-
-func (*Authenticator[UserModel]) GetAuthenticatedUser(ctx context.Context) (UserModel, error) { //nolint:ireturn
-	var zero UserModel
-
-	user, ok := ctx.Value(authContextKey{}).(UserModel)
-	if !ok {
-		return zero, status.Error(codes.Internal, "Failed to get authenticated user")
-	}
-
-	return user, nil
-}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+)
+
+// CertificateIdentity is the field of a client certificate that identifies the user.
+type CertificateIdentity string
+
+const (
+	CertificateSubjectCommonName CertificateIdentity = "subject_cn"
+	CertificateDNSNames          CertificateIdentity = "san_dns"
+	CertificateEmailAddresses    CertificateIdentity = "san_email"
+	CertificateURIs              CertificateIdentity = "san_uri"
+)
+
+func ParseCertificateIdentity(raw string) (CertificateIdentity, error) {
+	switch identity := CertificateIdentity(raw); identity {
+	case CertificateSubjectCommonName, CertificateDNSNames, CertificateEmailAddresses, CertificateURIs:
+		return identity, nil
+	default:
+		return "", fmt.Errorf(
+			"unknown certificate identity %q, expected %q, %q, %q or %q",
+			raw, CertificateSubjectCommonName, CertificateDNSNames, CertificateEmailAddresses, CertificateURIs,
+		)
+	}
+}
+
+func (i CertificateIdentity) names(certificate *x509.Certificate) []string {
+	switch i {
+	case CertificateSubjectCommonName:
+		if certificate.Subject.CommonName == "" {
+			return nil
+		}
+
+		return []string{certificate.Subject.CommonName}
+	case CertificateDNSNames:
+		return certificate.DNSNames
+	case CertificateEmailAddresses:
+		return certificate.EmailAddresses
+	case CertificateURIs:
+		names := make([]string, len(certificate.URIs))
+		for j, uri := range certificate.URIs {
+			names[j] = uri.String()
+		}
+
+		return names
+	default:
+		return nil
+	}
+}
+
+// WithClientCertificateAuth authenticates requests without an authorization header by the verified
+// client certificate of the connection. Every name of the identity field is passed to authFn
+// until one of them belongs to a user of the organization named by the request.
+func WithClientCertificateAuth[UserModel any](
+	identity CertificateIdentity,
+	authFn UsernameAuthFn[UserModel],
+) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		a.clientCertificateAuth = func(ctx context.Context) (UserModel, error) {
+			var zero UserModel
+
+			certificate, ok := verifiedClientCertificate(ctx)
+			if !ok {
+				return zero, errMissingAuthorization
+			}
+
+			for _, name := range identity.names(certificate) {
+				user, err := authFn(usernameOrganization(ctx), name)
+				if errors.Is(err, common.ErrNotFound) || errors.Is(err, common.ErrInvalidCredentials) {
+					continue
+				}
+
+				return user, err
+			}
+
+			return zero, fmt.Errorf(
+				"%w: no user matches the client certificate %q",
+				common.ErrInvalidCredentials, certificate.Subject,
+			)
+		}
+	}
+}
+
+// verifiedClientCertificate returns the leaf client certificate if it has been verified against the client CAs.
+func verifiedClientCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0], true
+}
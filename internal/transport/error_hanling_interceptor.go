@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"google.golang.org/grpc"
@@ -9,6 +10,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
 )
 
 func ErrorHandlingUnaryInterceptor(
@@ -21,6 +23,10 @@ func ErrorHandlingUnaryInterceptor(
 	switch {
 	case err == nil:
 		return resp, nil
+	case errors.Is(err, usecases.ErrPasswordPoolSaturated):
+		return nil, status.Error(codes.Unavailable, "Too many password verifications, retry later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
 	case status.Code(err) == codes.Unknown || status.Code(err) == codes.Internal:
 		return nil, InternalError(ctx, err)
 	default:
@@ -0,0 +1,250 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+// ExtAuthzCheckMethod must be public: Envoy calls it on behalf of the downstream client,
+// whose credentials are in the request rather than in the metadata of the call.
+const ExtAuthzCheckMethod = "/envoy.service.auth.v3.Authorization/Check"
+
+// The headers ExtAuthzGRPCHandlers sets on allowed requests, replacing the ones sent by the client.
+const (
+	UserIDHeaderKey           = "x-user-id"
+	UserOrganizationHeaderKey = "x-user-organization"
+	UserRolesHeaderKey        = "x-user-roles"
+)
+
+var _ authv3.AuthorizationServer = (*ExtAuthzGRPCHandlers)(nil)
+
+// TrustedProxies are the callers of Check whose source addresses of proxied requests are trusted
+// for lockouts, identified by the network of the address of the call or by a name of its verified
+// client certificate. The source addresses sent by anyone else are ignored, since they could be rotated
+// to evade lockouts of addresses.
+type TrustedProxies struct {
+	Networks []netip.Prefix
+	// CertificateNames match the subject common name and the subject alternative names.
+	CertificateNames []string
+}
+
+// ParseTrustedProxies parses comma separated IP addresses, networks in CIDR notation and certificate names.
+func ParseTrustedProxies(raw string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{
+		Networks:         nil,
+		CertificateNames: nil,
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/") && !strings.Contains(entry, "://"):
+			network, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse trusted network %q: %w", entry, err)
+			}
+
+			proxies.Networks = append(proxies.Networks, network.Masked())
+		default:
+			if address, err := netip.ParseAddr(entry); err == nil {
+				proxies.Networks = append(proxies.Networks, netip.PrefixFrom(address, address.BitLen()))
+
+				continue
+			}
+
+			proxies.CertificateNames = append(proxies.CertificateNames, entry)
+		}
+	}
+
+	return proxies, nil
+}
+
+// trusts reports whether the call comes from a trusted proxy.
+func (p *TrustedProxies) trusts(ctx context.Context) bool {
+	if p == nil {
+		return false
+	}
+
+	if certificate, ok := verifiedClientCertificate(ctx); ok {
+		for _, identity := range []CertificateIdentity{
+			CertificateSubjectCommonName, CertificateDNSNames, CertificateEmailAddresses, CertificateURIs,
+		} {
+			for _, name := range identity.names(certificate) {
+				if slices.Contains(p.CertificateNames, name) {
+					return true
+				}
+			}
+		}
+	}
+
+	caller, ok := peer.FromContext(ctx)
+	if !ok || caller.Addr == nil {
+		return false
+	}
+
+	address, err := netip.ParseAddrPort(caller.Addr.String())
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(p.Networks, func(network netip.Prefix) bool {
+		return network.Contains(address.Addr().Unmap())
+	})
+}
+
+// ExtAuthzGRPCHandlers is an Envoy external authorization server that authenticates proxied HTTP requests
+// with the same schemes as the requests of this service. Client certificates are not supported,
+// since the certificate of the call belongs to Envoy.
+type ExtAuthzGRPCHandlers struct {
+	authv3.UnimplementedAuthorizationServer
+
+	authenticator  *Authenticator[*models.User]
+	roleUseCases   *usecases.RoleUseCases
+	trustedProxies *TrustedProxies
+}
+
+// NewExtAuthzGRPCHandlers locks out the source addresses of requests proxied by the trusted proxies,
+// and the address of the call otherwise. Without trusted proxies the address of the call is always used.
+func NewExtAuthzGRPCHandlers(
+	authenticator *Authenticator[*models.User],
+	roleUseCases *usecases.RoleUseCases,
+	trustedProxies *TrustedProxies,
+) *ExtAuthzGRPCHandlers {
+	return &ExtAuthzGRPCHandlers{
+		UnimplementedAuthorizationServer: authv3.UnimplementedAuthorizationServer{},
+
+		authenticator:  authenticator,
+		roleUseCases:   roleUseCases,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// Register only accepts *grpc.Server, which is all the generated code of go-control-plane supports.
+func (h *ExtAuthzGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	server, ok := registrar.(*grpc.Server)
+	if !ok {
+		panic(fmt.Sprintf("ext_authz handlers cannot be registered with %T", registrar))
+	}
+
+	authv3.RegisterAuthorizationServer(server, h)
+}
+
+// Check authenticates the headers of the proxied request. The path of the request is the method
+// that API key scopes are checked against, which is the full gRPC method for proxied gRPC requests.
+// Basic credentials are checked in the organization named by the x-organization header of the request.
+func (h *ExtAuthzGRPCHandlers) Check(ctx context.Context, request *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	httpRequest := request.GetAttributes().GetRequest().GetHttp()
+	method, _, _ := strings.Cut(httpRequest.GetPath(), "?")
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.New(httpRequest.GetHeaders()))
+	if h.trustedProxies.trusts(ctx) {
+		ctx = withCallerAddress(ctx, request.GetAttributes().GetSource().GetAddress().GetSocketAddress().GetAddress())
+	}
+
+	user, err := h.authenticate(ctx, method)
+	if err != nil {
+		s, ok := status.FromError(authenticationError(err))
+		if !ok {
+			return nil, fmt.Errorf("failed to authenticate proxied request: %w", err)
+		}
+
+		var headers []*corev3.HeaderValueOption
+		if seconds, ok := retryAfterSeconds(err); ok {
+			headers = append(headers, newOverwritingHeader(RetryAfterTrailerKey, seconds))
+		}
+
+		return newDeniedCheckResponse(s, headers), nil
+	}
+
+	roles, err := h.roleUseCases.EffectiveRoles(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effective roles: %w", err)
+	}
+
+	return newOkCheckResponse(user, roles), nil
+}
+
+func (h *ExtAuthzGRPCHandlers) authenticate(ctx context.Context, method string) (*models.User, error) {
+	scheme, token, err := extractAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.authenticator.authenticateToken(ctx, scheme, token, method)
+}
+
+func newOkCheckResponse(user *models.User, roles []string) *authv3.CheckResponse {
+	return &authv3.CheckResponse{
+		Status: &rpcstatus.Status{
+			Code:    int32(codes.OK),
+			Message: "",
+			Details: nil,
+		},
+		HttpResponse: &authv3.CheckResponse_OkResponse{
+			OkResponse: &authv3.OkHttpResponse{
+				Headers: []*corev3.HeaderValueOption{
+					newOverwritingHeader(UserIDHeaderKey, user.ID.String()),
+					newOverwritingHeader(UserOrganizationHeaderKey, user.Organization),
+					newOverwritingHeader(UserRolesHeaderKey, strings.Join(roles, ",")),
+				},
+			},
+		},
+	}
+}
+
+func newDeniedCheckResponse(s *status.Status, headers []*corev3.HeaderValueOption) *authv3.CheckResponse {
+	httpStatus := typev3.StatusCode_Forbidden
+	switch s.Code() { //nolint:exhaustive
+	case codes.Unauthenticated:
+		httpStatus = typev3.StatusCode_Unauthorized
+	case codes.ResourceExhausted:
+		httpStatus = typev3.StatusCode_TooManyRequests
+	case codes.Unavailable:
+		httpStatus = typev3.StatusCode_ServiceUnavailable
+	}
+
+	return &authv3.CheckResponse{
+		Status: s.Proto(),
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status: &typev3.HttpStatus{
+					Code: httpStatus,
+				},
+				Headers: headers,
+				Body:    s.Message(),
+			},
+		},
+	}
+}
+
+// newOverwritingHeader replaces the header of the client, even with an empty value,
+// so that the upstream can trust it.
+func newOverwritingHeader(key string, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{
+			Key:   key,
+			Value: value,
+		},
+		AppendAction:   corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		KeepEmptyValue: true,
+	}
+}
@@ -0,0 +1,308 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure/repositorytest"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+const getAllUsersMethod = "/users.UserService/GetAllUsers"
+
+func TestExtAuthz(t *testing.T) {
+	t.Parallel()
+
+	client, userID, apiKey := startExtAuthzServer(t, nil)
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:password"))
+	wrongPassword := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:wrong"))
+
+	testCases := []struct {
+		name       string
+		path       string
+		headers    map[string]string
+		statusCode codes.Code
+		httpStatus typev3.StatusCode
+	}{
+		{
+			name:       "basic auth",
+			path:       "/orders",
+			headers:    map[string]string{"authorization": basic, transport.UserIDHeaderKey: "spoofed"},
+			statusCode: codes.OK,
+			httpStatus: 0,
+		},
+		{
+			name:       "api key in scope",
+			path:       getAllUsersMethod + "?page_size=10",
+			headers:    map[string]string{"x-api-key": apiKey},
+			statusCode: codes.OK,
+			httpStatus: 0,
+		},
+		{
+			name:       "api key out of scope",
+			path:       "/users.UserService/DeleteUser",
+			headers:    map[string]string{"authorization": "ApiKey " + apiKey},
+			statusCode: codes.PermissionDenied,
+			httpStatus: typev3.StatusCode_Forbidden,
+		},
+		{
+			name:       "missing authorization",
+			path:       "/orders",
+			headers:    nil,
+			statusCode: codes.Unauthenticated,
+			httpStatus: typev3.StatusCode_Unauthorized,
+		},
+		{
+			name:       "invalid credentials",
+			path:       "/orders",
+			headers:    map[string]string{"authorization": wrongPassword},
+			statusCode: codes.Unauthenticated,
+			httpStatus: typev3.StatusCode_Unauthorized,
+		},
+		{
+			name:       "unknown scheme",
+			path:       "/orders",
+			headers:    map[string]string{"authorization": "Digest username=alice"},
+			statusCode: codes.Unauthenticated,
+			httpStatus: typev3.StatusCode_Unauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			response, err := client.Check(ctx, &authv3.CheckRequest{
+				Attributes: &authv3.AttributeContext{
+					Request: &authv3.AttributeContext_Request{
+						Http: &authv3.AttributeContext_HttpRequest{
+							Method:  "GET",
+							Path:    tc.path,
+							Headers: tc.headers,
+						},
+					},
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int32(tc.statusCode), response.GetStatus().GetCode())
+
+			if tc.statusCode != codes.OK {
+				assert.Equal(t, tc.httpStatus, response.GetDeniedResponse().GetStatus().GetCode())
+
+				return
+			}
+
+			headers := make(map[string]string)
+			for _, header := range response.GetOkResponse().GetHeaders() {
+				headers[header.GetHeader().GetKey()] = header.GetHeader().GetValue()
+			}
+
+			assert.Equal(t, map[string]string{
+				transport.UserIDHeaderKey:           userID,
+				transport.UserRolesHeaderKey:        models.SuperuserRole + ",support,auditor",
+				transport.UserOrganizationHeaderKey: models.DefaultOrganization,
+			}, headers)
+		})
+	}
+}
+
+func TestExtAuthzLockout(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, client authv3.AuthorizationClient, source string, password string) *authv3.CheckResponse {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		response, err := client.Check(ctx, &authv3.CheckRequest{
+			Attributes: &authv3.AttributeContext{
+				Source: &authv3.AttributeContext_Peer{
+					Address: &corev3.Address{
+						Address: &corev3.Address_SocketAddress{
+							SocketAddress: &corev3.SocketAddress{
+								Address:       source,
+								PortSpecifier: &corev3.SocketAddress_PortValue{PortValue: 51234},
+							},
+						},
+					},
+				},
+				Request: &authv3.AttributeContext_Request{
+					Http: &authv3.AttributeContext_HttpRequest{
+						Method: "GET",
+						Path:   "/orders",
+						Headers: map[string]string{
+							"authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:"+password)),
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		return response
+	}
+
+	t.Run("untrusted caller", func(t *testing.T) {
+		t.Parallel()
+
+		client, _, _ := startExtAuthzServer(t, nil)
+
+		check(t, client, "203.0.113.7", "wrong")
+		check(t, client, "203.0.113.8", "wrong")
+
+		response := check(t, client, "203.0.113.9", "password")
+		assert.Equal(t, int32(codes.ResourceExhausted), response.GetStatus().GetCode(),
+			"rotating the source address does not evade the lockout of the caller")
+		assert.Equal(t, typev3.StatusCode_TooManyRequests, response.GetDeniedResponse().GetStatus().GetCode())
+
+		headers := response.GetDeniedResponse().GetHeaders()
+		require.Len(t, headers, 1)
+		assert.Equal(t, transport.RetryAfterTrailerKey, headers[0].GetHeader().GetKey())
+		assert.Equal(t, "60", headers[0].GetHeader().GetValue())
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		t.Parallel()
+
+		trustedProxies, err := transport.ParseTrustedProxies("10.0.0.0/8, 127.0.0.1, envoy.internal")
+		require.NoError(t, err)
+
+		client, _, _ := startExtAuthzServer(t, trustedProxies)
+
+		check(t, client, "203.0.113.7", "wrong")
+		check(t, client, "203.0.113.7", "wrong")
+
+		response := check(t, client, "203.0.113.7", "password")
+		assert.Equal(t, int32(codes.ResourceExhausted), response.GetStatus().GetCode())
+
+		response = check(t, client, "203.0.113.8", "password")
+		assert.Equal(t, int32(codes.OK), response.GetStatus().GetCode(), "other clients of the proxy are not locked out")
+	})
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	trustedProxies, err := transport.ParseTrustedProxies("10.1.2.3/8, ::1,,spiffe://cluster/envoy, fd00::/8")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+		netip.MustParsePrefix("fd00::/8"),
+	}, trustedProxies.Networks)
+	assert.Equal(t, []string{"spiffe://cluster/envoy"}, trustedProxies.CertificateNames)
+
+	_, err = transport.ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+}
+
+func startExtAuthzServer( //nolint:ireturn
+	t *testing.T,
+	trustedProxies *transport.TrustedProxies,
+) (authv3.AuthorizationClient, string, string) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+	userID, err := userUseCases.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(
+			models.DefaultOrganization,
+			"alice",
+			"alice@corp.com",
+			"password",
+			[]string{models.SuperuserRole, "support"},
+		),
+	)
+	require.NoError(t, err)
+
+	group := repositorytest.NewGroup("auditors", "auditor", "support")
+	group.UserIDs = []uuid.UUID{userID}
+	require.NoError(t, repo.SaveGroup(group))
+
+	apiKeyUseCases := usecases.NewAPIKeyUseCases(repo, repo)
+	apiKeyCmd, err := usecases.NewCreateAPIKeyCommand(
+		userID.String(), "proxy", []string{getAllUsersMethod}, time.Time{}, "",
+	)
+	require.NoError(t, err)
+
+	created, err := apiKeyUseCases.CreateAPIKey(apiKeyCmd)
+	require.NoError(t, err)
+
+	authenticator := transport.NewAuthenticator(
+		userUseCases.AuthenticateUser,
+		transport.WithAPIKeyAuth(apiKeyUseCases.AuthenticateAPIKey),
+		transport.WithPublicMethods[*models.User](transport.ExtAuthzCheckMethod),
+		transport.WithLockout[*models.User](usecases.NewLockoutUseCases(usecases.LockoutConfig{
+			MaxUsernameFailures: 0,
+			MaxIPFailures:       2,
+			BaseDuration:        time.Minute,
+			MaxDuration:         time.Hour,
+		})),
+	)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		insecure.NewCredentials(),
+		nil,
+		transport.NewExtAuthzGRPCHandlers(authenticator, usecases.NewRoleUseCases(repo, repo, repo), trustedProxies),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+		cancel()
+		<-done
+	})
+
+	return authv3.NewAuthorizationClient(conn), userID.String(), created.Key
+}
@@ -0,0 +1,358 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.GroupServiceServer = (*GroupGRPCHandlers)(nil)
+
+type GroupGRPCHandlers struct {
+	proto.UnimplementedGroupServiceServer
+
+	groupUseCases *usecases.GroupUseCases
+	authorization *usecases.AuthorizationUseCases
+	authenticator *Authenticator[*models.User]
+}
+
+func NewGroupGRPCHandlers(
+	groupUseCases *usecases.GroupUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *GroupGRPCHandlers {
+	return &GroupGRPCHandlers{
+		UnimplementedGroupServiceServer: proto.UnimplementedGroupServiceServer{},
+
+		groupUseCases: groupUseCases,
+		authorization: authorization,
+		authenticator: authenticator,
+	}
+}
+
+func (h *GroupGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterGroupServiceServer(registrar, h)
+}
+
+func (h *GroupGRPCHandlers) CreateGroup(
+	ctx context.Context,
+	request *proto.CreateGroupRequest,
+) (*proto.CreateGroupResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, request.Roles...); err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewCreateGroupCommand(organization, request.Name, request.Roles)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidGroup):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create CreateGroup command: %w", err)
+	}
+
+	group, err := h.groupUseCases.CreateGroup(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidRole):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "Group already exists")
+	default:
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return &proto.CreateGroupResponse{
+		Group: newProtoGroup(group),
+	}, nil
+}
+
+func (h *GroupGRPCHandlers) GetGroup(
+	ctx context.Context,
+	request *proto.GetGroupRequest,
+) (*proto.GetGroupResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := usecases.NewGetGroupQuery(organization, request.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GetGroup query: %w", err)
+	}
+
+	group, err := h.groupUseCases.GetGroup(query)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "Group not found")
+	default:
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	return &proto.GetGroupResponse{
+		Group: newProtoGroup(group),
+	}, nil
+}
+
+func (h *GroupGRPCHandlers) ListGroups(
+	ctx context.Context,
+	_ *proto.ListGroupsRequest,
+) (*proto.ListGroupsResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := h.groupUseCases.ListGroups(organization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	response := &proto.ListGroupsResponse{
+		Groups: make([]*proto.Group, len(groups)),
+	}
+	for i, group := range groups {
+		response.Groups[i] = newProtoGroup(group)
+	}
+
+	return response, nil
+}
+
+func (h *GroupGRPCHandlers) DeleteGroup(ctx context.Context, request *proto.DeleteGroupRequest) (*emptypb.Empty, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewDeleteGroupCommand(organization, request.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DeleteGroup command: %w", err)
+	}
+
+	err = h.groupUseCases.DeleteGroup(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "Group not found")
+	default:
+		return nil, fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *GroupGRPCHandlers) AddGroupMember(
+	ctx context.Context,
+	request *proto.AddGroupMemberRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewGroupMemberCommand(organization, request.GroupId, request.UserId, request.MemberGroupId)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidGroup):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create AddGroupMember command: %w", err)
+	}
+
+	query, err := usecases.NewGetGroupQuery(organization, request.GroupId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GetGroup query: %w", err)
+	}
+
+	roles, err := h.groupUseCases.GrantedRoles(query)
+	if err != nil {
+		return nil, groupUpdateError(err, "failed to get roles granted by group")
+	}
+
+	// The new member gets the roles of the group, which the caller could otherwise grant to itself.
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, roles...); err != nil {
+		return nil, err
+	}
+
+	err = h.groupUseCases.AddGroupMember(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrGroupCycle):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return nil, groupUpdateError(err, "failed to add group member")
+	}
+
+	return empty, nil
+}
+
+func (h *GroupGRPCHandlers) RemoveGroupMember(
+	ctx context.Context,
+	request *proto.RemoveGroupMemberRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewGroupMemberCommand(organization, request.GroupId, request.UserId, request.MemberGroupId)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidGroup):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create RemoveGroupMember command: %w", err)
+	}
+
+	if err := h.groupUseCases.RemoveGroupMember(cmd); err != nil {
+		return nil, groupUpdateError(err, "failed to remove group member")
+	}
+
+	return empty, nil
+}
+
+func (h *GroupGRPCHandlers) AssignGroupRole(
+	ctx context.Context,
+	request *proto.AssignGroupRoleRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, request.Role); err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewGroupRoleCommand(organization, request.GroupId, request.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AssignGroupRole command: %w", err)
+	}
+
+	err = h.groupUseCases.AssignGroupRole(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidRole):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, groupUpdateError(err, "failed to assign group role")
+	}
+
+	return empty, nil
+}
+
+func (h *GroupGRPCHandlers) UnassignGroupRole(
+	ctx context.Context,
+	request *proto.UnassignGroupRoleRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, request.Role); err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewGroupRoleCommand(organization, request.GroupId, request.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UnassignGroupRole command: %w", err)
+	}
+
+	if err := h.groupUseCases.UnassignGroupRole(cmd); err != nil {
+		return nil, groupUpdateError(err, "failed to unassign group role")
+	}
+
+	return empty, nil
+}
+
+// groupUpdateError converts the errors common to the changes of a group into the status returned to the caller.
+func groupUpdateError(err error, message string) error {
+	switch {
+	case errors.Is(err, common.ErrNotFound):
+		return status.Error(codes.NotFound, "Group or member not found")
+	case errors.Is(err, common.ErrConflict):
+		return status.Error(codes.Aborted, "Group has been modified concurrently")
+	default:
+		return fmt.Errorf("%s: %w", message, err)
+	}
+}
+
+func newProtoGroup(group *models.Group) *proto.Group {
+	return &proto.Group{
+		Id:           group.ID.String(),
+		Name:         group.Name,
+		Roles:        group.Roles,
+		UserIds:      uuidStrings(group.UserIDs),
+		GroupIds:     uuidStrings(group.GroupIDs),
+		CreateTime:   timestamppb.New(group.CreatedAt),
+		Organization: group.Organization,
+	}
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	raw := make([]string, len(ids))
+	for i, id := range ids {
+		raw[i] = id.String()
+	}
+
+	return raw
+}
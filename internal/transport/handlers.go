@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
 	"github.com/ScareTrow/grpc_user_auth/internal/models"
@@ -15,46 +18,76 @@ import (
 	"github.com/ScareTrow/grpc_user_auth/proto"
 )
 
+// These methods let users with an unverified email see themselves, fix a mistyped email and change
+// their password, see WithVerifiedEmail.
+const (
+	UserServiceGetMeMethod          = "/users.UserService/GetMe"
+	UserServiceUpdateMeMethod       = "/users.UserService/UpdateMe"
+	UserServiceChangePasswordMethod = "/users.UserService/ChangePassword"
+)
+
 var _ proto.UserServiceServer = (*GRPCHandlers)(nil)
 
 type GRPCHandlers struct {
 	proto.UnimplementedUserServiceServer
 
 	userUseCases  *usecases.UserUseCases
+	authorization *usecases.AuthorizationUseCases
 	authenticator *Authenticator[*models.User]
 }
 
 func NewGRPCHandlers(
 	userUseCases *usecases.UserUseCases,
+	authorization *usecases.AuthorizationUseCases,
 	authenticator *Authenticator[*models.User],
 ) *GRPCHandlers {
 	return &GRPCHandlers{
 		UnimplementedUserServiceServer: proto.UnimplementedUserServiceServer{},
 
 		userUseCases:  userUseCases,
+		authorization: authorization,
 		authenticator: authenticator,
 	}
 }
 
+func (h *GRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterUserServiceServer(registrar, h)
+}
+
 var empty = new(emptypb.Empty) //nolint:gochecknoglobals
 
 func (h *GRPCHandlers) CreateUser(
 	ctx context.Context,
 	request *proto.CreateUserRequest,
 ) (*proto.CreateUserResponse, error) {
-	err := h.adminOnly(ctx)
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionUsersWrite, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	var roles []string
+	if request.Admin {
+		if _, err := authorizeSuperuser(ctx, h.authenticator, h.authorization); err != nil {
+			return nil, err
+		}
+
+		roles = []string{models.SuperuserRole}
+	}
+
 	cmd := usecases.NewCreateUserCommand(
+		organization,
 		request.Username,
 		request.Email,
 		request.Password,
-		request.Admin,
+		roles,
 	)
 
-	id, err := h.userUseCases.CreateUser(cmd)
+	id, err := h.userUseCases.CreateUser(ctx, cmd)
 	switch {
 	case err == nil:
 	case errors.Is(err, common.ErrAlreadyExists):
@@ -68,29 +101,54 @@ func (h *GRPCHandlers) CreateUser(
 	}, nil
 }
 
-func (h *GRPCHandlers) GetAllUsers(_ context.Context, _ *emptypb.Empty) (*proto.GetAllUsersResponse, error) {
-	users, err := h.userUseCases.GetAllUsers()
+func (h *GRPCHandlers) GetAllUsers(
+	ctx context.Context,
+	request *proto.GetAllUsersRequest,
+) (*proto.GetAllUsersResponse, error) {
+	organization, err := organizationOf(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all users: %w", err)
+		return nil, err
+	}
+
+	query, err := usecases.NewListUsersQuery(
+		organization,
+		int(request.PageSize),
+		request.PageToken,
+		request.OrderBy,
+		request.Filter,
+	)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidOrderBy), errors.Is(err, usecases.ErrInvalidFilter):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create ListUsers query: %w", err)
+	}
+
+	page, err := h.userUseCases.ListUsers(query)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidPageToken), errors.Is(err, usecases.ErrInvalidFilter):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
 	response := &proto.GetAllUsersResponse{
-		Users: make([]*proto.User, len(users)),
-	}
-	for i, user := range users {
-		response.Users[i] = &proto.User{
-			Id:       user.ID.String(),
-			Username: user.Username,
-			Email:    user.Email,
-			Admin:    user.Admin,
-		}
+		Users:         make([]*proto.User, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i, user := range page.Users {
+		response.Users[i] = newProtoUser(user)
 	}
 
 	return response, nil
 }
 
 func (h *GRPCHandlers) GetUserByID(ctx context.Context, request *proto.GetUserRequest) (*proto.GetUserResponse, error) {
-	err := h.adminOnly(ctx)
+	_, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionUsersRead, usecases.UserResource(request.Id),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -110,19 +168,16 @@ func (h *GRPCHandlers) GetUserByID(ctx context.Context, request *proto.GetUserRe
 	}
 
 	response := &proto.GetUserResponse{
-		User: &proto.User{
-			Id:       user.ID.String(),
-			Username: user.Username,
-			Email:    user.Email,
-			Admin:    user.Admin,
-		},
+		User: newProtoUser(user),
 	}
 
 	return response, nil
 }
 
 func (h *GRPCHandlers) UpdateUser(ctx context.Context, request *proto.UpdateUserRequest) (*emptypb.Empty, error) {
-	err := h.adminOnly(ctx)
+	_, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionUsersWrite, usecases.UserResource(request.Id),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -132,17 +187,25 @@ func (h *GRPCHandlers) UpdateUser(ctx context.Context, request *proto.UpdateUser
 		request.Username,
 		request.Email,
 		request.Password,
-		request.Admin,
+		request.Etag,
 	)
-	if err != nil {
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidETag):
+		return nil, status.Error(codes.InvalidArgument, "Etag is invalid")
+	default:
 		return empty, fmt.Errorf("failed to create UpdateUser command: %w", err)
 	}
 
-	err = h.userUseCases.UpdateUser(cmd)
+	err = h.userUseCases.UpdateUser(ctx, cmd)
 	switch {
 	case err == nil:
 	case errors.Is(err, common.ErrNotFound):
 		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "User with this username or email already exists")
 	default:
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -151,13 +214,19 @@ func (h *GRPCHandlers) UpdateUser(ctx context.Context, request *proto.UpdateUser
 }
 
 func (h *GRPCHandlers) DeleteUser(ctx context.Context, request *proto.DeleteUserRequest) (*emptypb.Empty, error) {
-	err := h.adminOnly(ctx)
+	_, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionUsersDelete, usecases.UserResource(request.Id),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	cmd, err := usecases.NewDeleteUserCommand(request.Id)
-	if err != nil {
+	cmd, err := usecases.NewDeleteUserCommand(request.Id, request.Etag)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidETag):
+		return nil, status.Error(codes.InvalidArgument, "Etag is invalid")
+	default:
 		return empty, fmt.Errorf("failed to create DeleteUser command: %w", err)
 	}
 
@@ -166,6 +235,8 @@ func (h *GRPCHandlers) DeleteUser(ctx context.Context, request *proto.DeleteUser
 	case err == nil:
 	case errors.Is(err, common.ErrNotFound):
 		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
 	default:
 		return nil, fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -173,15 +244,96 @@ func (h *GRPCHandlers) DeleteUser(ctx context.Context, request *proto.DeleteUser
 	return empty, nil
 }
 
-func (h *GRPCHandlers) adminOnly(ctx context.Context) error {
+func (h *GRPCHandlers) GetMe(ctx context.Context, _ *proto.GetMeRequest) (*proto.GetMeResponse, error) {
 	user, err := h.authenticator.GetAuthenticatedUser(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get authenticated user: %w", err)
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
 	}
 
-	if !user.Admin {
-		return status.Error(codes.PermissionDenied, "Only admins can perform this action")
+	return &proto.GetMeResponse{
+		User: newProtoUser(user),
+	}, nil
+}
+
+func (h *GRPCHandlers) UpdateMe(ctx context.Context, request *proto.UpdateMeRequest) (*emptypb.Empty, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	cmd, err := usecases.NewUpdateProfileCommand(user.ID, request.Username, request.Email, request.Etag)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidETag):
+		return nil, status.Error(codes.InvalidArgument, "Etag is invalid")
+	default:
+		return nil, fmt.Errorf("failed to create UpdateProfile command: %w", err)
+	}
+
+	err = h.userUseCases.UpdateProfile(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "User with this username or email already exists")
+	default:
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *GRPCHandlers) ChangePassword(
+	ctx context.Context,
+	request *proto.ChangePasswordRequest,
+) (*emptypb.Empty, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
 	}
 
-	return nil
+	cmd := usecases.NewChangePasswordCommand(user.ID, request.CurrentPassword, request.NewPassword)
+
+	// The current password is guessed like any other, so it is throttled by the same lockouts.
+	_, err = throttlePasswordAttempt(ctx, h.authenticator.lockouts, user.Organization, user.Username, func() (any, error) {
+		return nil, h.userUseCases.ChangePassword(ctx, cmd)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrLockedOut):
+		setRetryAfter(ctx, err)
+
+		return nil, status.Error(codes.ResourceExhausted, "Too many failed attempts, retry later")
+	case errors.Is(err, usecases.ErrPasswordPoolSaturated):
+		return nil, status.Error(codes.Unavailable, "Too many password verifications, retry later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case errors.Is(err, common.ErrInvalidCredentials):
+		return nil, status.Error(codes.PermissionDenied, "Current password is wrong")
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return empty, nil
+}
+
+func newProtoUser(user *models.User) *proto.User {
+	return &proto.User{
+		Id:            user.ID.String(),
+		Email:         user.Email,
+		Username:      user.Username,
+		Admin:         slices.Contains(user.Roles, models.SuperuserRole),
+		Etag:          usecases.FormatETag(user.Version),
+		CreateTime:    timestamppb.New(user.CreatedAt),
+		Roles:         user.Roles,
+		Organization:  user.Organization,
+		EmailVerified: user.EmailVerification == nil,
+	}
 }
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+// RetryAfterTrailerKey is the trailer that tells locked out callers how many seconds to wait before retrying.
+const RetryAfterTrailerKey = "retry-after"
+
+// WithLockout rejects Basic credentials of locked out usernames and addresses without checking them,
+// and records the result of the others.
+func WithLockout[UserModel any](lockouts *usecases.LockoutUseCases) AuthenticatorOption[UserModel] {
+	return func(a *Authenticator[UserModel]) {
+		a.lockouts = lockouts
+	}
+}
+
+// lockedOutError is usecases.ErrLockedOut with the time until the attempt may be retried.
+type lockedOutError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *lockedOutError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.err, e.retryAfter)
+}
+
+func (e *lockedOutError) Unwrap() error {
+	return e.err
+}
+
+// throttlePasswordAttempt checks the password of the username in the organization with check,
+// unless the username or the address of the caller is locked out, and records the result.
+// Attempts that fail for other reasons, such as a saturated password pool, do not count.
+// Without lockouts the password is always checked.
+func throttlePasswordAttempt[Result any](
+	ctx context.Context,
+	lockouts *usecases.LockoutUseCases,
+	organization string,
+	username string,
+	check func() (Result, error),
+) (Result, error) {
+	if lockouts == nil {
+		return check()
+	}
+
+	attempt := usecases.NewPasswordAttempt(organization, username, callerAddress(ctx))
+
+	if retryAfter, err := lockouts.CheckAttempt(attempt); err != nil {
+		var zero Result
+
+		return zero, &lockedOutError{err: err, retryAfter: retryAfter}
+	}
+
+	result, err := check()
+	switch {
+	case err == nil:
+		lockouts.RecordSuccess(attempt)
+	case errors.Is(err, common.ErrInvalidCredentials), errors.Is(err, common.ErrNotFound):
+		lockouts.RecordFailure(attempt)
+	default:
+		lockouts.ReleaseAttempt(attempt)
+	}
+
+	return result, err
+}
+
+// retryAfterSeconds returns the whole seconds a locked out caller must wait, if the error is a lockout.
+func retryAfterSeconds(err error) (string, bool) {
+	var lockedOut *lockedOutError
+	if !errors.As(err, &lockedOut) {
+		return "", false
+	}
+
+	return formatRetryAfter(lockedOut.retryAfter), true
+}
+
+// formatRetryAfter returns the duration in whole seconds, rounded up.
+func formatRetryAfter(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
+
+// setRetryAfter sends the retry-after trailer if the error is a lockout.
+func setRetryAfter(ctx context.Context, err error) {
+	if seconds, ok := retryAfterSeconds(err); ok {
+		setRetryAfterTrailer(ctx, seconds)
+	}
+}
+
+func setRetryAfterTrailer(ctx context.Context, seconds string) {
+	// It only fails outside of a gRPC call, where there is nobody to tell.
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(RetryAfterTrailerKey, seconds))
+}
+
+type callerAddressContextKey struct{}
+
+// withCallerAddress overrides the address of the peer, such as for requests proxied by Envoy.
+func withCallerAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, callerAddressContextKey{}, address)
+}
+
+// callerAddress returns the IP address of the caller without the port, or an empty string if unknown.
+func callerAddress(ctx context.Context) string {
+	if address, ok := ctx.Value(callerAddressContextKey{}).(string); ok {
+		return address
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
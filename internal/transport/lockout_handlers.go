@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.LockoutServiceServer = (*LockoutGRPCHandlers)(nil)
+
+type LockoutGRPCHandlers struct {
+	proto.UnimplementedLockoutServiceServer
+
+	lockoutUseCases *usecases.LockoutUseCases
+	authorization   *usecases.AuthorizationUseCases
+	authenticator   *Authenticator[*models.User]
+}
+
+func NewLockoutGRPCHandlers(
+	lockoutUseCases *usecases.LockoutUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *LockoutGRPCHandlers {
+	return &LockoutGRPCHandlers{
+		UnimplementedLockoutServiceServer: proto.UnimplementedLockoutServiceServer{},
+
+		lockoutUseCases: lockoutUseCases,
+		authorization:   authorization,
+		authenticator:   authenticator,
+	}
+}
+
+func (h *LockoutGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterLockoutServiceServer(registrar, h)
+}
+
+func (h *LockoutGRPCHandlers) ListLockouts(
+	ctx context.Context,
+	_ *proto.ListLockoutsRequest,
+) (*proto.ListLockoutsResponse, error) {
+	_, err := authorizeSuperuser(ctx, h.authenticator, h.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := h.lockoutUseCases.ListLockouts()
+
+	response := &proto.ListLockoutsResponse{
+		Lockouts: make([]*proto.Lockout, len(lockouts)),
+	}
+	for i, lockout := range lockouts {
+		response.Lockouts[i] = newProtoLockout(lockout)
+	}
+
+	return response, nil
+}
+
+func (h *LockoutGRPCHandlers) ClearLockout(
+	ctx context.Context,
+	request *proto.ClearLockoutRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorizeSuperuser(ctx, h.authenticator, h.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewClearLockoutCommand(request.Kind, request.Organization, request.Subject)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidLockout):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create ClearLockout command: %w", err)
+	}
+
+	err = h.lockoutUseCases.ClearLockout(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "Lockout not found")
+	default:
+		return nil, fmt.Errorf("failed to clear lockout: %w", err)
+	}
+
+	return empty, nil
+}
+
+func newProtoLockout(lockout *models.Lockout) *proto.Lockout {
+	return &proto.Lockout{
+		Kind:            string(lockout.Kind),
+		Organization:    lockout.Organization,
+		Subject:         lockout.Subject,
+		Failures:        int32(lockout.Failures),
+		LastFailureTime: timestamppb.New(lockout.LastFailureAt),
+		LockedUntil:     timestamppb.New(lockout.LockedUntil),
+	}
+}
@@ -0,0 +1,203 @@
+package transport_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+func TestLockout(t *testing.T) {
+	t.Parallel()
+
+	conn := startLockoutServer(t)
+	users := proto.NewUserServiceClient(conn)
+	auth := proto.NewAuthServiceClient(conn)
+	lockouts := proto.NewLockoutServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	root := withBasicAuth(ctx, "root", "password")
+
+	for i := 0; i < 2; i++ {
+		_, err := users.GetMe(withBasicAuth(ctx, "alice", "wrong"), &proto.GetMeRequest{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	_, err := auth.Login(ctx, &proto.LoginRequest{Username: "alice", Password: "wrong"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	var trailer metadata.MD
+	_, err = users.GetMe(withBasicAuth(ctx, "alice", "password"), &proto.GetMeRequest{}, grpc.Trailer(&trailer))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the right password is not checked while locked out")
+	require.Len(t, trailer.Get(transport.RetryAfterTrailerKey), 1)
+
+	seconds, err := strconv.Atoi(trailer.Get(transport.RetryAfterTrailerKey)[0])
+	require.NoError(t, err)
+	assert.Equal(t, 60, seconds)
+
+	_, err = auth.Login(ctx, &proto.LoginRequest{Username: "alice", Password: "password"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "Login shares the lockout")
+
+	_, err = lockouts.ListLockouts(withBasicAuth(ctx, "alice", "password"), &proto.ListLockoutsRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	list, err := lockouts.ListLockouts(root, &proto.ListLockoutsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Lockouts, 1)
+	assert.Equal(t, "username", list.Lockouts[0].Kind)
+	assert.Equal(t, models.DefaultOrganization, list.Lockouts[0].Organization)
+	assert.Equal(t, "alice", list.Lockouts[0].Subject)
+	assert.EqualValues(t, 3, list.Lockouts[0].Failures)
+
+	_, err = lockouts.ClearLockout(root, &proto.ClearLockoutRequest{Kind: "user", Subject: "alice"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = lockouts.ClearLockout(root, &proto.ClearLockoutRequest{
+		Kind:         "username",
+		Organization: models.DefaultOrganization,
+		Subject:      "alice",
+	})
+	require.NoError(t, err)
+
+	_, err = users.GetMe(withBasicAuth(ctx, "alice", "password"), &proto.GetMeRequest{})
+	assert.NoError(t, err)
+}
+
+func TestLockout_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	conn := startLockoutServer(t)
+	users := proto.NewUserServiceClient(conn)
+	auth := proto.NewAuthServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokens, err := auth.Login(ctx, &proto.LoginRequest{Username: "alice", Password: "password"})
+	require.NoError(t, err)
+
+	alice := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tokens.AccessToken)
+
+	for i := 0; i < 3; i++ {
+		_, err := users.ChangePassword(alice, &proto.ChangePasswordRequest{
+			CurrentPassword: "wrong",
+			NewPassword:     "new password",
+		})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	}
+
+	_, err = users.ChangePassword(alice, &proto.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "new password",
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the current password is not checked while locked out")
+
+	_, err = auth.Login(ctx, &proto.LoginRequest{Username: "alice", Password: "password"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "ChangePassword shares the lockout")
+}
+
+func startLockoutServer(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+	for _, username := range []string{"alice", "root"} {
+		_, err = userUseCases.CreateUser(context.Background(), usecases.NewCreateUserCommand(
+			models.DefaultOrganization, username, username+"@corp.com", "password", []string{models.SuperuserRole},
+		))
+		require.NoError(t, err)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	issuer, err := infrastructure.NewJWTIssuer(infrastructure.JWTConfig{
+		Issuer:     "issuer",
+		Audience:   "audience",
+		TTL:        time.Minute,
+		PrivateKey: privateKey,
+	})
+	require.NoError(t, err)
+
+	authUseCases := usecases.NewAuthUseCases(userUseCases, issuer, repo, time.Hour)
+	organizationUseCases := usecases.NewOrganizationUseCases(repo)
+	roleUseCases := usecases.NewRoleUseCases(repo, repo, repo)
+	authorization := usecases.NewAuthorizationUseCases(roleUseCases, repo)
+	lockoutUseCases := usecases.NewLockoutUseCases(usecases.LockoutConfig{
+		MaxUsernameFailures: 3,
+		MaxIPFailures:       0,
+		BaseDuration:        time.Minute,
+		MaxDuration:         time.Hour,
+	})
+
+	authenticator := transport.NewAuthenticator(
+		userUseCases.AuthenticateUser,
+		transport.WithBearerAuth(authUseCases.AuthenticateAccessToken),
+		transport.WithPublicMethods[*models.User](transport.AuthServiceLoginMethod),
+		transport.WithLockout[*models.User](lockoutUseCases),
+	)
+	resolver := transport.NewOrganizationResolver(authenticator, organizationUseCases, authorization)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		insecure.NewCredentials(),
+		[]grpc.UnaryServerInterceptor{resolver.UnaryInterceptor},
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+		transport.NewAuthGRPCHandlers(authUseCases, lockoutUseCases),
+		transport.NewLockoutGRPCHandlers(lockoutUseCases, authorization, authenticator),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+		cancel()
+		<-done
+	})
+
+	return conn
+}
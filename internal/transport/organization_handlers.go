@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.OrganizationServiceServer = (*OrganizationGRPCHandlers)(nil)
+
+type OrganizationGRPCHandlers struct {
+	proto.UnimplementedOrganizationServiceServer
+
+	organizationUseCases *usecases.OrganizationUseCases
+	authorization        *usecases.AuthorizationUseCases
+	authenticator        *Authenticator[*models.User]
+}
+
+func NewOrganizationGRPCHandlers(
+	organizationUseCases *usecases.OrganizationUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *OrganizationGRPCHandlers {
+	return &OrganizationGRPCHandlers{
+		UnimplementedOrganizationServiceServer: proto.UnimplementedOrganizationServiceServer{},
+
+		organizationUseCases: organizationUseCases,
+		authorization:        authorization,
+		authenticator:        authenticator,
+	}
+}
+
+func (h *OrganizationGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterOrganizationServiceServer(registrar, h)
+}
+
+func (h *OrganizationGRPCHandlers) CreateOrganization(
+	ctx context.Context,
+	request *proto.CreateOrganizationRequest,
+) (*proto.CreateOrganizationResponse, error) {
+	_, err := authorizeSuperuser(ctx, h.authenticator, h.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewCreateOrganizationCommand(request.Name)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidOrganization):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create CreateOrganization command: %w", err)
+	}
+
+	organization, err := h.organizationUseCases.CreateOrganization(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "Organization already exists")
+	default:
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return &proto.CreateOrganizationResponse{
+		Organization: newProtoOrganization(organization),
+	}, nil
+}
+
+func (h *OrganizationGRPCHandlers) ListOrganizations(
+	ctx context.Context,
+	_ *proto.ListOrganizationsRequest,
+) (*proto.ListOrganizationsResponse, error) {
+	_, err := authorizeSuperuser(ctx, h.authenticator, h.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := h.organizationUseCases.ListOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	response := &proto.ListOrganizationsResponse{
+		Organizations: make([]*proto.Organization, len(organizations)),
+	}
+	for i, organization := range organizations {
+		response.Organizations[i] = newProtoOrganization(organization)
+	}
+
+	return response, nil
+}
+
+func newProtoOrganization(organization *models.Organization) *proto.Organization {
+	return &proto.Organization{
+		Name:       organization.Name,
+		CreateTime: timestamppb.New(organization.CreatedAt),
+	}
+}
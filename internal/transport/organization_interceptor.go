@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+// OrganizationHeaderKey names the organization a request acts in. Usernames are looked up in it,
+// and superusers may name any organization to act in it instead of their own.
+const OrganizationHeaderKey = "x-organization"
+
+type organizationContextKey struct{}
+
+// requestedOrganization returns the organization named by the metadata of the request, or an empty string.
+func requestedOrganization(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(OrganizationHeaderKey); len(values) == 1 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// usernameOrganization returns the organization users are looked up in by username,
+// which is the requested organization or the default one.
+func usernameOrganization(ctx context.Context) string {
+	if organization := requestedOrganization(ctx); organization != "" {
+		return organization
+	}
+
+	return models.DefaultOrganization
+}
+
+// organizationOf returns the organization the call acts in, as resolved by OrganizationResolver.
+func organizationOf(ctx context.Context) (string, error) {
+	organization, ok := ctx.Value(organizationContextKey{}).(string)
+	if !ok {
+		return "", status.Error(codes.Internal, "Failed to get organization of the request")
+	}
+
+	return organization, nil
+}
+
+// OrganizationResolver scopes every call to an organization. Authenticated calls act in the organization
+// of the caller, and superusers may act in another existing organization by naming it in the metadata.
+// Public methods act in the requested organization or the default one.
+type OrganizationResolver struct {
+	authenticator *Authenticator[*models.User]
+	organizations *usecases.OrganizationUseCases
+	authorization *usecases.AuthorizationUseCases
+}
+
+func NewOrganizationResolver(
+	authenticator *Authenticator[*models.User],
+	organizations *usecases.OrganizationUseCases,
+	authorization *usecases.AuthorizationUseCases,
+) *OrganizationResolver {
+	return &OrganizationResolver{
+		authenticator: authenticator,
+		organizations: organizations,
+		authorization: authorization,
+	}
+}
+
+func (r *OrganizationResolver) UnaryInterceptor(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	organization := usernameOrganization(ctx)
+
+	if user, err := r.authenticator.GetAuthenticatedUser(ctx); err == nil {
+		organization, err = r.resolve(user, requestedOrganization(ctx))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(context.WithValue(ctx, organizationContextKey{}, organization), req)
+}
+
+func (r *OrganizationResolver) resolve(user *models.User, requested string) (string, error) {
+	if requested == "" || requested == user.Organization {
+		return user.Organization, nil
+	}
+
+	decision, err := r.authorization.AuthorizeSuperuser(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to authorize acting in organization %q: %w", requested, err)
+	}
+
+	if !decision.Allowed {
+		return "", status.Error(codes.PermissionDenied, "Credentials are not allowed to act in this organization")
+	}
+
+	_, err = r.organizations.GetOrganization(requested)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return "", status.Error(codes.NotFound, "Organization not found")
+	default:
+		return "", fmt.Errorf("failed to get organization %q: %w", requested, err)
+	}
+
+	return requested, nil
+}
@@ -0,0 +1,215 @@
+package transport_test
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+func TestPasswordPool(t *testing.T) {
+	t.Parallel()
+
+	hasher := &blockingPasswordHasher{
+		PasswordHasher: newTestPasswordHasher(t),
+		started:        make(chan struct{}, 3),
+		release:        make(chan struct{}),
+	}
+	client := startPasswordPoolServer(t, usecases.NewPasswordPool(hasher, 1, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	getMe := func() error {
+		_, err := client.GetMe(withBasicAuth(ctx, "alice", "password"), &proto.GetMeRequest{})
+
+		return err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		assert.NoError(t, getMe(), "the running verification completes")
+	}()
+
+	<-hasher.started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// A probe below may hold the place in the queue for a moment.
+		for {
+			err := getMe()
+			if status.Code(err) != codes.Unavailable {
+				assert.NoError(t, err, "the queued verification completes")
+
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		return status.Code(getMe()) == codes.Unavailable
+	}, 2*time.Second, 10*time.Millisecond, "verifications beyond the queue are rejected")
+
+	close(hasher.release)
+	wg.Wait()
+
+	assert.Len(t, hasher.started, 1, "the queued verification has run")
+	assert.NoError(t, getMe())
+}
+
+// blockingPasswordHasher verifies passwords once they are released.
+type blockingPasswordHasher struct {
+	usecases.PasswordHasher
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingPasswordHasher) Verify(ctx context.Context, hash []byte, password string) (bool, error) {
+	h.started <- struct{}{}
+	<-h.release
+
+	return h.PasswordHasher.Verify(ctx, hash, password)
+}
+
+// BenchmarkPasswordPool authenticates many more concurrent requests than there are CPUs,
+// with every verification on its own goroutine and on a pool with a worker per CPU.
+func BenchmarkPasswordPool(b *testing.B) {
+	hasher := newTestPasswordHasher(b)
+
+	benchmarks := []struct {
+		name   string
+		hasher usecases.PasswordHasher
+	}{
+		{name: "unbounded", hasher: hasher},
+		{name: "pool", hasher: usecases.NewPasswordPool(hasher, runtime.NumCPU(), 64)},
+	}
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			client := startPasswordPoolServer(b, bm.hasher)
+			ctx := withBasicAuth(context.Background(), "alice", "password")
+
+			var mu sync.Mutex
+			var latencies []time.Duration
+			var rejected atomic.Int64
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					start := time.Now()
+					_, err := client.GetMe(ctx, &proto.GetMeRequest{})
+					elapsed := time.Since(start)
+
+					switch status.Code(err) { //nolint:exhaustive
+					case codes.OK:
+						mu.Lock()
+						latencies = append(latencies, elapsed)
+						mu.Unlock()
+					case codes.Unavailable:
+						rejected.Add(1)
+					default:
+						b.Error(err)
+					}
+				}
+			})
+
+			b.StopTimer()
+
+			slices.Sort(latencies)
+			if len(latencies) > 0 {
+				b.ReportMetric(float64(latencies[len(latencies)/2].Milliseconds()), "p50-ms")
+				b.ReportMetric(float64(latencies[len(latencies)*99/100].Milliseconds()), "p99-ms")
+			}
+
+			b.ReportMetric(float64(rejected.Load())/float64(b.N), "rejected/op")
+		})
+	}
+}
+
+func startPasswordPoolServer(tb testing.TB, hasher usecases.PasswordHasher) proto.UserServiceClient { //nolint:ireturn
+	tb.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(tb, err)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		hasher,
+		nil,
+		nil,
+	)
+	_, err = userUseCases.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, "alice", "alice@corp.com", "password", nil),
+	)
+	require.NoError(tb, err)
+
+	authenticator := transport.NewAuthenticator(userUseCases.AuthenticateUser)
+	authorization := usecases.NewAuthorizationUseCases(usecases.NewRoleUseCases(repo, repo, repo), repo)
+	resolver := transport.NewOrganizationResolver(authenticator, usecases.NewOrganizationUseCases(repo), authorization)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		insecure.NewCredentials(),
+		[]grpc.UnaryServerInterceptor{resolver.UnaryInterceptor},
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(tb, server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		require.NoError(tb, conn.Close())
+		cancel()
+		<-done
+	})
+
+	return proto.NewUserServiceClient(conn)
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+// The methods of PasswordResetService must be public, since they are called by users who cannot authenticate.
+const (
+	PasswordResetServiceRequestPasswordResetMethod = "/users.PasswordResetService/RequestPasswordReset"
+	PasswordResetServiceResetPasswordMethod        = "/users.PasswordResetService/ResetPassword"
+)
+
+var _ proto.PasswordResetServiceServer = (*PasswordResetGRPCHandlers)(nil)
+
+type PasswordResetGRPCHandlers struct {
+	proto.UnimplementedPasswordResetServiceServer
+
+	passwordResetUseCases *usecases.PasswordResetUseCases
+}
+
+func NewPasswordResetGRPCHandlers(passwordResetUseCases *usecases.PasswordResetUseCases) *PasswordResetGRPCHandlers {
+	return &PasswordResetGRPCHandlers{
+		UnimplementedPasswordResetServiceServer: proto.UnimplementedPasswordResetServiceServer{},
+
+		passwordResetUseCases: passwordResetUseCases,
+	}
+}
+
+func (h *PasswordResetGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterPasswordResetServiceServer(registrar, h)
+}
+
+func (h *PasswordResetGRPCHandlers) RequestPasswordReset(
+	ctx context.Context,
+	request *proto.RequestPasswordResetRequest,
+) (*emptypb.Empty, error) {
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := usecases.NewRequestPasswordResetCommand(organization, request.Username)
+
+	retryAfter, err := h.passwordResetUseCases.RequestPasswordReset(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrPasswordResetCooldown):
+		setRetryAfterTrailer(ctx, formatRetryAfter(retryAfter))
+
+		return nil, status.Error(codes.ResourceExhausted, "Password reset requested recently, retry later")
+	case errors.Is(err, usecases.ErrPasswordResetQueueFull):
+		return nil, status.Error(codes.Unavailable, "Too many password reset requests, retry later")
+	default:
+		return nil, fmt.Errorf("failed to request password reset: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *PasswordResetGRPCHandlers) ResetPassword(
+	ctx context.Context,
+	request *proto.ResetPasswordRequest,
+) (*emptypb.Empty, error) {
+	cmd := usecases.NewResetPasswordCommand(request.Token, request.NewPassword)
+
+	err := h.passwordResetUseCases.ResetPassword(ctx, cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrInvalidToken):
+		return nil, status.Error(codes.InvalidArgument, "Invalid or expired password reset token")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return empty, nil
+}
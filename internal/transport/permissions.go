@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+// authorize returns the authenticated user if the user may perform the action on the resource,
+// which is empty or usecases.UserResource, or if a policy rule allowed the call.
+// Policy rules only grant the action: the resource must belong to the organization of the user either way.
+func authorize(
+	ctx context.Context,
+	authenticator *Authenticator[*models.User],
+	authorization *usecases.AuthorizationUseCases,
+	action models.Permission,
+	resource string,
+) (*models.User, error) {
+	user, err := authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	tenancy, err := authorization.AuthorizeOrganization(user, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize organization of %q: %w", resource, err)
+	}
+
+	if !tenancy.Allowed {
+		return nil, status.Error(codes.PermissionDenied, tenancy.Reason)
+	}
+
+	if allowedByPolicy(ctx) {
+		return user, nil
+	}
+
+	decision, err := authorization.Authorize(user, action, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize %q on %q: %w", action, resource, err)
+	}
+
+	if !decision.Allowed {
+		return nil, status.Error(codes.PermissionDenied, decision.Reason)
+	}
+
+	return user, nil
+}
+
+// authorizeSuperuser returns the authenticated user if the user has the superuser role.
+// Unlike with authorize, policy rules cannot allow the call, since the role crosses organizations.
+func authorizeSuperuser(
+	ctx context.Context,
+	authenticator *Authenticator[*models.User],
+	authorization *usecases.AuthorizationUseCases,
+) (*models.User, error) {
+	user, err := authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	decision, err := authorization.AuthorizeSuperuser(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize superuser: %w", err)
+	}
+
+	if !decision.Allowed {
+		return nil, status.Error(codes.PermissionDenied, decision.Reason)
+	}
+
+	return user, nil
+}
+
+// authorizeRoleChange lets the authenticated user grant or revoke the roles only if the user has
+// every permission they grant, and the superuser role to grant or revoke that role.
+// Unlike with authorize, policy rules cannot allow the change, since it would let users escalate themselves.
+func authorizeRoleChange(
+	ctx context.Context,
+	authenticator *Authenticator[*models.User],
+	authorization *usecases.AuthorizationUseCases,
+	roles ...string,
+) error {
+	user, err := authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	decision, err := authorization.AuthorizeRoleGrant(user, roles...)
+	if err != nil {
+		return fmt.Errorf("failed to authorize granting roles %q: %w", roles, err)
+	}
+
+	if !decision.Allowed {
+		return status.Error(codes.PermissionDenied, decision.Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/policy"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+type policyAllowedContextKey struct{}
+
+// allowedByPolicy reports whether a policy rule allowed the call, which skips the permission checks of the handler.
+func allowedByPolicy(ctx context.Context) bool {
+	_, ok := ctx.Value(policyAllowedContextKey{}).(string)
+
+	return ok
+}
+
+// PolicyEnforcer evaluates the policy for every authenticated call. Denied calls fail with PERMISSION_DENIED,
+// allowed calls skip the permission checks of the handler, and other calls are checked by the handler as usual.
+// In audit mode the calls the policy would deny are only logged.
+type PolicyEnforcer struct {
+	source        *policy.Source
+	authenticator *Authenticator[*models.User]
+	userUseCases  *usecases.UserUseCases
+	roleUseCases  *usecases.RoleUseCases
+}
+
+func NewPolicyEnforcer(
+	source *policy.Source,
+	authenticator *Authenticator[*models.User],
+	userUseCases *usecases.UserUseCases,
+	roleUseCases *usecases.RoleUseCases,
+) *PolicyEnforcer {
+	return &PolicyEnforcer{
+		source:        source,
+		authenticator: authenticator,
+		userUseCases:  userUseCases,
+		roleUseCases:  roleUseCases,
+	}
+}
+
+func (e *PolicyEnforcer) UnaryInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	user, err := e.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		// Public methods have no caller to evaluate the policy for.
+		return handler(ctx, req)
+	}
+
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return nil, fmt.Errorf("request of %q is not a proto message", info.FullMethod)
+	}
+
+	current := e.source.Policy()
+	env := &policyEnv{
+		enforcer:     e,
+		method:       info.FullMethod,
+		caller:       user,
+		request:      message.ProtoReflect(),
+		target:       nil,
+		targetLoaded: false,
+	}
+
+	logger := common.ExtractLogger(ctx)
+
+	decision, err := current.Evaluate(info.FullMethod, env)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to evaluate policy", slog.String("error", err.Error()))
+	}
+
+	if current.Mode == policy.ModeAudit {
+		if decision.Effect == policy.EffectDeny {
+			logger.WarnContext(
+				ctx,
+				"policy would deny the call",
+				slog.String("method", info.FullMethod),
+				slog.String("user_id", user.ID.String()),
+				slog.String("rule", decision.Rule),
+			)
+		}
+
+		return handler(ctx, req)
+	}
+
+	switch decision.Effect {
+	case policy.EffectDeny:
+		logger.InfoContext(
+			ctx,
+			"policy denied the call",
+			slog.String("method", info.FullMethod),
+			slog.String("user_id", user.ID.String()),
+			slog.String("rule", decision.Rule),
+		)
+
+		return nil, status.Errorf(codes.PermissionDenied, "Denied by policy rule %q", decision.Rule)
+	case policy.EffectAllow:
+		ctx = context.WithValue(ctx, policyAllowedContextKey{}, decision.Rule)
+	case policy.EffectNone:
+	}
+
+	return handler(ctx, req)
+}
+
+// policyEnv exposes the method, the caller, the fields of the request and the target user to policy conditions.
+// The target is the user named by the user_id field of the request, or by its id field if it has no user_id,
+// and is only loaded if a condition refers to it.
+type policyEnv struct {
+	enforcer *PolicyEnforcer
+	method   string
+	caller   *models.User
+	request  protoreflect.Message
+
+	target       *models.User
+	targetLoaded bool
+}
+
+func (e *policyEnv) Lookup(field string) (any, bool) {
+	if field == "method" {
+		return e.method, true
+	}
+
+	scope, name, ok := strings.Cut(field, ".")
+	if !ok {
+		return nil, false
+	}
+
+	switch scope {
+	case "caller":
+		return e.lookupUser(e.caller, name)
+	case "request":
+		return lookupMessageField(e.request, name)
+	case "target":
+		if err := e.loadTarget(); err != nil {
+			return nil, false
+		}
+
+		if name == "exists" {
+			return e.target != nil, true
+		}
+
+		return e.lookupUser(e.target, name)
+	default:
+		return nil, false
+	}
+}
+
+func (e *policyEnv) lookupUser(user *models.User, name string) (any, bool) {
+	if user == nil {
+		return lookupMissingUser(name)
+	}
+
+	switch name {
+	case "id":
+		return user.ID.String(), true
+	case "username":
+		return user.Username, true
+	case "email":
+		return user.Email, true
+	case "roles":
+		roles, err := e.enforcer.roleUseCases.EffectiveRoles(user)
+		if err != nil {
+			return nil, false
+		}
+
+		return roles, true
+	case "permissions":
+		permissions, err := e.enforcer.roleUseCases.EffectivePermissions(user)
+		if err != nil {
+			return nil, false
+		}
+
+		raw := make([]string, len(permissions))
+		for i, permission := range permissions {
+			raw[i] = string(permission)
+		}
+
+		return raw, true
+	default:
+		return nil, false
+	}
+}
+
+// lookupMissingUser returns zero values for a missing target, so that conditions can still be evaluated.
+func lookupMissingUser(name string) (any, bool) {
+	switch name {
+	case "id", "username", "email":
+		return "", true
+	case "roles", "permissions":
+		return []string{}, true
+	default:
+		return nil, false
+	}
+}
+
+func (e *policyEnv) loadTarget() error {
+	if e.targetLoaded {
+		return nil
+	}
+
+	fields := e.request.Descriptor().Fields()
+
+	field := fields.ByName("user_id")
+	if field == nil {
+		field = fields.ByName("id")
+	}
+
+	if field == nil || field.Kind() != protoreflect.StringKind || field.IsList() {
+		e.targetLoaded = true
+
+		return nil
+	}
+
+	query, err := usecases.NewGetUserByIDQuery(e.request.Get(field).String())
+	if err != nil {
+		e.targetLoaded = true
+
+		return nil //nolint:nilerr
+	}
+
+	target, err := e.enforcer.userUseCases.GetUserByID(query)
+	switch {
+	case err == nil:
+		e.target = target
+	case errors.Is(err, common.ErrNotFound):
+	default:
+		return fmt.Errorf("failed to get target: %w", err)
+	}
+
+	e.targetLoaded = true
+
+	return nil
+}
+
+// lookupMessageField returns scalar fields, repeated strings and timestamps in the form package filter compares.
+func lookupMessageField(message protoreflect.Message, name string) (any, bool) {
+	field := message.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil || field.IsMap() {
+		return nil, false
+	}
+
+	value := message.Get(field)
+
+	if field.IsList() {
+		if field.Kind() != protoreflect.StringKind {
+			return nil, false
+		}
+
+		list := value.List()
+		values := make([]string, list.Len())
+		for i := range values {
+			values[i] = list.Get(i).String()
+		}
+
+		return values, true
+	}
+
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return value.Bool(), true
+	case protoreflect.StringKind:
+		return value.String(), true
+	case protoreflect.EnumKind:
+		if enum := field.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name()), true
+		}
+
+		return int64(value.Enum()), true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value.Int(), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint(), true
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float(), true
+	case protoreflect.MessageKind:
+		if timestamp, ok := value.Message().Interface().(*timestamppb.Timestamp); ok {
+			return timestamp.AsTime(), true
+		}
+
+		return nil, false
+	case protoreflect.BytesKind, protoreflect.GroupKind:
+		return nil, false
+	default:
+		return nil, false
+	}
+}
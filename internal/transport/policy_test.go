@@ -0,0 +1,232 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/policy"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+const enforcedPolicy = `
+rules:
+  - name: self-service
+    method: /users.UserService/UpdateUser
+    effect: allow
+    condition: request.id = caller.id
+  - name: protect-superusers
+    method: /users.UserService/DeleteUser
+    effect: deny
+    condition: target.roles:superuser
+  - name: read-anyone
+    method: /users.UserService/GetUserByID
+    effect: allow
+`
+
+func TestPolicyEnforcer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		mode            policy.Mode
+		selfUpdateCode  codes.Code
+		deleteSuperCode codes.Code
+		updateOtherCode codes.Code
+		deleteOtherCode codes.Code
+	}{
+		{
+			name:            "enforce",
+			mode:            policy.ModeEnforce,
+			selfUpdateCode:  codes.OK,
+			deleteSuperCode: codes.PermissionDenied,
+			updateOtherCode: codes.PermissionDenied,
+			deleteOtherCode: codes.OK,
+		},
+		{
+			name:            "audit",
+			mode:            policy.ModeAudit,
+			selfUpdateCode:  codes.PermissionDenied,
+			deleteSuperCode: codes.OK,
+			updateOtherCode: codes.PermissionDenied,
+			deleteOtherCode: codes.OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, ids := startPolicyServer(t, "mode: "+string(tc.mode)+enforcedPolicy)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			bob := withBasicAuth(ctx, "bob", "password")
+			root := withBasicAuth(ctx, "root", "password")
+
+			_, err := client.UpdateUser(bob, &proto.UpdateUserRequest{
+				Id:       ids["bob"],
+				Email:    "bob@example.org",
+				Username: "bob",
+				Password: "password",
+			})
+			assert.Equal(t, tc.selfUpdateCode, status.Code(err))
+
+			_, err = client.UpdateUser(bob, &proto.UpdateUserRequest{
+				Id:       ids["carol"],
+				Email:    "carol@example.org",
+				Username: "carol",
+				Password: "password",
+			})
+			assert.Equal(t, tc.updateOtherCode, status.Code(err))
+
+			_, err = client.DeleteUser(root, &proto.DeleteUserRequest{Id: ids["admin"]})
+			assert.Equal(t, tc.deleteSuperCode, status.Code(err))
+
+			_, err = client.DeleteUser(root, &proto.DeleteUserRequest{Id: ids["carol"]})
+			assert.Equal(t, tc.deleteOtherCode, status.Code(err))
+		})
+	}
+}
+
+func TestPolicyEnforcer_Organizations(t *testing.T) {
+	t.Parallel()
+
+	client, ids := startPolicyServer(t, "mode: "+string(policy.ModeEnforce)+enforcedPolicy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := client.GetUserByID(withBasicAuth(ctx, "bob", "password"), &proto.GetUserRequest{Id: ids["carol"]})
+	assert.Equal(t, codes.OK, status.Code(err), "the policy allows reading users without a permission")
+
+	mallory := metadata.AppendToOutgoingContext(
+		withBasicAuth(ctx, "mallory", "password"),
+		transport.OrganizationHeaderKey, "acme",
+	)
+
+	_, err = client.GetUserByID(mallory, &proto.GetUserRequest{Id: ids["carol"]})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the policy does not cross organizations")
+}
+
+func startPolicyServer(t *testing.T, rawPolicy string) (proto.UserServiceClient, map[string]string) { //nolint:ireturn
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rawPolicy), 0o600))
+
+	source, err := policy.NewSource(path, common.NewDisabledLogger())
+	require.NoError(t, err)
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+	roleUseCases := usecases.NewRoleUseCases(repo, repo, repo)
+
+	ids := make(map[string]string)
+	for username, roles := range map[string][]string{
+		"root":  {models.SuperuserRole},
+		"admin": {models.SuperuserRole},
+		"bob":   nil,
+		"carol": nil,
+	} {
+		id, err := userUseCases.CreateUser(
+			context.Background(),
+			usecases.NewCreateUserCommand(models.DefaultOrganization, username, username+"@example.com", "password", roles),
+		)
+		require.NoError(t, err)
+
+		ids[username] = id.String()
+	}
+
+	mallory, err := userUseCases.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand("acme", "mallory", "mallory@example.com", "password", nil),
+	)
+	require.NoError(t, err)
+
+	ids["mallory"] = mallory.String()
+
+	authorization := usecases.NewAuthorizationUseCases(roleUseCases, repo)
+	authenticator := transport.NewAuthenticator(userUseCases.AuthenticateUser)
+	resolver := transport.NewOrganizationResolver(authenticator, usecases.NewOrganizationUseCases(repo), authorization)
+	enforcer := transport.NewPolicyEnforcer(source, authenticator, userUseCases, roleUseCases)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		insecure.NewCredentials(),
+		[]grpc.UnaryServerInterceptor{resolver.UnaryInterceptor, enforcer.UnaryInterceptor},
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+		cancel()
+		<-done
+	})
+
+	return proto.NewUserServiceClient(conn), ids
+}
+
+func withBasicAuth(ctx context.Context, username string, password string) context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+credentials)
+}
+
+func newTestPasswordHasher(t testing.TB) *infrastructure.PasswordHasher {
+	t.Helper()
+
+	hasher, err := infrastructure.NewPasswordHasher(infrastructure.PasswordHasherConfig{
+		Algorithm: infrastructure.PasswordHashArgon2id,
+		Cost:      0,
+		Memory:    0,
+	})
+	require.NoError(t, err)
+
+	return hasher
+}
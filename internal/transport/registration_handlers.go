@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+// Register and VerifyEmail must be public, since they are called before the user can authenticate.
+const (
+	RegistrationServiceRegisterMethod                = "/users.RegistrationService/Register"
+	RegistrationServiceVerifyEmailMethod             = "/users.RegistrationService/VerifyEmail"
+	RegistrationServiceResendVerificationEmailMethod = "/users.RegistrationService/ResendVerificationEmail"
+)
+
+var errEmailNotVerified = errors.New("email is not verified")
+
+// WithVerifiedEmail lets users with a pending email verification call only the given methods, besides public ones.
+func WithVerifiedEmail(methods ...string) AuthenticatorOption[*models.User] {
+	return WithMethodCheck(func(user *models.User, method string) error {
+		if user.EmailVerification == nil || slices.Contains(methods, method) {
+			return nil
+		}
+
+		return fmt.Errorf("%w: user %q may not call %q", errEmailNotVerified, user.ID, method)
+	})
+}
+
+var _ proto.RegistrationServiceServer = (*RegistrationGRPCHandlers)(nil)
+
+type RegistrationGRPCHandlers struct {
+	proto.UnimplementedRegistrationServiceServer
+
+	registrationUseCases *usecases.RegistrationUseCases
+	authenticator        *Authenticator[*models.User]
+}
+
+func NewRegistrationGRPCHandlers(
+	registrationUseCases *usecases.RegistrationUseCases,
+	authenticator *Authenticator[*models.User],
+) *RegistrationGRPCHandlers {
+	return &RegistrationGRPCHandlers{
+		UnimplementedRegistrationServiceServer: proto.UnimplementedRegistrationServiceServer{},
+
+		registrationUseCases: registrationUseCases,
+		authenticator:        authenticator,
+	}
+}
+
+// RegisterService is the Register method of Service, which is taken by the RPC. Pass it as a ServiceFunc.
+func (h *RegistrationGRPCHandlers) RegisterService(registrar grpc.ServiceRegistrar) {
+	proto.RegisterRegistrationServiceServer(registrar, h)
+}
+
+func (h *RegistrationGRPCHandlers) Register(
+	ctx context.Context,
+	request *proto.RegisterRequest,
+) (*proto.RegisterResponse, error) {
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := usecases.NewRegisterCommand(organization, request.Username, request.Email, request.Password)
+
+	id, err := h.registrationUseCases.Register(ctx, cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrRegistrationDisabled):
+		return nil, status.Error(codes.Unimplemented, "Registration is disabled")
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "Organization not found")
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "User already exists")
+	default:
+		return nil, fmt.Errorf("failed to register user: %w", err)
+	}
+
+	return &proto.RegisterResponse{
+		Id: id.String(),
+	}, nil
+}
+
+func (h *RegistrationGRPCHandlers) VerifyEmail(
+	_ context.Context,
+	request *proto.VerifyEmailRequest,
+) (*emptypb.Empty, error) {
+	err := h.registrationUseCases.VerifyEmail(usecases.NewVerifyEmailCommand(request.Token))
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrInvalidToken):
+		return nil, status.Error(codes.InvalidArgument, "Invalid or expired verification token")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *RegistrationGRPCHandlers) ResendVerificationEmail(
+	ctx context.Context,
+	_ *proto.ResendVerificationEmailRequest,
+) (*emptypb.Empty, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	err = h.registrationUseCases.ResendVerificationEmail(user)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to resend verification email: %w", err)
+	}
+
+	return empty, nil
+}
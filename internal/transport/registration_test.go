@@ -0,0 +1,230 @@
+package transport_test
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+func TestRegistration(t *testing.T) {
+	t.Parallel()
+
+	conn, outbox := startRegistrationServer(t)
+	registration := proto.NewRegistrationServiceClient(conn)
+	roles := proto.NewRoleServiceClient(conn)
+	users := proto.NewUserServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := registration.Register(ctx, &proto.RegisterRequest{
+		Email:    "alice@corp.com",
+		Username: "alice",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	alice := withBasicAuth(ctx, "alice", "password")
+
+	_, err = roles.ListPermissions(alice, &proto.ListPermissionsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "unverified users are limited")
+	assert.Equal(t, "Email is not verified", status.Convert(err).Message())
+
+	me, err := users.GetMe(alice, &proto.GetMeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", me.User.Username)
+	assert.False(t, me.User.EmailVerified)
+
+	_, err = registration.ResendVerificationEmail(alice, &proto.ResendVerificationEmailRequest{})
+	require.NoError(t, err)
+
+	_, err = users.UpdateMe(alice, &proto.UpdateMeRequest{Username: "alice", Email: "alice@corp.org", Etag: ""})
+	require.NoError(t, err, "unverified users may fix a mistyped email")
+
+	_, err = users.ChangePassword(alice, &proto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new"})
+	assert.Equal(t, "Current password is wrong", status.Convert(err).Message(), "unverified users may change passwords")
+
+	mails := outbox.Mails()
+	require.Len(t, mails, 3)
+	assert.Equal(t, "alice@corp.org", mails[2].To)
+
+	token := regexp.MustCompile(`token (\S+)`).FindStringSubmatch(mails[2].Body)
+	require.Len(t, token, 2)
+
+	_, err = registration.VerifyEmail(ctx, &proto.VerifyEmailRequest{Token: token[1] + "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = registration.VerifyEmail(ctx, &proto.VerifyEmailRequest{Token: token[1]})
+	require.NoError(t, err)
+
+	_, err = roles.ListPermissions(alice, &proto.ListPermissionsRequest{})
+	assert.NoError(t, err)
+
+	_, err = users.ChangePassword(alice, &proto.ChangePasswordRequest{
+		CurrentPassword: "wrong",
+		NewPassword:     "new password",
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = users.ChangePassword(alice, &proto.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "new password",
+	})
+	require.NoError(t, err)
+
+	me, err = users.GetMe(withBasicAuth(ctx, "alice", "new password"), &proto.GetMeRequest{})
+	require.NoError(t, err)
+	assert.True(t, me.User.EmailVerified)
+}
+
+func TestPasswordReset(t *testing.T) {
+	t.Parallel()
+
+	conn, outbox := startRegistrationServer(t)
+	registration := proto.NewRegistrationServiceClient(conn)
+	passwordReset := proto.NewPasswordResetServiceClient(conn)
+	users := proto.NewUserServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := registration.Register(ctx, &proto.RegisterRequest{
+		Email:    "alice@corp.com",
+		Username: "alice",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	_, err = passwordReset.RequestPasswordReset(ctx, &proto.RequestPasswordResetRequest{Username: "bob"})
+	require.NoError(t, err, "unknown usernames are not revealed")
+
+	_, err = passwordReset.RequestPasswordReset(ctx, &proto.RequestPasswordResetRequest{Username: "alice"})
+	require.NoError(t, err)
+
+	var trailer metadata.MD
+	_, err = passwordReset.RequestPasswordReset(
+		ctx, &proto.RequestPasswordResetRequest{Username: "alice"}, grpc.Trailer(&trailer),
+	)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the pending reset is not replaced in the cooldown")
+	assert.Equal(t, []string{"60"}, trailer.Get(transport.RetryAfterTrailerKey))
+
+	require.Eventually(t, func() bool {
+		return len(outbox.Mails()) == 2
+	}, 5*time.Second, time.Millisecond, "the reset is mailed in the background")
+
+	mails := outbox.Mails()
+
+	token := regexp.MustCompile(`token (\S+)`).FindStringSubmatch(mails[1].Body)
+	require.Len(t, token, 2)
+
+	request := &proto.ResetPasswordRequest{Token: token[1], NewPassword: "new password"}
+
+	_, err = passwordReset.ResetPassword(ctx, request)
+	require.NoError(t, err)
+
+	_, err = passwordReset.ResetPassword(ctx, request)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = users.GetMe(withBasicAuth(ctx, "alice", "password"), &proto.GetMeRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	me, err := users.GetMe(withBasicAuth(ctx, "alice", "new password"), &proto.GetMeRequest{})
+	require.NoError(t, err)
+	assert.True(t, me.User.EmailVerified, "the reset token proves the email")
+}
+
+func startRegistrationServer(t *testing.T) (*grpc.ClientConn, *infrastructure.MemoryOutbox) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	outbox := infrastructure.NewMemoryOutbox()
+	verifications := usecases.NewEmailVerifications(outbox, time.Hour)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		verifications,
+	)
+	organizationUseCases := usecases.NewOrganizationUseCases(repo)
+	roleUseCases := usecases.NewRoleUseCases(repo, repo, repo)
+	authorization := usecases.NewAuthorizationUseCases(roleUseCases, repo)
+	registrationUseCases := usecases.NewRegistrationUseCases(
+		userUseCases, organizationUseCases, verifications, true,
+	)
+	passwordResetUseCases := usecases.NewPasswordResetUseCases(userUseCases, repo, outbox, time.Hour, time.Minute)
+
+	authenticator := transport.NewAuthenticator(
+		userUseCases.AuthenticateUser,
+		transport.WithPublicMethods[*models.User](
+			transport.RegistrationServiceRegisterMethod,
+			transport.RegistrationServiceVerifyEmailMethod,
+			transport.PasswordResetServiceRequestPasswordResetMethod,
+			transport.PasswordResetServiceResetPasswordMethod,
+		),
+		transport.WithVerifiedEmail(
+			transport.UserServiceGetMeMethod,
+			transport.UserServiceUpdateMeMethod,
+			transport.UserServiceChangePasswordMethod,
+			transport.RegistrationServiceResendVerificationEmailMethod,
+		),
+	)
+	resolver := transport.NewOrganizationResolver(authenticator, organizationUseCases, authorization)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		insecure.NewCredentials(),
+		[]grpc.UnaryServerInterceptor{resolver.UnaryInterceptor},
+		transport.ServiceFunc(transport.NewRegistrationGRPCHandlers(registrationUseCases, authenticator).RegisterService),
+		transport.NewRoleGRPCHandlers(roleUseCases, authorization, authenticator),
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+		transport.NewPasswordResetGRPCHandlers(passwordResetUseCases),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+	go passwordResetUseCases.Run(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+		cancel()
+		<-done
+	})
+
+	return conn, outbox
+}
@@ -0,0 +1,272 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/relation"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.RelationServiceServer = (*RelationGRPCHandlers)(nil)
+
+type RelationGRPCHandlers struct {
+	proto.UnimplementedRelationServiceServer
+
+	relationUseCases *usecases.RelationUseCases
+	authorization    *usecases.AuthorizationUseCases
+	authenticator    *Authenticator[*models.User]
+}
+
+func NewRelationGRPCHandlers(
+	relationUseCases *usecases.RelationUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *RelationGRPCHandlers {
+	return &RelationGRPCHandlers{
+		UnimplementedRelationServiceServer: proto.UnimplementedRelationServiceServer{},
+
+		relationUseCases: relationUseCases,
+		authorization:    authorization,
+		authenticator:    authenticator,
+	}
+}
+
+func (h *RelationGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterRelationServiceServer(registrar, h)
+}
+
+func (h *RelationGRPCHandlers) WriteTuples(
+	ctx context.Context,
+	request *proto.WriteTuplesRequest,
+) (*proto.WriteTuplesResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionTuplesWrite, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := parseProtoTuples(request.Writes)
+	if err != nil {
+		return nil, err
+	}
+
+	deletes, err := parseProtoTuples(request.Deletes)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewWriteTuplesCommand(organization, writes, deletes)
+	switch {
+	case err == nil:
+	case errors.Is(err, relation.ErrInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create WriteTuples command: %w", err)
+	}
+
+	token, err := h.relationUseCases.WriteTuples(cmd)
+	if err != nil {
+		return nil, relationError(err, "failed to write tuples")
+	}
+
+	return &proto.WriteTuplesResponse{
+		ConsistencyToken: token,
+	}, nil
+}
+
+func (h *RelationGRPCHandlers) ReadTuples(
+	ctx context.Context,
+	request *proto.ReadTuplesRequest,
+) (*proto.ReadTuplesResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionTuplesRead, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := usecases.NewReadTuplesQuery(
+		organization,
+		request.Namespace,
+		request.ObjectId,
+		request.Relation,
+		request.Subject,
+		request.ConsistencyToken,
+	)
+	switch {
+	case err == nil:
+	case errors.Is(err, relation.ErrInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create ReadTuples query: %w", err)
+	}
+
+	tuples, token, err := h.relationUseCases.ReadTuples(query)
+	if err != nil {
+		return nil, relationError(err, "failed to read tuples")
+	}
+
+	response := &proto.ReadTuplesResponse{
+		Tuples:           make([]*proto.RelationTuple, len(tuples)),
+		ConsistencyToken: token,
+	}
+	for i, tuple := range tuples {
+		response.Tuples[i] = newProtoTuple(tuple)
+	}
+
+	return response, nil
+}
+
+func (h *RelationGRPCHandlers) Check(
+	ctx context.Context,
+	request *proto.CheckRelationRequest,
+) (*proto.CheckRelationResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionTuplesRead, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := usecases.NewCheckRelationQuery(
+		organization,
+		request.Object,
+		request.Relation,
+		request.Subject,
+		request.ConsistencyToken,
+	)
+	switch {
+	case err == nil:
+	case errors.Is(err, relation.ErrInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create Check query: %w", err)
+	}
+
+	allowed, token, err := h.relationUseCases.Check(query)
+	if err != nil {
+		return nil, relationError(err, "failed to check relation")
+	}
+
+	return &proto.CheckRelationResponse{
+		Allowed:          allowed,
+		ConsistencyToken: token,
+	}, nil
+}
+
+func (h *RelationGRPCHandlers) Expand(
+	ctx context.Context,
+	request *proto.ExpandRequest,
+) (*proto.ExpandResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionTuplesRead, "")
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organizationOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := usecases.NewExpandQuery(organization, request.Object, request.Relation, request.ConsistencyToken)
+	switch {
+	case err == nil:
+	case errors.Is(err, relation.ErrInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create Expand query: %w", err)
+	}
+
+	tree, token, err := h.relationUseCases.Expand(query)
+	if err != nil {
+		return nil, relationError(err, "failed to expand relation")
+	}
+
+	return &proto.ExpandResponse{
+		Tree:             newProtoExpandNode(tree),
+		ConsistencyToken: token,
+	}, nil
+}
+
+// relationError converts an error of the relation use cases into the status returned to the caller.
+func relationError(err error, message string) error {
+	switch {
+	case errors.Is(err, relation.ErrInvalid), errors.Is(err, usecases.ErrInvalidConsistencyToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, usecases.ErrConsistencyTokenAhead):
+		return status.Error(codes.FailedPrecondition, "Consistency token is ahead of the stored tuples")
+	case errors.Is(err, relation.ErrDepthExceeded):
+		return status.Error(codes.FailedPrecondition, "Relations are nested too deeply to evaluate")
+	default:
+		return fmt.Errorf("%s: %w", message, err)
+	}
+}
+
+func parseProtoTuples(tuples []*proto.RelationTuple) ([]*models.Tuple, error) {
+	parsed := make([]*models.Tuple, len(tuples))
+
+	for i, tuple := range tuples {
+		t, err := relation.ParseTuple(tuple.Object, tuple.Relation, tuple.Subject)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		parsed[i] = t
+	}
+
+	return parsed, nil
+}
+
+func newProtoTuple(tuple *models.Tuple) *proto.RelationTuple {
+	return &proto.RelationTuple{
+		Object:   tuple.Object.String(),
+		Relation: tuple.Relation,
+		Subject:  tuple.Subject.String(),
+	}
+}
+
+func newProtoExpandNode(node *relation.Node) *proto.ExpandNode {
+	protoNode := &proto.ExpandNode{
+		Operation: proto.ExpandNode_OPERATION_UNSPECIFIED,
+		Userset:   node.Userset.String(),
+		Subjects:  make([]string, len(node.Subjects)),
+		Children:  make([]*proto.ExpandNode, len(node.Children)),
+	}
+
+	switch node.Kind {
+	case relation.NodeUnion:
+		protoNode.Operation = proto.ExpandNode_OPERATION_UNION
+	case relation.NodeDirect:
+		protoNode.Operation = proto.ExpandNode_OPERATION_DIRECT
+	case relation.NodeInherited:
+		protoNode.Operation = proto.ExpandNode_OPERATION_INHERITED
+	}
+
+	for i, subject := range node.Subjects {
+		protoNode.Subjects[i] = subject.String()
+	}
+
+	for i, child := range node.Children {
+		protoNode.Children[i] = newProtoExpandNode(child)
+	}
+
+	return protoNode
+}
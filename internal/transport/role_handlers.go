@@ -0,0 +1,236 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+var _ proto.RoleServiceServer = (*RoleGRPCHandlers)(nil)
+
+type RoleGRPCHandlers struct {
+	proto.UnimplementedRoleServiceServer
+
+	roleUseCases  *usecases.RoleUseCases
+	authorization *usecases.AuthorizationUseCases
+	authenticator *Authenticator[*models.User]
+}
+
+func NewRoleGRPCHandlers(
+	roleUseCases *usecases.RoleUseCases,
+	authorization *usecases.AuthorizationUseCases,
+	authenticator *Authenticator[*models.User],
+) *RoleGRPCHandlers {
+	return &RoleGRPCHandlers{
+		UnimplementedRoleServiceServer: proto.UnimplementedRoleServiceServer{},
+
+		roleUseCases:  roleUseCases,
+		authorization: authorization,
+		authenticator: authenticator,
+	}
+}
+
+func (h *RoleGRPCHandlers) Register(registrar grpc.ServiceRegistrar) {
+	proto.RegisterRoleServiceServer(registrar, h)
+}
+
+// CreateRole requires the superuser role, since custom roles are shared by all organizations.
+func (h *RoleGRPCHandlers) CreateRole(
+	ctx context.Context,
+	request *proto.CreateRoleRequest,
+) (*proto.CreateRoleResponse, error) {
+	_, err := authorizeSuperuser(ctx, h.authenticator, h.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewCreateRoleCommand(request.Name, request.Permissions)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidRole):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, fmt.Errorf("failed to create CreateRole command: %w", err)
+	}
+
+	role, err := h.roleUseCases.CreateRole(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, "Role already exists")
+	default:
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return &proto.CreateRoleResponse{
+		Role: newProtoRole(role),
+	}, nil
+}
+
+func (h *RoleGRPCHandlers) ListRoles(
+	ctx context.Context,
+	_ *proto.ListRolesRequest,
+) (*proto.ListRolesResponse, error) {
+	_, err := authorize(ctx, h.authenticator, h.authorization, models.PermissionRolesManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := h.roleUseCases.ListRoles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	response := &proto.ListRolesResponse{
+		Roles: make([]*proto.Role, len(roles)),
+	}
+	for i, role := range roles {
+		response.Roles[i] = newProtoRole(role)
+	}
+
+	return response, nil
+}
+
+func (h *RoleGRPCHandlers) AssignRole(ctx context.Context, request *proto.AssignRoleRequest) (*emptypb.Empty, error) {
+	_, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionRolesManage, usecases.UserResource(request.UserId),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, request.Role); err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewAssignRoleCommand(request.UserId, request.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AssignRole command: %w", err)
+	}
+
+	err = h.roleUseCases.AssignRole(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, usecases.ErrInvalidRole):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *RoleGRPCHandlers) UnassignRole(
+	ctx context.Context,
+	request *proto.UnassignRoleRequest,
+) (*emptypb.Empty, error) {
+	_, err := authorize(
+		ctx, h.authenticator, h.authorization, models.PermissionRolesManage, usecases.UserResource(request.UserId),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeRoleChange(ctx, h.authenticator, h.authorization, request.Role); err != nil {
+		return nil, err
+	}
+
+	cmd, err := usecases.NewUnassignRoleCommand(request.UserId, request.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UnassignRole command: %w", err)
+	}
+
+	err = h.roleUseCases.UnassignRole(cmd)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, common.ErrConflict):
+		return nil, status.Error(codes.Aborted, "User has been modified concurrently")
+	default:
+		return nil, fmt.Errorf("failed to unassign role: %w", err)
+	}
+
+	return empty, nil
+}
+
+func (h *RoleGRPCHandlers) ListPermissions(
+	ctx context.Context,
+	request *proto.ListPermissionsRequest,
+) (*proto.ListPermissionsResponse, error) {
+	user, err := h.authenticator.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	userID := request.UserId
+	if userID == "" {
+		userID = user.ID.String()
+	}
+
+	if userID != user.ID.String() {
+		_, err := authorize(
+			ctx, h.authenticator, h.authorization, models.PermissionUsersRead, usecases.UserResource(userID),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query, err := usecases.NewListPermissionsQuery(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ListPermissions query: %w", err)
+	}
+
+	permissions, err := h.roleUseCases.ListPermissions(query)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "User not found")
+	default:
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+
+	response := &proto.ListPermissionsResponse{
+		Permissions: make([]string, len(permissions)),
+	}
+	for i, permission := range permissions {
+		response.Permissions[i] = string(permission)
+	}
+
+	return response, nil
+}
+
+func newProtoRole(role *models.Role) *proto.Role {
+	protoRole := &proto.Role{
+		Name:        role.Name,
+		Permissions: make([]string, len(role.Permissions)),
+		BuiltIn:     role.Name == models.SuperuserRole,
+		CreateTime:  nil,
+	}
+
+	for i, permission := range role.Permissions {
+		protoRole.Permissions[i] = string(permission)
+	}
+
+	if !role.CreatedAt.IsZero() {
+		protoRole.CreateTime = timestamppb.New(role.CreatedAt)
+	}
+
+	return protoRole
+}
@@ -7,28 +7,50 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
 	"github.com/ScareTrow/grpc_user_auth/internal/models"
-	"github.com/ScareTrow/grpc_user_auth/proto"
 )
 
 type GRPCServer struct {
 	server *grpc.Server
 }
 
+// Service is a set of handlers of a gRPC service.
+type Service interface {
+	Register(registrar grpc.ServiceRegistrar)
+}
+
+// ServiceFunc is a Service for handlers that cannot have the Register method, since the service has an RPC of that name.
+type ServiceFunc func(registrar grpc.ServiceRegistrar)
+
+func (f ServiceFunc) Register(registrar grpc.ServiceRegistrar) {
+	f(registrar)
+}
+
+// NewGRPCServer creates a server that accepts connections with the given credentials,
+// such as insecure.NewCredentials() for plaintext connections or NewServerCredentials for TLS.
+// The interceptors run after authentication and validation, in order.
 func NewGRPCServer(
 	logger *slog.Logger,
 	authenticator *Authenticator[*models.User],
-	handlers *GRPCHandlers,
+	creds credentials.TransportCredentials,
+	interceptors []grpc.UnaryServerInterceptor,
+	services ...Service,
 ) *GRPCServer {
 	server := grpc.NewServer(
+		grpc.Creds(creds),
 		grpc.ChainUnaryInterceptor(common.GetLoggerInjectionUnaryInterceptor(logger)),
 		grpc.ChainUnaryInterceptor(ErrorHandlingUnaryInterceptor),
-		grpc.ChainUnaryInterceptor(authenticator.BasicAuthUnaryInterceptor),
+		grpc.ChainUnaryInterceptor(authenticator.AuthUnaryInterceptor),
 		grpc.ChainUnaryInterceptor(ValidationUnaryInterceptor),
+		grpc.ChainUnaryInterceptor(interceptors...),
 	)
-	proto.RegisterUserServiceServer(server, handlers)
+
+	for _, service := range services {
+		service.Register(server)
+	}
 
 	return &GRPCServer{
 		server: server,
@@ -36,14 +58,19 @@ func NewGRPCServer(
 }
 
 func (s *GRPCServer) ListenAndServe(ctx context.Context, address string) error {
-	logger := common.ExtractLogger(ctx)
-
 	listenerConfig := new(net.ListenConfig)
 	listener, err := listenerConfig.Listen(ctx, "tcp", address)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts connections on the listener until the server is stopped.
+func (s *GRPCServer) Serve(ctx context.Context, listener net.Listener) error {
+	logger := common.ExtractLogger(ctx)
+
 	logger.InfoContext(
 		ctx,
 		"grpc server is listening",
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+)
+
+type TLSConfig struct {
+	CertPath string
+	KeyPath  string
+	// ClientCAPath enables client certificates, which are verified against the PEM encoded CAs in the file.
+	ClientCAPath string
+	// RequireClientCert rejects connections without a valid client certificate.
+	RequireClientCert bool
+}
+
+func NewServerCredentials(config TLSConfig) (credentials.TransportCredentials, error) { //nolint:ireturn
+	certificate, err := tls.LoadX509KeyPair(config.CertPath, config.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.NoClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch {
+	case config.ClientCAPath != "":
+		clientCAs, err := loadCertPool(config.ClientCAPath)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+		if config.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	case config.RequireClientCert:
+		return nil, errors.New("client certificates cannot be required without a client CA")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates %q: %w", path, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no PEM encoded certificates in %q", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,274 @@
+package transport_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/transport"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+	"github.com/ScareTrow/grpc_user_auth/proto"
+)
+
+const serviceUsername = "billing"
+
+func TestMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	anotherCA := newTestCA(t)
+
+	serverCert := ca.issue(t, "server", func(template *x509.Certificate) {
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	})
+	writePEM(t, filepath.Join(dir, "server.crt"), "CERTIFICATE", serverCert.Certificate[0])
+	writePEM(t, filepath.Join(dir, "server.key"), "PRIVATE KEY", marshalKey(t, serverCert.PrivateKey))
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", ca.certificate.Raw)
+
+	config := transport.TLSConfig{
+		CertPath:          filepath.Join(dir, "server.crt"),
+		KeyPath:           filepath.Join(dir, "server.key"),
+		ClientCAPath:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: false,
+	}
+
+	serviceCert := ca.issue(t, serviceUsername, nil)
+	unknownCert := ca.issue(t, "unknown", nil)
+	forgedCert := anotherCA.issue(t, serviceUsername, nil)
+
+	t.Run("client certificate", func(t *testing.T) {
+		t.Parallel()
+
+		address := startTLSServer(t, config)
+
+		_, err := getAllUsers(t, address, ca, &serviceCert)
+		assert.NoError(t, err)
+
+		_, err = getAllUsers(t, address, ca, &unknownCert)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = getAllUsers(t, address, ca, nil)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = getAllUsers(t, address, ca, &forgedCert)
+		assert.Error(t, err, "certificates of another CA must be rejected")
+	})
+
+	t.Run("required client certificate", func(t *testing.T) {
+		t.Parallel()
+
+		requiredConfig := config
+		requiredConfig.RequireClientCert = true
+		address := startTLSServer(t, requiredConfig)
+
+		_, err := getAllUsers(t, address, ca, &serviceCert)
+		assert.NoError(t, err)
+
+		_, err = getAllUsers(t, address, ca, nil)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("required client certificate without CA", func(t *testing.T) {
+		t.Parallel()
+
+		invalidConfig := config
+		invalidConfig.ClientCAPath = ""
+		invalidConfig.RequireClientCert = true
+
+		_, err := transport.NewServerCredentials(invalidConfig)
+		assert.Error(t, err)
+	})
+}
+
+func startTLSServer(t *testing.T, config transport.TLSConfig) string {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	userUseCases := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+	_, err = userUseCases.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, serviceUsername, "billing@corp.com", "password", nil),
+	)
+	require.NoError(t, err)
+
+	authUseCases := usecases.NewAuthUseCases(userUseCases, nil, repo, time.Hour)
+	authenticator := transport.NewAuthenticator(
+		userUseCases.AuthenticateUser,
+		transport.WithClientCertificateAuth(transport.CertificateSubjectCommonName, authUseCases.AuthenticateUsername),
+	)
+
+	authorization := usecases.NewAuthorizationUseCases(usecases.NewRoleUseCases(repo, repo, repo), repo)
+	resolver := transport.NewOrganizationResolver(authenticator, usecases.NewOrganizationUseCases(repo), authorization)
+
+	creds, err := transport.NewServerCredentials(config)
+	require.NoError(t, err)
+
+	server := transport.NewGRPCServer(
+		common.NewDisabledLogger(),
+		authenticator,
+		creds,
+		[]grpc.UnaryServerInterceptor{resolver.UnaryInterceptor},
+		transport.NewGRPCHandlers(userUseCases, authorization, authenticator),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ShutdownOnContextDone(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.Serve(ctx, listener))
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return listener.Addr().String()
+}
+
+func getAllUsers(
+	t *testing.T,
+	address string,
+	ca *testCA,
+	clientCert *tls.Certificate,
+) (*proto.GetAllUsersResponse, error) {
+	t.Helper()
+
+	tlsConfig := &tls.Config{
+		RootCAs:    x509.NewCertPool(),
+		MinVersion: tls.VersionTLS12,
+	}
+	tlsConfig.RootCAs.AddCert(ca.certificate)
+
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, conn.Close())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return proto.NewUserServiceClient(conn).GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+}
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := newCertificateTemplate("test CA")
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		certificate: certificate,
+		key:         key,
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, commonName string, customize func(*x509.Certificate)) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := newCertificateTemplate(commonName)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	if customize != nil {
+		customize(template)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
+
+func newCertificateTemplate(commonName string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
+
+func marshalKey(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return der
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
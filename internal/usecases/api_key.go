@@ -0,0 +1,289 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+const (
+	// apiKeyPrefix makes keys recognizable, for example by secret scanners.
+	apiKeyPrefix         = "gua_"
+	apiKeySize           = 32
+	apiKeyPrefixLength   = len(apiKeyPrefix) + 8
+	maxAPIKeyNameLength  = 100
+	scopeServiceWildcard = "*"
+)
+
+type APIKeyUseCases struct {
+	apiKeys APIKeyRepository
+	users   UserRepository
+}
+
+func NewAPIKeyUseCases(apiKeys APIKeyRepository, users UserRepository) *APIKeyUseCases {
+	return &APIKeyUseCases{
+		apiKeys: apiKeys,
+		users:   users,
+	}
+}
+
+type CreateAPIKeyCommand struct {
+	userID       uuid.UUID
+	name         string
+	scopes       []string
+	expiresAt    time.Time
+	callerAPIKey string
+}
+
+// NewCreateAPIKeyCommand creates a command for a key of the user. Zero expiresAt means that the key never expires.
+// callerAPIKey is the key that the caller authenticated with, which limits the scopes of the new key to its own,
+// or empty if the caller authenticated otherwise.
+func NewCreateAPIKeyCommand(
+	rawUserID string,
+	name string,
+	scopes []string,
+	expiresAt time.Time,
+	callerAPIKey string,
+) (*CreateAPIKeyCommand, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters long", ErrInvalidAPIKey, maxAPIKeyNameLength)
+	}
+
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiration time is in the past", ErrInvalidAPIKey)
+	}
+
+	for _, scope := range scopes {
+		if err := validateScope(scope); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CreateAPIKeyCommand{
+		userID:       userID,
+		name:         name,
+		scopes:       scopes,
+		expiresAt:    expiresAt,
+		callerAPIKey: callerAPIKey,
+	}, nil
+}
+
+type CreatedAPIKey struct {
+	APIKey *models.APIKey
+	// Key is the secret itself, which is not stored and cannot be shown again.
+	Key string
+}
+
+// CreateAPIKey fails with common.ErrPermissionDenied if the caller authenticated with an API key
+// that is not allowed to call every method that the new key would be allowed to call,
+// so that a key cannot escape its scopes by creating another one.
+func (a *APIKeyUseCases) CreateAPIKey(cmd *CreateAPIKeyCommand) (*CreatedAPIKey, error) {
+	if cmd.callerAPIKey != "" {
+		if err := a.checkCallerScopes(cmd.callerAPIKey, cmd.scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	raw := make([]byte, apiKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now().UTC()
+
+	var expiresAt time.Time
+	if !cmd.expiresAt.IsZero() {
+		expiresAt = cmd.expiresAt.UTC()
+	}
+
+	apiKey := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    cmd.userID,
+		Name:      cmd.name,
+		Prefix:    key[:apiKeyPrefixLength],
+		Hash:      hashSecret(key),
+		Scopes:    cmd.scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := a.apiKeys.SaveAPIKey(apiKey); err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return &CreatedAPIKey{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+type ListAPIKeysQuery struct {
+	userID uuid.UUID
+}
+
+func NewListAPIKeysQuery(rawUserID string) (*ListAPIKeysQuery, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return &ListAPIKeysQuery{
+		userID: userID,
+	}, nil
+}
+
+func (a *APIKeyUseCases) ListAPIKeys(query *ListAPIKeysQuery) ([]*models.APIKey, error) {
+	keys, err := a.apiKeys.ListAPIKeys(query.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys of user %q: %w", query.userID, err)
+	}
+
+	return keys, nil
+}
+
+type RevokeAPIKeyCommand struct {
+	userID uuid.UUID
+	id     uuid.UUID
+}
+
+func NewRevokeAPIKeyCommand(rawUserID string, rawID string) (*RevokeAPIKeyCommand, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse api key id: %w", err)
+	}
+
+	return &RevokeAPIKeyCommand{
+		userID: userID,
+		id:     id,
+	}, nil
+}
+
+// RevokeAPIKey deletes the key of the user. A key of another user is reported as not found.
+func (a *APIKeyUseCases) RevokeAPIKey(cmd *RevokeAPIKeyCommand) error {
+	keys, err := a.apiKeys.ListAPIKeys(cmd.userID)
+	if err != nil {
+		return fmt.Errorf("failed to list api keys of user %q: %w", cmd.userID, err)
+	}
+
+	for _, key := range keys {
+		if key.ID != cmd.id {
+			continue
+		}
+
+		if err := a.apiKeys.DeleteAPIKey(cmd.id); err != nil {
+			return fmt.Errorf("failed to delete api key %q: %w", cmd.id, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: api key %q of user %q not found", common.ErrNotFound, cmd.id, cmd.userID)
+}
+
+// AuthenticateAPIKey returns the owner of the key if the key is allowed to call the full gRPC method.
+func (a *APIKeyUseCases) AuthenticateAPIKey(key string, method string) (*models.User, error) {
+	apiKey, err := a.apiKeys.GetAPIKeyByHash(hashSecret(key))
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: %w", common.ErrInvalidToken, err)
+	default:
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if !apiKey.ExpiresAt.IsZero() && time.Now().After(apiKey.ExpiresAt) {
+		return nil, fmt.Errorf("%w: api key %q has expired", common.ErrInvalidToken, apiKey.ID)
+	}
+
+	if !apiKeyAllows(apiKey, method) {
+		return nil, fmt.Errorf("%w: api key %q is not allowed to call %s", common.ErrPermissionDenied, apiKey.ID, method)
+	}
+
+	user, err := a.users.GetByID(apiKey.UserID)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: owner of the api key does not exist", common.ErrInvalidToken)
+	default:
+		return nil, fmt.Errorf("failed to get user by id %q: %w", apiKey.UserID, err)
+	}
+
+	return user, nil
+}
+
+// checkCallerScopes fails with common.ErrPermissionDenied unless the scopes are within the scopes of the caller key.
+func (a *APIKeyUseCases) checkCallerScopes(callerAPIKey string, scopes []string) error {
+	caller, err := a.apiKeys.GetAPIKeyByHash(hashSecret(callerAPIKey))
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return fmt.Errorf("%w: %w", common.ErrInvalidToken, err)
+	default:
+		return fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if len(caller.Scopes) == 0 {
+		return nil
+	}
+
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: api key %q may not create an unscoped key", common.ErrPermissionDenied, caller.ID)
+	}
+
+	// A service scope such as "/users.UserService/*" is only allowed by the same service scope.
+	for _, scope := range scopes {
+		if !apiKeyAllows(caller, scope) {
+			return fmt.Errorf("%w: api key %q may not create a key for %s", common.ErrPermissionDenied, caller.ID, scope)
+		}
+	}
+
+	return nil
+}
+
+func apiKeyAllows(apiKey *models.APIKey, method string) bool {
+	if len(apiKey.Scopes) == 0 {
+		return true
+	}
+
+	service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+	for _, scope := range apiKey.Scopes {
+		if scope == method || scope == "/"+service+"/"+scopeServiceWildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateScope accepts full gRPC methods, such as "/users.UserService/GetAllUsers",
+// and all methods of a service, such as "/users.UserService/*".
+func validateScope(scope string) error {
+	service, method, ok := strings.Cut(strings.TrimPrefix(scope, "/"), "/")
+	if !strings.HasPrefix(scope, "/") || !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return fmt.Errorf("%w: %q is not a gRPC method such as \"/users.UserService/GetAllUsers\"", ErrInvalidAPIKey, scope)
+	}
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package usecases_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+const getAllUsersMethod = "/users.UserService/GetAllUsers"
+
+func TestAPIKeyUseCases_AuthenticateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		scopes      []string
+		method      string
+		expectedErr error
+	}{
+		{
+			name:        "unscoped",
+			scopes:      nil,
+			method:      "/users.UserService/DeleteUser",
+			expectedErr: nil,
+		},
+		{
+			name:        "method scope",
+			scopes:      []string{getAllUsersMethod},
+			method:      getAllUsersMethod,
+			expectedErr: nil,
+		},
+		{
+			name:        "service scope",
+			scopes:      []string{"/users.UserService/*"},
+			method:      getAllUsersMethod,
+			expectedErr: nil,
+		},
+		{
+			name:        "out of scope",
+			scopes:      []string{getAllUsersMethod, "/users.AuthService/*"},
+			method:      "/users.UserService/DeleteUser",
+			expectedErr: common.ErrPermissionDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sut, user := newTestAPIKeyUseCases(t)
+			created := createAPIKey(t, sut, user, tc.scopes, time.Time{})
+
+			actual, err := sut.AuthenticateAPIKey(created.Key, tc.method)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, actual.ID)
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		sut, user := newTestAPIKeyUseCases(t)
+		created := createAPIKey(t, sut, user, nil, time.Now().Add(100*time.Millisecond))
+
+		_, err := sut.AuthenticateAPIKey(created.Key, getAllUsersMethod)
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			_, err := sut.AuthenticateAPIKey(created.Key, getAllUsersMethod)
+
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+
+		_, err = sut.AuthenticateAPIKey(created.Key, getAllUsersMethod)
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		t.Parallel()
+
+		sut, user := newTestAPIKeyUseCases(t)
+		created := createAPIKey(t, sut, user, nil, time.Time{})
+
+		assert.True(t, strings.HasPrefix(created.Key, created.APIKey.Prefix))
+
+		cmd, err := usecases.NewRevokeAPIKeyCommand(uuid.NewString(), created.APIKey.ID.String())
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.RevokeAPIKey(cmd), common.ErrNotFound, "keys of other users must not be revoked")
+
+		cmd, err = usecases.NewRevokeAPIKeyCommand(user.ID.String(), created.APIKey.ID.String())
+		require.NoError(t, err)
+		require.NoError(t, sut.RevokeAPIKey(cmd))
+
+		_, err = sut.AuthenticateAPIKey(created.Key, getAllUsersMethod)
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+}
+
+func TestAPIKeyUseCases_CreateAPIKeyWithAPIKey(t *testing.T) {
+	t.Parallel()
+
+	sut, user := newTestAPIKeyUseCases(t)
+	unscoped := createAPIKey(t, sut, user, nil, time.Time{})
+	scoped := createAPIKey(t, sut, user, []string{"/users.ApiKeyService/*", getAllUsersMethod}, time.Time{})
+
+	testCases := []struct {
+		name        string
+		callerKey   string
+		scopes      []string
+		expectedErr error
+	}{
+		{
+			name:        "unscoped caller",
+			callerKey:   unscoped.Key,
+			scopes:      nil,
+			expectedErr: nil,
+		},
+		{
+			name:        "unscoped key",
+			callerKey:   scoped.Key,
+			scopes:      nil,
+			expectedErr: common.ErrPermissionDenied,
+		},
+		{
+			name:        "same scopes",
+			callerKey:   scoped.Key,
+			scopes:      []string{"/users.ApiKeyService/*", getAllUsersMethod},
+			expectedErr: nil,
+		},
+		{
+			name:        "narrower scopes",
+			callerKey:   scoped.Key,
+			scopes:      []string{"/users.ApiKeyService/ListApiKeys"},
+			expectedErr: nil,
+		},
+		{
+			name:        "method out of scope",
+			callerKey:   scoped.Key,
+			scopes:      []string{"/users.UserService/DeleteUser"},
+			expectedErr: common.ErrPermissionDenied,
+		},
+		{
+			name:        "service wider than a method",
+			callerKey:   scoped.Key,
+			scopes:      []string{"/users.UserService/*"},
+			expectedErr: common.ErrPermissionDenied,
+		},
+		{
+			name:        "unknown caller key",
+			callerKey:   "gua_unknown",
+			scopes:      nil,
+			expectedErr: common.ErrInvalidToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, err := usecases.NewCreateAPIKeyCommand(user.ID.String(), "ci", tc.scopes, time.Time{}, tc.callerKey)
+			require.NoError(t, err)
+
+			_, err = sut.CreateAPIKey(cmd)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewCreateAPIKeyCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt time.Time
+	}{
+		{
+			name:      "empty name",
+			keyName:   "",
+			scopes:    nil,
+			expiresAt: time.Time{},
+		},
+		{
+			name:      "past expiration",
+			keyName:   "ci",
+			scopes:    nil,
+			expiresAt: time.Now().Add(-time.Minute),
+		},
+		{
+			name:      "scope without service",
+			keyName:   "ci",
+			scopes:    []string{"GetAllUsers"},
+			expiresAt: time.Time{},
+		},
+		{
+			name:      "scope without leading slash",
+			keyName:   "ci",
+			scopes:    []string{"users.UserService/GetAllUsers"},
+			expiresAt: time.Time{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := usecases.NewCreateAPIKeyCommand(uuid.NewString(), tc.keyName, tc.scopes, tc.expiresAt, "")
+			assert.ErrorIs(t, err, usecases.ErrInvalidAPIKey)
+		})
+	}
+}
+
+func newTestAPIKeyUseCases(t *testing.T) (*usecases.APIKeyUseCases, *models.User) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	user := &models.User{
+		ID:                uuid.New(),
+		Organization:      models.DefaultOrganization,
+		Username:          "bob",
+		Email:             "bob@example.com",
+		PasswordHash:      nil,
+		Roles:             nil,
+		EmailVerification: nil,
+		PasswordReset:     nil,
+		PasswordChangedAt: time.Time{},
+		CreatedAt:         time.Now().UTC(),
+		Version:           0,
+	}
+	require.NoError(t, repo.Save(user))
+
+	return usecases.NewAPIKeyUseCases(repo, repo), user
+}
+
+func createAPIKey(
+	t *testing.T,
+	sut *usecases.APIKeyUseCases,
+	user *models.User,
+	scopes []string,
+	expiresAt time.Time,
+) *usecases.CreatedAPIKey {
+	t.Helper()
+
+	cmd, err := usecases.NewCreateAPIKeyCommand(user.ID.String(), "ci", scopes, expiresAt, "")
+	require.NoError(t, err)
+
+	created, err := sut.CreateAPIKey(cmd)
+	require.NoError(t, err)
+
+	return created
+}
@@ -0,0 +1,297 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+type AccessTokenIssuer interface {
+	Issue(user *models.User) (token string, expiresAt time.Time, err error)
+	// Verify fails with common.ErrInvalidToken if the token is forged, expired or issued for someone else.
+	// It returns the subject and the PasswordChangedAt of the subject when the token was issued.
+	Verify(token string) (subject uuid.UUID, passwordChangedAt time.Time, err error)
+}
+
+// ErrRefreshTokenReused means that a rotated refresh token has been presented again,
+// so the token family has leaked and has been revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+type AuthUseCases struct {
+	users           *UserUseCases
+	accessTokens    AccessTokenIssuer
+	refreshTokens   RefreshTokenRepository
+	refreshTokenTTL time.Duration
+}
+
+func NewAuthUseCases(
+	users *UserUseCases,
+	accessTokens AccessTokenIssuer,
+	refreshTokens RefreshTokenRepository,
+	refreshTokenTTL time.Duration,
+) *AuthUseCases {
+	return &AuthUseCases{
+		users:           users,
+		accessTokens:    accessTokens,
+		refreshTokens:   refreshTokens,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+type LoginCommand struct {
+	organization string
+	username     string
+	password     string
+}
+
+// NewLoginCommand logs in the user with the username in the organization.
+func NewLoginCommand(organization string, username string, password string) *LoginCommand {
+	return &LoginCommand{
+		organization: organization,
+		username:     username,
+		password:     password,
+	}
+}
+
+type Tokens struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+func (a *AuthUseCases) Login(ctx context.Context, cmd *LoginCommand) (*Tokens, error) {
+	user, err := a.users.AuthenticateUser(ctx, cmd.organization, cmd.username, cmd.password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate user: %w", err)
+	}
+
+	refreshToken, stored, err := a.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.refreshTokens.SaveRefreshToken(stored)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		// The user has been deleted after the password was checked.
+		return nil, fmt.Errorf("%w: %w", common.ErrInvalidCredentials, err)
+	default:
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return a.issueTokens(user, refreshToken, stored)
+}
+
+type RefreshCommand struct {
+	refreshToken string
+}
+
+func NewRefreshCommand(refreshToken string) *RefreshCommand {
+	return &RefreshCommand{
+		refreshToken: refreshToken,
+	}
+}
+
+// Refresh exchanges a refresh token for new access and refresh tokens, so every refresh token is used only once.
+// Presenting a rotated refresh token revokes its whole family and fails with ErrRefreshTokenReused.
+func (a *AuthUseCases) Refresh(cmd *RefreshCommand) (*Tokens, error) {
+	previous, err := a.getRefreshToken(cmd.refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous.Rotated {
+		return nil, a.revokeReusedRefreshToken(previous)
+	}
+
+	user, err := a.users.repo.GetByID(previous.UserID)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: owner of the refresh token does not exist", common.ErrInvalidToken)
+	default:
+		return nil, fmt.Errorf("failed to get user by id %q: %w", previous.UserID, err)
+	}
+
+	refreshToken, next, err := a.newRefreshToken(user.ID, previous.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.refreshTokens.RotateRefreshToken(previous.Hash, next)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrConflict):
+		// The same token has been refreshed concurrently.
+		return nil, a.revokeReusedRefreshToken(previous)
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: refresh token has been revoked", common.ErrInvalidToken)
+	default:
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return a.issueTokens(user, refreshToken, next)
+}
+
+type LogoutCommand struct {
+	refreshToken string
+}
+
+func NewLogoutCommand(refreshToken string) *LogoutCommand {
+	return &LogoutCommand{
+		refreshToken: refreshToken,
+	}
+}
+
+// Logout revokes the family of the refresh token. Unknown and expired tokens are ignored.
+func (a *AuthUseCases) Logout(cmd *LogoutCommand) error {
+	token, err := a.refreshTokens.GetRefreshToken(hashSecret(cmd.refreshToken))
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil
+	default:
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return a.revokeRefreshTokenFamily(token.FamilyID)
+}
+
+// AuthenticateAccessToken returns the owner of a valid access token without checking any password.
+// The owner is loaded from the repository, so changes of the user apply to the tokens issued earlier,
+// and the tokens issued before the password was last changed or reset are rejected.
+func (a *AuthUseCases) AuthenticateAccessToken(token string) (*models.User, error) {
+	id, passwordChangedAt, err := a.accessTokens.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify access token: %w", err)
+	}
+
+	user, err := a.users.repo.GetByID(id)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: owner of the access token does not exist", common.ErrInvalidToken)
+	default:
+		return nil, fmt.Errorf("failed to get user by id %q: %w", id, err)
+	}
+
+	if !passwordChangedAt.Equal(user.PasswordChangedAt) {
+		return nil, fmt.Errorf("%w: password of user %q has changed since the access token was issued", common.ErrInvalidToken, id)
+	}
+
+	return user, nil
+}
+
+func (a *AuthUseCases) getRefreshToken(refreshToken string) (*models.RefreshToken, error) {
+	token, err := a.refreshTokens.GetRefreshToken(hashSecret(refreshToken))
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: %w", common.ErrInvalidToken, err)
+	default:
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		if err := a.revokeRefreshTokenFamily(token.FamilyID); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: refresh token has expired", common.ErrInvalidToken)
+	}
+
+	return token, nil
+}
+
+func (a *AuthUseCases) revokeReusedRefreshToken(token *models.RefreshToken) error {
+	if err := a.revokeRefreshTokenFamily(token.FamilyID); err != nil {
+		return err
+	}
+
+	return fmt.Errorf(
+		"%w: %w: family %q of user %q has been revoked",
+		common.ErrInvalidToken, ErrRefreshTokenReused, token.FamilyID, token.UserID,
+	)
+}
+
+func (a *AuthUseCases) revokeRefreshTokenFamily(familyID uuid.UUID) error {
+	err := a.refreshTokens.DeleteRefreshTokenFamily(familyID)
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("failed to delete refresh token family %q: %w", familyID, err)
+	}
+
+	return nil
+}
+
+func (a *AuthUseCases) newRefreshToken(userID uuid.UUID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	const refreshTokenSize = 32
+
+	raw := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now().UTC()
+
+	return refreshToken, &models.RefreshToken{
+		Hash:      hashSecret(refreshToken),
+		FamilyID:  familyID,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.refreshTokenTTL),
+		Rotated:   false,
+	}, nil
+}
+
+func (a *AuthUseCases) issueTokens(
+	user *models.User,
+	refreshToken string,
+	stored *models.RefreshToken,
+) (*Tokens, error) {
+	accessToken, expiresAt, err := a.accessTokens.Issue(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+
+	return &Tokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// hashSecret hashes the refresh tokens, API keys and user tokens that are stored only as hashes.
+// It does not need a salt or a slow hash, since these secrets are random and long, unlike passwords.
+func hashSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+
+	return hash[:]
+}
+
+// AuthenticateUsername returns the user with the username in the organization without checking any password.
+// It is meant for credentials that have already been verified elsewhere, such as client certificates.
+func (a *AuthUseCases) AuthenticateUsername(organization string, username string) (*models.User, error) {
+	user, err := a.users.repo.GetByUsername(organization, username)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: %w", common.ErrInvalidCredentials, err)
+	default:
+		return nil, fmt.Errorf("failed to get user by username %q: %w", username, err)
+	}
+
+	return user, nil
+}
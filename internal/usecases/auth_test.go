@@ -0,0 +1,213 @@
+package usecases_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestAuthUseCases_Refresh(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rotation", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _ := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		refreshed, err := sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		require.NoError(t, err)
+		assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+		_, err = sut.AuthenticateAccessToken(refreshed.AccessToken)
+		assert.NoError(t, err)
+
+		refreshed, err = sut.Refresh(usecases.NewRefreshCommand(refreshed.RefreshToken))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, refreshed.RefreshToken)
+	})
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _ := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+		anotherLogin := login(t, sut)
+
+		refreshed, err := sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		require.NoError(t, err)
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, usecases.ErrRefreshTokenReused)
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(refreshed.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(anotherLogin.RefreshToken))
+		assert.NoError(t, err, "other logins of the user must survive")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _ := newTestAuthUseCases(t, -time.Second)
+		tokens := login(t, sut)
+
+		_, err := sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _ := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		require.NoError(t, sut.Logout(usecases.NewLogoutCommand(tokens.RefreshToken)))
+		assert.NoError(t, sut.Logout(usecases.NewLogoutCommand(tokens.RefreshToken)))
+
+		_, err := sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+
+	t.Run("changed password", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		user, err := sut.AuthenticateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+
+		cmd := usecases.NewChangePasswordCommand(user.ID, testPassword, "new password")
+		require.NoError(t, users.ChangePassword(context.Background(), cmd))
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "refresh tokens do not outlive the password")
+	})
+
+	t.Run("overwritten password", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		user, err := sut.AuthenticateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+
+		cmd, err := usecases.NewUpdateUserCommand(user.ID.String(), user.Username, user.Email, "new password", "")
+		require.NoError(t, err)
+		require.NoError(t, users.UpdateUser(context.Background(), cmd))
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "refresh tokens do not outlive the password")
+	})
+
+	t.Run("changed profile", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		user, err := sut.AuthenticateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+
+		cmd, err := usecases.NewUpdateProfileCommand(user.ID, "alicia", user.Email, "")
+		require.NoError(t, err)
+		require.NoError(t, users.UpdateProfile(cmd))
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.NoError(t, err, "refresh tokens outlive other changes")
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users := newTestAuthUseCases(t, time.Hour)
+		tokens := login(t, sut)
+
+		user, err := sut.AuthenticateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+
+		cmd, err := usecases.NewDeleteUserCommand(user.ID.String(), "")
+		require.NoError(t, err)
+		require.NoError(t, users.DeleteUser(cmd))
+
+		_, err = sut.Refresh(usecases.NewRefreshCommand(tokens.RefreshToken))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+
+		_, err = sut.AuthenticateAccessToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+}
+
+const (
+	testUsername = "alice"
+	testPassword = "password"
+)
+
+func newTestAuthUseCases(
+	t *testing.T,
+	refreshTokenTTL time.Duration,
+) (*usecases.AuthUseCases, *usecases.UserUseCases) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	users := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+
+	_, err = users.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, testUsername, "alice@example.com", testPassword, nil),
+	)
+	require.NoError(t, err)
+
+	return usecases.NewAuthUseCases(users, newTestAccessTokenIssuer(t), repo, refreshTokenTTL), users
+}
+
+func newTestAccessTokenIssuer(t *testing.T) *infrastructure.JWTIssuer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	issuer, err := infrastructure.NewJWTIssuer(infrastructure.JWTConfig{
+		Issuer:     "issuer",
+		Audience:   "audience",
+		TTL:        time.Minute,
+		PrivateKey: key,
+	})
+	require.NoError(t, err)
+
+	return issuer
+}
+
+func login(t *testing.T, sut *usecases.AuthUseCases) *usecases.Tokens {
+	t.Helper()
+
+	cmd := usecases.NewLoginCommand(models.DefaultOrganization, testUsername, testPassword)
+
+	tokens, err := sut.Login(context.Background(), cmd)
+	require.NoError(t, err)
+
+	return tokens
+}
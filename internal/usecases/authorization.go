@@ -0,0 +1,368 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidCheck = errors.New("invalid authorization check")
+
+const userResourcePrefix = "users/"
+
+// UserResource returns the name of the user as the resource of an authorization check.
+func UserResource(id string) string {
+	return userResourcePrefix + id
+}
+
+// Decision is the result of an authorization check. Reason explains it to humans and must not be parsed.
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+// AuthorizationUseCases decides whether users may perform actions. The gRPC handlers of this service
+// enforce the same decisions, so other services get the same answers by calling Check.
+type AuthorizationUseCases struct {
+	roles *RoleUseCases
+	users UserRepository
+}
+
+func NewAuthorizationUseCases(roles *RoleUseCases, users UserRepository) *AuthorizationUseCases {
+	return &AuthorizationUseCases{
+		roles: roles,
+		users: users,
+	}
+}
+
+type CheckQuery struct {
+	subjectID uuid.UUID
+	action    models.Permission
+	resource  string
+}
+
+// NewCheckQuery creates a check of whether the subject, a user ID, may perform the action, a permission,
+// on the resource, which is either empty for the service as a whole or "users/{id}".
+func NewCheckQuery(rawSubjectID string, action string, resource string) (*CheckQuery, error) {
+	subjectID, err := uuid.Parse(rawSubjectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject must be a user id: %w", ErrInvalidCheck, err)
+	}
+
+	if !slices.Contains(models.Permissions(), models.Permission(action)) {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidCheck, action)
+	}
+
+	if _, err := parseResource(resource); err != nil {
+		return nil, err
+	}
+
+	return &CheckQuery{
+		subjectID: subjectID,
+		action:    models.Permission(action),
+		resource:  resource,
+	}, nil
+}
+
+func (q *CheckQuery) SubjectID() uuid.UUID {
+	return q.subjectID
+}
+
+// Check denies unknown subjects instead of failing.
+func (a *AuthorizationUseCases) Check(query *CheckQuery) (*Decision, error) {
+	subject, err := a.users.GetByID(query.subjectID)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return &Decision{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Subject %q does not exist", query.subjectID),
+		}, nil
+	default:
+		return nil, fmt.Errorf("failed to get user by id %q: %w", query.subjectID, err)
+	}
+
+	return a.Authorize(subject, query.action, query.resource)
+}
+
+// BatchCheck returns the decisions in the order of the queries.
+func (a *AuthorizationUseCases) BatchCheck(queries []*CheckQuery) ([]*Decision, error) {
+	decisions := make([]*Decision, len(queries))
+
+	for i, query := range queries {
+		decision, err := a.Check(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %d: %w", i, err)
+		}
+
+		decisions[i] = decision
+	}
+
+	return decisions, nil
+}
+
+// Authorize decides whether the user may perform the action on the resource.
+// The action must be granted by one of the user's roles, and only superusers may act on the users
+// of other organizations. Changing or deleting another user requires every permission of that user,
+// and the superuser role if that user has it, so that the subject cannot take over more powerful users.
+func (a *AuthorizationUseCases) Authorize(
+	subject *models.User,
+	action models.Permission,
+	resource string,
+) (*Decision, error) {
+	targetID, err := parseResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	granting, err := a.roles.GrantingRoles(subject, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles granting %q: %w", action, err)
+	}
+
+	if len(granting) == 0 {
+		return &Decision{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Permission %q is not granted by any role of the subject", action),
+		}, nil
+	}
+
+	if targetID != nil {
+		reason, err := a.checkTarget(subject, action, *targetID)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			return &Decision{
+				Allowed: false,
+				Reason:  reason,
+			}, nil
+		}
+	}
+
+	return &Decision{
+		Allowed: true,
+		Reason:  fmt.Sprintf("Permission %q is granted by role %q", action, granting[0]),
+	}, nil
+}
+
+// AuthorizeSuperuser decides whether the user may act across organizations, such as managing organizations,
+// custom roles, which all organizations share, or the superuser role itself.
+func (a *AuthorizationUseCases) AuthorizeSuperuser(subject *models.User) (*Decision, error) {
+	superuser, err := a.roles.IsSuperuser(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if !superuser {
+		return &Decision{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Role %q is required", models.SuperuserRole),
+		}, nil
+	}
+
+	return &Decision{
+		Allowed: true,
+		Reason:  fmt.Sprintf("Subject has role %q", models.SuperuserRole),
+	}, nil
+}
+
+// AuthorizeRoleGrant decides whether the user may grant or revoke the roles, to users and groups alike.
+// The superuser role requires the superuser role, and other roles require every permission they grant,
+// so that nobody can escalate their own permissions. Roles that do not exist are not checked.
+func (a *AuthorizationUseCases) AuthorizeRoleGrant(subject *models.User, roles ...string) (*Decision, error) {
+	if slices.Contains(roles, models.SuperuserRole) {
+		return a.AuthorizeSuperuser(subject)
+	}
+
+	granted, err := a.roles.EffectivePermissions(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range roles {
+		role, err := a.roles.getRole(name)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidRole):
+			continue
+		default:
+			return nil, err
+		}
+
+		for _, permission := range role.Permissions {
+			if !slices.Contains(granted, permission) {
+				return &Decision{
+					Allowed: false,
+					Reason:  fmt.Sprintf("Role %q grants permission %q that the subject does not have", name, permission),
+				}, nil
+			}
+		}
+	}
+
+	return &Decision{
+		Allowed: true,
+		Reason:  "Subject has every permission of the roles",
+	}, nil
+}
+
+// AuthorizeOrganization decides whether the user may act on the resource at all, whatever the action:
+// only superusers may act on the users of other organizations. Authorize makes the same decision,
+// but unlike the permissions it checks, this one must hold even when a policy rule allows the call.
+func (a *AuthorizationUseCases) AuthorizeOrganization(subject *models.User, resource string) (*Decision, error) {
+	targetID, err := parseResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetID != nil {
+		target, err := a.getTarget(*targetID)
+		if err != nil {
+			return nil, err
+		}
+
+		if target != nil {
+			reason, err := a.checkOrganization(subject, target)
+			if err != nil {
+				return nil, err
+			}
+
+			if reason != "" {
+				return &Decision{
+					Allowed: false,
+					Reason:  reason,
+				}, nil
+			}
+		}
+	}
+
+	return &Decision{
+		Allowed: true,
+		Reason:  "Resource belongs to the organization of the subject",
+	}, nil
+}
+
+// checkTarget returns the reason the subject may not perform the action on the target user, or an empty string.
+// Missing targets are not checked.
+func (a *AuthorizationUseCases) checkTarget(
+	subject *models.User,
+	action models.Permission,
+	targetID uuid.UUID,
+) (string, error) {
+	target, err := a.getTarget(targetID)
+	if err != nil || target == nil {
+		return "", err
+	}
+
+	reason, err := a.checkOrganization(subject, target)
+	if err != nil || reason != "" {
+		return reason, err
+	}
+
+	superuser, err := a.roles.IsSuperuser(subject)
+	if err != nil {
+		return "", err
+	}
+
+	if action != models.PermissionUsersWrite && action != models.PermissionUsersDelete {
+		return "", nil
+	}
+
+	targetSuperuser, err := a.roles.IsSuperuser(target)
+	if err != nil {
+		return "", err
+	}
+
+	if targetSuperuser && !superuser {
+		return fmt.Sprintf("User %q has role %q that the subject does not have", targetID, models.SuperuserRole), nil
+	}
+
+	missing, err := a.missingPermissions(subject, target)
+	if err != nil {
+		return "", err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Sprintf("User %q has permission %q that the subject does not have", targetID, missing[0]), nil
+	}
+
+	return "", nil
+}
+
+// getTarget returns the target user, or nil if it does not exist.
+func (a *AuthorizationUseCases) getTarget(targetID uuid.UUID) (*models.User, error) {
+	target, err := a.users.GetByID(targetID)
+	switch {
+	case err == nil:
+		return target, nil
+	case errors.Is(err, common.ErrNotFound):
+		return nil, nil //nolint:nilnil
+	default:
+		return nil, fmt.Errorf("failed to get user by id %q: %w", targetID, err)
+	}
+}
+
+// checkOrganization returns the reason the subject may not act on the target user of another organization,
+// or an empty string.
+func (a *AuthorizationUseCases) checkOrganization(subject *models.User, target *models.User) (string, error) {
+	if target.Organization == subject.Organization {
+		return "", nil
+	}
+
+	superuser, err := a.roles.IsSuperuser(subject)
+	if err != nil {
+		return "", err
+	}
+
+	if !superuser {
+		return fmt.Sprintf("User %q belongs to another organization", target.ID), nil
+	}
+
+	return "", nil
+}
+
+// missingPermissions returns the permissions of the target that the subject does not have.
+func (a *AuthorizationUseCases) missingPermissions(
+	subject *models.User,
+	target *models.User,
+) ([]models.Permission, error) {
+	granted, err := a.roles.EffectivePermissions(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := a.roles.EffectivePermissions(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(required, func(permission models.Permission) bool {
+		return slices.Contains(granted, permission)
+	}), nil
+}
+
+// parseResource returns the ID of the user the resource names, or nil for an empty resource.
+func parseResource(resource string) (*uuid.UUID, error) {
+	if resource == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	rawID, ok := strings.CutPrefix(resource, userResourcePrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown resource %q, expected %q", ErrInvalidCheck, resource, userResourcePrefix+"{id}")
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resource %q has an invalid user id: %w", ErrInvalidCheck, resource, err)
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,313 @@
+package usecases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestAuthorizationUseCases_Check(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	roles := usecases.NewRoleUseCases(repo, repo, repo)
+	sut := usecases.NewAuthorizationUseCases(roles, repo)
+
+	createRole(t, roles, "support", models.PermissionUsersRead, models.PermissionUsersWrite)
+
+	support := saveUser(t, repo, "support", "support")
+	superuser := saveUser(t, repo, "root", models.SuperuserRole)
+	nobody := saveUser(t, repo, "nobody")
+	orgAdmin := saveUser(t, repo, "admin", models.OrgAdminRole)
+	foreigner := saveOrganizationUser(t, repo, "acme", "nobody")
+
+	testCases := []struct {
+		name     string
+		subject  uuid.UUID
+		action   models.Permission
+		resource string
+		allowed  bool
+		reason   string
+	}{
+		{
+			name:     "granted",
+			subject:  support.ID,
+			action:   models.PermissionUsersRead,
+			resource: usecases.UserResource(superuser.ID.String()),
+			allowed:  true,
+			reason:   `Permission "users.read" is granted by role "support"`,
+		},
+		{
+			name:     "not granted",
+			subject:  support.ID,
+			action:   models.PermissionUsersDelete,
+			resource: "",
+			allowed:  false,
+			reason:   `Permission "users.delete" is not granted by any role of the subject`,
+		},
+		{
+			name:     "more powerful target",
+			subject:  support.ID,
+			action:   models.PermissionUsersWrite,
+			resource: usecases.UserResource(superuser.ID.String()),
+			allowed:  false,
+			reason:   `User "` + superuser.ID.String() + `" has role "superuser" that the subject does not have`,
+		},
+		{
+			name:     "more permissions of target",
+			subject:  support.ID,
+			action:   models.PermissionUsersWrite,
+			resource: usecases.UserResource(orgAdmin.ID.String()),
+			allowed:  false,
+			reason:   `User "` + orgAdmin.ID.String() + `" has permission "users.delete" that the subject does not have`,
+		},
+		{
+			name:     "org admin on superuser",
+			subject:  orgAdmin.ID,
+			action:   models.PermissionUsersDelete,
+			resource: usecases.UserResource(superuser.ID.String()),
+			allowed:  false,
+			reason:   `User "` + superuser.ID.String() + `" has role "superuser" that the subject does not have`,
+		},
+		{
+			name:     "target of another organization",
+			subject:  orgAdmin.ID,
+			action:   models.PermissionUsersRead,
+			resource: usecases.UserResource(foreigner.ID.String()),
+			allowed:  false,
+			reason:   `User "` + foreigner.ID.String() + `" belongs to another organization`,
+		},
+		{
+			name:     "superuser on another organization",
+			subject:  superuser.ID,
+			action:   models.PermissionUsersDelete,
+			resource: usecases.UserResource(foreigner.ID.String()),
+			allowed:  true,
+			reason:   `Permission "users.delete" is granted by role "superuser"`,
+		},
+		{
+			name:     "less powerful target",
+			subject:  support.ID,
+			action:   models.PermissionUsersWrite,
+			resource: usecases.UserResource(nobody.ID.String()),
+			allowed:  true,
+			reason:   `Permission "users.write" is granted by role "support"`,
+		},
+		{
+			name:     "missing target",
+			subject:  support.ID,
+			action:   models.PermissionUsersWrite,
+			resource: usecases.UserResource(uuid.NewString()),
+			allowed:  true,
+			reason:   `Permission "users.write" is granted by role "support"`,
+		},
+		{
+			name:     "superuser",
+			subject:  superuser.ID,
+			action:   models.PermissionRolesManage,
+			resource: "",
+			allowed:  true,
+			reason:   `Permission "roles.manage" is granted by role "superuser"`,
+		},
+		{
+			name:     "unknown subject",
+			subject:  uuid.Nil,
+			action:   models.PermissionUsersRead,
+			resource: "",
+			allowed:  false,
+			reason:   `Subject "00000000-0000-0000-0000-000000000000" does not exist`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := usecases.NewCheckQuery(tc.subject.String(), string(tc.action), tc.resource)
+			require.NoError(t, err)
+
+			decision, err := sut.Check(query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, decision.Allowed)
+			assert.Equal(t, tc.reason, decision.Reason)
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		t.Parallel()
+
+		queries := make([]*usecases.CheckQuery, 0, len(models.Permissions()))
+		for _, permission := range models.Permissions() {
+			query, err := usecases.NewCheckQuery(support.ID.String(), string(permission), "")
+			require.NoError(t, err)
+
+			queries = append(queries, query)
+		}
+
+		decisions, err := sut.BatchCheck(queries)
+		require.NoError(t, err)
+		require.Len(t, decisions, 6)
+		assert.True(t, decisions[0].Allowed)
+		assert.True(t, decisions[1].Allowed)
+		for _, decision := range decisions[2:] {
+			assert.False(t, decision.Allowed)
+		}
+	})
+}
+
+func TestAuthorizationUseCases_AuthorizeRoleGrant(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	roles := usecases.NewRoleUseCases(repo, repo, repo)
+	sut := usecases.NewAuthorizationUseCases(roles, repo)
+
+	createRole(t, roles, "reader", models.PermissionUsersRead)
+	createRole(t, roles, "support", models.PermissionUsersRead, models.PermissionUsersWrite)
+
+	testCases := []struct {
+		name    string
+		subject *models.User
+		roles   []string
+		allowed bool
+		reason  string
+	}{
+		{
+			name:    "subset of permissions",
+			subject: saveUser(t, repo, "support", "support"),
+			roles:   []string{"reader", "support"},
+			allowed: true,
+			reason:  "Subject has every permission of the roles",
+		},
+		{
+			name:    "more permissions",
+			subject: saveUser(t, repo, "reader", "reader"),
+			roles:   []string{"support"},
+			allowed: false,
+			reason:  `Role "support" grants permission "users.write" that the subject does not have`,
+		},
+		{
+			name:    "superuser by org admin",
+			subject: saveUser(t, repo, "admin", models.OrgAdminRole),
+			roles:   []string{"reader", models.SuperuserRole},
+			allowed: false,
+			reason:  `Role "superuser" is required`,
+		},
+		{
+			name:    "superuser by superuser",
+			subject: saveUser(t, repo, "root", models.SuperuserRole),
+			roles:   []string{models.SuperuserRole},
+			allowed: true,
+			reason:  `Subject has role "superuser"`,
+		},
+		{
+			name:    "unknown role",
+			subject: saveUser(t, repo, "nobody"),
+			roles:   []string{"ghost"},
+			allowed: true,
+			reason:  "Subject has every permission of the roles",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			decision, err := sut.AuthorizeRoleGrant(tc.subject, tc.roles...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, decision.Allowed)
+			assert.Equal(t, tc.reason, decision.Reason)
+		})
+	}
+}
+
+func TestNewCheckQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		subject  string
+		action   string
+		resource string
+	}{
+		{
+			name:     "invalid subject",
+			subject:  "bob",
+			action:   "users.read",
+			resource: "",
+		},
+		{
+			name:     "unknown action",
+			subject:  uuid.NewString(),
+			action:   "users.impersonate",
+			resource: "",
+		},
+		{
+			name:     "unknown resource",
+			subject:  uuid.NewString(),
+			action:   "users.read",
+			resource: "roles/support",
+		},
+		{
+			name:     "invalid user resource",
+			subject:  uuid.NewString(),
+			action:   "users.read",
+			resource: "users/bob",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := usecases.NewCheckQuery(tc.subject, tc.action, tc.resource)
+			assert.ErrorIs(t, err, usecases.ErrInvalidCheck)
+		})
+	}
+}
+
+func saveUser(t *testing.T, repo *infrastructure.Repository, username string, roles ...string) *models.User {
+	t.Helper()
+
+	return saveOrganizationUser(t, repo, models.DefaultOrganization, username, roles...)
+}
+
+func saveOrganizationUser(
+	t *testing.T,
+	repo *infrastructure.Repository,
+	organization string,
+	username string,
+	roles ...string,
+) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		ID:                uuid.New(),
+		Organization:      organization,
+		Username:          username,
+		Email:             username + "@example.com",
+		PasswordHash:      nil,
+		Roles:             roles,
+		EmailVerification: nil,
+		PasswordReset:     nil,
+		CreatedAt:         time.Now().UTC(),
+		Version:           0,
+	}
+	require.NoError(t, repo.Save(user))
+
+	return user
+}
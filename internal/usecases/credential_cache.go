@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const credentialCacheSecretLength = 32
+
+// CredentialCache remembers successfully verified passwords for a short time, so that clients sending
+// their password on every call do not pay for hashing it every time. Passwords are not stored:
+// entries are keyed by an HMAC of the organization, username and password with a random per-process secret.
+// The least recently used entries are evicted once the cache is full.
+type CredentialCache struct {
+	secret     []byte
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[credentialKey]*list.Element
+	// recent holds the entries, most recently used first.
+	recent *list.List
+	// byUser holds the keys of the entries of every user.
+	byUser map[uuid.UUID]map[credentialKey]struct{}
+}
+
+type credentialKey [sha256.Size]byte
+
+type credentialEntry struct {
+	key       credentialKey
+	userID    uuid.UUID
+	version   uint64
+	expiresAt time.Time
+}
+
+// NewCredentialCache remembers up to maxEntries verified passwords for ttl each.
+func NewCredentialCache(ttl time.Duration, maxEntries int) (*CredentialCache, error) {
+	secret := make([]byte, credentialCacheSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate credential cache secret: %w", err)
+	}
+
+	return &CredentialCache{
+		secret:     secret,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		mu:         sync.Mutex{},
+		entries:    make(map[credentialKey]*list.Element),
+		recent:     list.New(),
+		byUser:     make(map[uuid.UUID]map[credentialKey]struct{}),
+	}, nil
+}
+
+// key returns the HMAC of the credentials. The fields are prefixed with their lengths,
+// so that different credentials never have the same input.
+func (c *CredentialCache) key(organization string, username string, password string) credentialKey {
+	mac := hmac.New(sha256.New, c.secret)
+	for _, field := range []string{organization, username, password} {
+		_, _ = mac.Write(binary.AppendUvarint(nil, uint64(len(field))))
+		_, _ = mac.Write([]byte(field))
+	}
+
+	var key credentialKey
+	copy(key[:], mac.Sum(nil))
+
+	return key
+}
+
+// get returns the user and the version of the user whose password has been verified with the key,
+// unless the entry has expired.
+func (c *CredentialCache) get(key credentialKey) (uuid.UUID, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return uuid.UUID{}, 0, false
+	}
+
+	entry := element.Value.(*credentialEntry) //nolint:forcetypeassert
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeLocked(element)
+
+		return uuid.UUID{}, 0, false
+	}
+
+	c.recent.MoveToFront(element)
+
+	return entry.userID, entry.version, true
+}
+
+// put remembers that the password of the key has been verified for the version of the user.
+func (c *CredentialCache) put(key credentialKey, userID uuid.UUID, version uint64) {
+	if c.maxEntries < 1 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+
+	if c.recent.Len() >= c.maxEntries {
+		c.removeLocked(c.recent.Back())
+	}
+
+	c.entries[key] = c.recent.PushFront(&credentialEntry{
+		key:       key,
+		userID:    userID,
+		version:   version,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+
+	keys, ok := c.byUser[userID]
+	if !ok {
+		keys = make(map[credentialKey]struct{})
+		c.byUser[userID] = keys
+	}
+
+	keys[key] = struct{}{}
+}
+
+func (c *CredentialCache) remove(key credentialKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+}
+
+// invalidateUser forgets every verified password of the user.
+func (c *CredentialCache) invalidateUser(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.byUser[userID] {
+		c.removeLocked(c.entries[key])
+	}
+}
+
+func (c *CredentialCache) removeLocked(element *list.Element) {
+	entry := element.Value.(*credentialEntry) //nolint:forcetypeassert
+
+	c.recent.Remove(element)
+	delete(c.entries, entry.key)
+
+	keys := c.byUser[entry.userID]
+	delete(keys, entry.key)
+
+	if len(keys) == 0 {
+		delete(c.byUser, entry.userID)
+	}
+}
@@ -0,0 +1,228 @@
+package usecases_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestCredentialCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("verified passwords", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, hasher := newCachingUserUseCases(t, time.Minute, 10)
+		createUser(t, sut, "alice")
+
+		authenticate(t, sut, "alice", "password")
+		authenticate(t, sut, "alice", "password")
+		assert.EqualValues(t, 1, hasher.verifications.Load(), "the verified password is cached")
+
+		_, err := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+		assert.EqualValues(t, 3, hasher.verifications.Load(), "wrong passwords are not cached")
+
+		_, err = sut.AuthenticateUser(context.Background(), "acme", "alice", "password")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials, "the organization is part of the credentials")
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, hasher := newCachingUserUseCases(t, time.Minute, 10)
+		aliceID := createUser(t, sut, "alice")
+
+		authenticate(t, sut, "alice", "password")
+
+		cmd, err := usecases.NewUpdateUserCommand(aliceID.String(), "alice", "alice@corp.com", "new password", "")
+		require.NoError(t, err)
+		require.NoError(t, sut.UpdateUser(context.Background(), cmd))
+
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials, "the old password is forgotten")
+
+		authenticate(t, sut, "alice", "new password")
+		authenticate(t, sut, "alice", "new password")
+		assert.EqualValues(t, 3, hasher.verifications.Load())
+
+		deleteCmd, err := usecases.NewDeleteUserCommand(aliceID.String(), "")
+		require.NoError(t, err)
+		require.NoError(t, sut.DeleteUser(deleteCmd))
+
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "new password")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials, "deleted users are forgotten")
+	})
+
+	t.Run("other changes of the user", func(t *testing.T) {
+		t.Parallel()
+
+		sut, repo, hasher := newCachingUserUseCases(t, time.Minute, 10)
+		aliceID := createUser(t, sut, "alice")
+
+		authenticate(t, sut, "alice", "password")
+
+		stored, err := repo.GetByID(aliceID)
+		require.NoError(t, err)
+
+		user := *stored
+		user.Roles = []string{models.SuperuserRole}
+		require.NoError(t, repo.Save(&user))
+
+		authenticated := authenticate(t, sut, "alice", "password")
+		assert.Equal(t, []string{models.SuperuserRole}, authenticated.Roles)
+		assert.EqualValues(t, 2, hasher.verifications.Load(), "a changed user is verified again")
+	})
+
+	t.Run("saturated password pool", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := infrastructure.NewRepository()
+		require.NoError(t, err)
+
+		credentials, err := usecases.NewCredentialCache(time.Minute, 10)
+		require.NoError(t, err)
+
+		hasher := &blockingPasswordHasher{
+			PasswordHasher: newTestPasswordHasher(t),
+			started:        make(chan struct{}, 1),
+			release:        make(chan struct{}),
+		}
+		pool := usecases.NewPasswordPool(hasher, 1, 0)
+		sut := usecases.NewUserUseCases(repo, repo, usecases.NewPageTokenCodec([]byte("secret")), pool, credentials, nil)
+
+		createUser(t, usecases.NewUserUseCases(
+			repo,
+			repo,
+			usecases.NewPageTokenCodec([]byte("secret")),
+			newTestPasswordHasher(t),
+			nil,
+			nil,
+		), "alice")
+
+		authenticate(t, sut, "alice", "password")
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			createUser(t, sut, "bob")
+		}()
+
+		<-hasher.started
+
+		authenticate(t, sut, "alice", "password")
+
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+		assert.ErrorIs(t, err, usecases.ErrPasswordPoolSaturated, "only cached passwords skip the pool")
+
+		close(hasher.release)
+		<-done
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, hasher := newCachingUserUseCases(t, 50*time.Millisecond, 10)
+		createUser(t, sut, "alice")
+
+		authenticate(t, sut, "alice", "password")
+		time.Sleep(60 * time.Millisecond)
+		authenticate(t, sut, "alice", "password")
+		assert.EqualValues(t, 2, hasher.verifications.Load())
+	})
+
+	t.Run("least recently used eviction", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, hasher := newCachingUserUseCases(t, time.Minute, 2)
+		for _, username := range []string{"alice", "bob", "carol"} {
+			createUser(t, sut, username)
+		}
+
+		authenticate(t, sut, "alice", "password")
+		authenticate(t, sut, "bob", "password")
+		authenticate(t, sut, "alice", "password")
+		authenticate(t, sut, "carol", "password")
+		require.EqualValues(t, 3, hasher.verifications.Load())
+
+		authenticate(t, sut, "alice", "password")
+		assert.EqualValues(t, 3, hasher.verifications.Load(), "the recently used entry is kept")
+
+		authenticate(t, sut, "bob", "password")
+		assert.EqualValues(t, 4, hasher.verifications.Load(), "the least recently used entry is evicted")
+	})
+}
+
+// countingPasswordHasher counts the hashed and the verified passwords, and remembers the last verified hash.
+type countingPasswordHasher struct {
+	usecases.PasswordHasher
+
+	hashes           atomic.Int64
+	verifications    atomic.Int64
+	lastVerifiedHash atomic.Pointer[[]byte]
+}
+
+func (h *countingPasswordHasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	h.hashes.Add(1)
+
+	return h.PasswordHasher.Hash(ctx, password) //nolint:wrapcheck
+}
+
+func (h *countingPasswordHasher) Verify(ctx context.Context, hash []byte, password string) (bool, error) {
+	h.verifications.Add(1)
+	h.lastVerifiedHash.Store(&hash)
+
+	return h.PasswordHasher.Verify(ctx, hash, password) //nolint:wrapcheck
+}
+
+func newCachingUserUseCases(
+	t *testing.T,
+	ttl time.Duration,
+	maxEntries int,
+) (*usecases.UserUseCases, *infrastructure.Repository, *countingPasswordHasher) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	credentials, err := usecases.NewCredentialCache(ttl, maxEntries)
+	require.NoError(t, err)
+
+	hasher := &countingPasswordHasher{
+		PasswordHasher:   newTestPasswordHasher(t),
+		hashes:           atomic.Int64{},
+		verifications:    atomic.Int64{},
+		lastVerifiedHash: atomic.Pointer[[]byte]{},
+	}
+
+	return usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		hasher,
+		credentials,
+		nil,
+	), repo, hasher
+}
+
+func authenticate(t *testing.T, sut *usecases.UserUseCases, username string, password string) *models.User {
+	t.Helper()
+
+	user, err := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, username, password)
+	require.NoError(t, err)
+
+	return user
+}
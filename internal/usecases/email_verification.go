@@ -0,0 +1,56 @@
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// EmailVerifications mails the tokens that prove that users own their emails,
+// both of registered users and of users whose email has changed.
+type EmailVerifications struct {
+	mail MailSender
+	ttl  time.Duration
+}
+
+// NewEmailVerifications mails tokens that expire after ttl.
+func NewEmailVerifications(mail MailSender, ttl time.Duration) *EmailVerifications {
+	return &EmailVerifications{
+		mail: mail,
+		ttl:  ttl,
+	}
+}
+
+// newVerification returns a token of the user and the pending verification it proves.
+func (v *EmailVerifications) newVerification(userID uuid.UUID) (string, *models.EmailVerification, error) {
+	token, hash, err := newUserToken(userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	return token, &models.EmailVerification{
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(v.ttl),
+	}, nil
+}
+
+// send mails the token of the pending verification to the email of the user.
+func (v *EmailVerifications) send(user *models.User, token string) error {
+	mail := &models.Mail{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nverify your email with the token %s\nIt expires at %s.\n",
+			user.Username, token, user.EmailVerification.ExpiresAt.Format(time.RFC3339),
+		),
+	}
+
+	if err := v.mail.Send(mail); err != nil {
+		return fmt.Errorf("failed to send verification mail: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrInvalidETag = errors.New("invalid etag")
+
+// FormatETag returns the opaque entity tag of the given version of a user.
+func FormatETag(version uint64) string {
+	return strconv.FormatUint(version, 10)
+}
+
+// ParseETag returns the version encoded in the entity tag. An empty tag means any version and is parsed as zero.
+func ParseETag(etag string) (uint64, error) {
+	if etag == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseUint(etag, 10, 64)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidETag, etag)
+	}
+
+	return version, nil
+}
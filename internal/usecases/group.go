@@ -0,0 +1,386 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var (
+	ErrInvalidGroup = errors.New("invalid group")
+	// ErrGroupCycle means that a group would become a member of itself through nested groups.
+	ErrGroupCycle = errors.New("group membership cycle")
+)
+
+type GroupUseCases struct {
+	groups GroupRepository
+	users  UserRepository
+	roles  *RoleUseCases
+}
+
+func NewGroupUseCases(groups GroupRepository, users UserRepository, roles *RoleUseCases) *GroupUseCases {
+	return &GroupUseCases{
+		groups: groups,
+		users:  users,
+		roles:  roles,
+	}
+}
+
+type CreateGroupCommand struct {
+	organization string
+	name         string
+	roles        []string
+}
+
+// NewCreateGroupCommand creates a group in the organization. It accepts the same names as roles.
+func NewCreateGroupCommand(organization string, name string, roles []string) (*CreateGroupCommand, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, fmt.Errorf(
+			"%w: name must start with a lowercase letter and consist of lowercase letters, digits, '_', '.' or '-'",
+			ErrInvalidGroup,
+		)
+	}
+
+	unique := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if !slices.Contains(unique, role) {
+			unique = append(unique, role)
+		}
+	}
+
+	return &CreateGroupCommand{
+		organization: organization,
+		name:         name,
+		roles:        unique,
+	}, nil
+}
+
+// CreateGroup fails with ErrInvalidRole if a role does not exist,
+// and with common.ErrAlreadyExists if a group with the same name exists in the organization.
+func (g *GroupUseCases) CreateGroup(cmd *CreateGroupCommand) (*models.Group, error) {
+	for _, role := range cmd.roles {
+		if _, err := g.roles.getRole(role); err != nil {
+			return nil, err
+		}
+	}
+
+	group := &models.Group{
+		ID:           uuid.New(),
+		Organization: cmd.organization,
+		Name:         cmd.name,
+		Roles:        cmd.roles,
+		UserIDs:      nil,
+		GroupIDs:     nil,
+		CreatedAt:    time.Now().UTC(),
+		Version:      0,
+	}
+
+	if err := g.groups.SaveGroup(group); err != nil {
+		return nil, fmt.Errorf("failed to save group: %w", err)
+	}
+
+	return group, nil
+}
+
+type GetGroupQuery struct {
+	organization string
+	id           uuid.UUID
+}
+
+// NewGetGroupQuery gets the group with the ID if it belongs to the organization.
+func NewGetGroupQuery(organization string, rawID string) (*GetGroupQuery, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse group id: %w", err)
+	}
+
+	return &GetGroupQuery{
+		organization: organization,
+		id:           id,
+	}, nil
+}
+
+func (g *GroupUseCases) GetGroup(query *GetGroupQuery) (*models.Group, error) {
+	return g.getGroup(query.organization, query.id)
+}
+
+// GrantedRoles returns the roles the members of the group get: the roles of the group
+// followed by the roles of the groups it is nested in, in the order of RoleUseCases.EffectiveRoles.
+func (g *GroupUseCases) GrantedRoles(query *GetGroupQuery) ([]string, error) {
+	group, err := g.getGroup(query.organization, query.id)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.roles.effectiveRoles(group.Roles, group.ID)
+}
+
+// ListGroups returns the groups of the organization ordered by name.
+func (g *GroupUseCases) ListGroups(organization string) ([]*models.Group, error) {
+	groups, err := g.groups.ListGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	return slices.DeleteFunc(groups, func(group *models.Group) bool {
+		return group.Organization != organization
+	}), nil
+}
+
+type DeleteGroupCommand struct {
+	organization string
+	id           uuid.UUID
+}
+
+func NewDeleteGroupCommand(organization string, rawID string) (*DeleteGroupCommand, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse group id: %w", err)
+	}
+
+	return &DeleteGroupCommand{
+		organization: organization,
+		id:           id,
+	}, nil
+}
+
+// DeleteGroup also removes the group from the groups it is nested in.
+func (g *GroupUseCases) DeleteGroup(cmd *DeleteGroupCommand) error {
+	if _, err := g.getGroup(cmd.organization, cmd.id); err != nil {
+		return err
+	}
+
+	if err := g.groups.DeleteGroup(cmd.id); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+// GroupMemberCommand names a member of a group, either a user or a nested group.
+type GroupMemberCommand struct {
+	organization  string
+	groupID       uuid.UUID
+	userID        *uuid.UUID
+	memberGroupID *uuid.UUID
+}
+
+// NewGroupMemberCommand requires exactly one of the user ID and the member group ID.
+// The group and the member must belong to the organization.
+func NewGroupMemberCommand(
+	organization string,
+	rawGroupID string,
+	rawUserID string,
+	rawMemberGroupID string,
+) (*GroupMemberCommand, error) {
+	groupID, err := uuid.Parse(rawGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse group id: %w", err)
+	}
+
+	cmd := &GroupMemberCommand{
+		organization:  organization,
+		groupID:       groupID,
+		userID:        nil,
+		memberGroupID: nil,
+	}
+
+	switch {
+	case rawUserID != "" && rawMemberGroupID == "":
+		userID, err := uuid.Parse(rawUserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse user id: %w", err)
+		}
+
+		cmd.userID = &userID
+	case rawUserID == "" && rawMemberGroupID != "":
+		memberGroupID, err := uuid.Parse(rawMemberGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse member group id: %w", err)
+		}
+
+		cmd.memberGroupID = &memberGroupID
+	default:
+		return nil, fmt.Errorf("%w: exactly one of user id and member group id is required", ErrInvalidGroup)
+	}
+
+	return cmd, nil
+}
+
+// AddGroupMember is a no-op if the member is already a direct member of the group.
+// It fails with common.ErrNotFound if the group or the member does not exist in the organization,
+// and with ErrGroupCycle if the group is nested in the member group. Concurrent additions may still close a cycle,
+// which RoleUseCases.EffectiveRoles tolerates.
+func (g *GroupUseCases) AddGroupMember(cmd *GroupMemberCommand) error {
+	if cmd.userID != nil {
+		user, err := g.users.GetByID(*cmd.userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user by id %q: %w", *cmd.userID, err)
+		}
+
+		if user.Organization != cmd.organization {
+			return fmt.Errorf(
+				"%w: user %q does not belong to organization %q", common.ErrNotFound, user.ID, cmd.organization,
+			)
+		}
+
+		return g.updateGroup(cmd.organization, cmd.groupID, func(group *models.Group) {
+			if !slices.Contains(group.UserIDs, *cmd.userID) {
+				group.UserIDs = append(group.UserIDs, *cmd.userID)
+			}
+		})
+	}
+
+	if _, err := g.getGroup(cmd.organization, *cmd.memberGroupID); err != nil {
+		return err
+	}
+
+	nested, err := g.isNestedIn(cmd.groupID, *cmd.memberGroupID)
+	if err != nil {
+		return err
+	}
+
+	if nested {
+		return fmt.Errorf(
+			"%w: group %q cannot be nested in group %q, which it contains",
+			ErrGroupCycle, *cmd.memberGroupID, cmd.groupID,
+		)
+	}
+
+	return g.updateGroup(cmd.organization, cmd.groupID, func(group *models.Group) {
+		if !slices.Contains(group.GroupIDs, *cmd.memberGroupID) {
+			group.GroupIDs = append(group.GroupIDs, *cmd.memberGroupID)
+		}
+	})
+}
+
+// RemoveGroupMember is a no-op if the member is not a direct member of the group.
+func (g *GroupUseCases) RemoveGroupMember(cmd *GroupMemberCommand) error {
+	return g.updateGroup(cmd.organization, cmd.groupID, func(group *models.Group) {
+		if cmd.userID != nil {
+			group.UserIDs = slices.DeleteFunc(group.UserIDs, func(id uuid.UUID) bool { return id == *cmd.userID })
+		} else {
+			group.GroupIDs = slices.DeleteFunc(group.GroupIDs, func(id uuid.UUID) bool { return id == *cmd.memberGroupID })
+		}
+	})
+}
+
+type GroupRoleCommand struct {
+	organization string
+	groupID      uuid.UUID
+	role         string
+}
+
+func NewGroupRoleCommand(organization string, rawGroupID string, role string) (*GroupRoleCommand, error) {
+	groupID, err := uuid.Parse(rawGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse group id: %w", err)
+	}
+
+	return &GroupRoleCommand{
+		organization: organization,
+		groupID:      groupID,
+		role:         role,
+	}, nil
+}
+
+// AssignGroupRole is a no-op if the group already has the role.
+// It fails with ErrInvalidRole if the role does not exist.
+func (g *GroupUseCases) AssignGroupRole(cmd *GroupRoleCommand) error {
+	if _, err := g.roles.getRole(cmd.role); err != nil {
+		return err
+	}
+
+	return g.updateGroup(cmd.organization, cmd.groupID, func(group *models.Group) {
+		if !slices.Contains(group.Roles, cmd.role) {
+			group.Roles = append(group.Roles, cmd.role)
+		}
+	})
+}
+
+// UnassignGroupRole is a no-op if the group does not have the role.
+func (g *GroupUseCases) UnassignGroupRole(cmd *GroupRoleCommand) error {
+	return g.updateGroup(cmd.organization, cmd.groupID, func(group *models.Group) {
+		group.Roles = slices.DeleteFunc(group.Roles, func(role string) bool { return role == cmd.role })
+	})
+}
+
+// isNestedIn reports whether the group is the ancestor group or is nested in it, directly or transitively.
+func (g *GroupUseCases) isNestedIn(groupID uuid.UUID, ancestorID uuid.UUID) (bool, error) {
+	visited := make(map[uuid.UUID]struct{})
+	pending := []uuid.UUID{groupID}
+
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		if id == ancestorID {
+			return true, nil
+		}
+
+		if _, ok := visited[id]; ok {
+			continue
+		}
+
+		visited[id] = struct{}{}
+
+		parents, err := g.groups.GroupsOfMember(id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get groups of member %q: %w", id, err)
+		}
+
+		for _, parent := range parents {
+			pending = append(pending, parent.ID)
+		}
+	}
+
+	return false, nil
+}
+
+// getGroup fails with common.ErrNotFound if the group does not exist in the organization.
+func (g *GroupUseCases) getGroup(organization string, id uuid.UUID) (*models.Group, error) {
+	group, err := g.groups.GetGroup(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group by id %q: %w", id, err)
+	}
+
+	if group.Organization != organization {
+		return nil, fmt.Errorf("%w: group %q does not belong to organization %q", common.ErrNotFound, id, organization)
+	}
+
+	return group, nil
+}
+
+// updateGroup saves a copy of the group of the organization changed by update.
+// It fails with common.ErrConflict if the group is modified concurrently.
+func (g *GroupUseCases) updateGroup(organization string, id uuid.UUID, update func(group *models.Group)) error {
+	existing, err := g.getGroup(organization, id)
+	if err != nil {
+		return err
+	}
+
+	group := *existing
+	group.Roles = slices.Clone(existing.Roles)
+	group.UserIDs = slices.Clone(existing.UserIDs)
+	group.GroupIDs = slices.Clone(existing.GroupIDs)
+
+	update(&group)
+
+	if slices.Equal(group.Roles, existing.Roles) &&
+		slices.Equal(group.UserIDs, existing.UserIDs) &&
+		slices.Equal(group.GroupIDs, existing.GroupIDs) {
+		return nil
+	}
+
+	if err := g.groups.SaveGroup(&group); err != nil {
+		return fmt.Errorf("failed to save group: %w", err)
+	}
+
+	return nil
+}
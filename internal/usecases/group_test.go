@@ -0,0 +1,146 @@
+package usecases_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure/repositorytest"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestGroupUseCases_TransitiveRoles(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	roles := usecases.NewRoleUseCases(repo, repo, repo)
+	authorization := usecases.NewAuthorizationUseCases(roles, repo)
+	sut := usecases.NewGroupUseCases(repo, repo, roles)
+
+	createRole(t, roles, "support", models.PermissionUsersRead)
+	createRole(t, roles, "auditor", models.PermissionTuplesRead)
+
+	user := saveUser(t, repo, "bob")
+	support := createGroup(t, sut, "support-team", "support")
+	eng := createGroup(t, sut, "eng", "auditor")
+	createGroup(t, sut, "unrelated", models.SuperuserRole)
+
+	// bob is in eng, which is nested in support-team.
+	addMember(t, sut, eng.ID, user.ID.String(), "")
+	addMember(t, sut, support.ID, "", eng.ID.String())
+
+	effective, err := roles.EffectiveRoles(user)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auditor", "support"}, effective)
+
+	decision, err := authorization.Authorize(user, models.PermissionUsersRead, "")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, `Permission "users.read" is granted by role "support"`, decision.Reason)
+
+	decision, err = authorization.Authorize(user, models.PermissionUsersDelete, "")
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, err := usecases.NewGroupMemberCommand(models.DefaultOrganization, eng.ID.String(), "", support.ID.String())
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.AddGroupMember(cmd), usecases.ErrGroupCycle)
+
+		cmd, err = usecases.NewGroupMemberCommand(models.DefaultOrganization, eng.ID.String(), "", eng.ID.String())
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.AddGroupMember(cmd), usecases.ErrGroupCycle)
+	})
+
+	t.Run("unknown members are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, err := usecases.NewGroupMemberCommand(models.DefaultOrganization, eng.ID.String(), uuid.NewString(), "")
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.AddGroupMember(cmd), common.ErrNotFound)
+	})
+
+	t.Run("other organizations are isolated", func(t *testing.T) {
+		t.Parallel()
+
+		foreigner := saveOrganizationUser(t, repo, "acme", "bob")
+
+		cmd, err := usecases.NewGroupMemberCommand(models.DefaultOrganization, eng.ID.String(), foreigner.ID.String(), "")
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.AddGroupMember(cmd), common.ErrNotFound)
+
+		cmd, err = usecases.NewGroupMemberCommand("acme", eng.ID.String(), foreigner.ID.String(), "")
+		require.NoError(t, err)
+		assert.ErrorIs(t, sut.AddGroupMember(cmd), common.ErrNotFound)
+
+		groups, err := sut.ListGroups("acme")
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+	})
+}
+
+func TestRoleUseCases_EffectiveRolesWithCycle(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	user := saveUser(t, repo, "bob", "support")
+
+	// Concurrent additions can close a cycle that AddGroupMember would reject.
+	first := repositorytest.NewGroup("first", "auditor")
+	second := repositorytest.NewGroup("second", "support", models.SuperuserRole)
+	first.UserIDs = []uuid.UUID{user.ID}
+	first.GroupIDs = []uuid.UUID{second.ID}
+	second.GroupIDs = []uuid.UUID{first.ID}
+	require.NoError(t, repo.SaveGroup(first))
+	require.NoError(t, repo.SaveGroup(second))
+
+	roles, err := usecases.NewRoleUseCases(repo, repo, repo).EffectiveRoles(user)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"support", "auditor", models.SuperuserRole}, roles)
+}
+
+func TestNewGroupMemberCommand(t *testing.T) {
+	t.Parallel()
+
+	groupID := uuid.NewString()
+
+	_, err := usecases.NewGroupMemberCommand(models.DefaultOrganization, groupID, "", "")
+	assert.ErrorIs(t, err, usecases.ErrInvalidGroup)
+
+	_, err = usecases.NewGroupMemberCommand(models.DefaultOrganization, groupID, uuid.NewString(), uuid.NewString())
+	assert.ErrorIs(t, err, usecases.ErrInvalidGroup)
+
+	_, err = usecases.NewCreateGroupCommand(models.DefaultOrganization, "Eng Team", nil)
+	assert.ErrorIs(t, err, usecases.ErrInvalidGroup)
+}
+
+func createGroup(t *testing.T, sut *usecases.GroupUseCases, name string, roles ...string) *models.Group {
+	t.Helper()
+
+	cmd, err := usecases.NewCreateGroupCommand(models.DefaultOrganization, name, roles)
+	require.NoError(t, err)
+
+	group, err := sut.CreateGroup(cmd)
+	require.NoError(t, err)
+
+	return group
+}
+
+func addMember(t *testing.T, sut *usecases.GroupUseCases, groupID uuid.UUID, userID string, memberGroupID string) {
+	t.Helper()
+
+	cmd, err := usecases.NewGroupMemberCommand(models.DefaultOrganization, groupID.String(), userID, memberGroupID)
+	require.NoError(t, err)
+	require.NoError(t, sut.AddGroupMember(cmd))
+}
@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/filter"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+var (
+	ErrInvalidOrderBy = errors.New("invalid order_by")
+	ErrInvalidFilter  = errors.New("invalid filter")
+)
+
+type ListUsersQuery struct {
+	organization string
+	pageSize     int
+	pageToken    string
+	orderBy      string
+	orderField   models.UserOrderField
+	descending   bool
+	filter       string
+	match        filter.Expr
+}
+
+// NewListUsersQuery lists the users of the organization.
+func NewListUsersQuery(
+	organization string,
+	pageSize int,
+	pageToken string,
+	orderBy string,
+	rawFilter string,
+) (*ListUsersQuery, error) {
+	switch {
+	case pageSize <= 0:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+
+	orderField, descending, err := parseOrderBy(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := filter.Parse(rawFilter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	for _, field := range match.Fields() {
+		if _, ok := userFilterFields[field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, field)
+		}
+	}
+
+	return &ListUsersQuery{
+		organization: organization,
+		pageSize:     pageSize,
+		pageToken:    pageToken,
+		orderBy:      orderBy,
+		orderField:   orderField,
+		descending:   descending,
+		filter:       rawFilter,
+		match:        match,
+	}, nil
+}
+
+// parseOrderBy parses an AIP-132 order_by with a single field and an optional "asc" or "desc" suffix.
+func parseOrderBy(orderBy string) (models.UserOrderField, bool, error) {
+	parts := strings.Fields(orderBy)
+
+	switch len(parts) {
+	case 0:
+		return models.UserOrderByUsername, false, nil
+	case 1, 2: //nolint:gomnd
+	default:
+		return "", false, fmt.Errorf("%w: ordering by several fields is not supported", ErrInvalidOrderBy)
+	}
+
+	field := models.UserOrderField(parts[0])
+	switch field {
+	case models.UserOrderByUsername, models.UserOrderByEmail, models.UserOrderByCreateTime:
+	default:
+		return "", false, fmt.Errorf("%w: unknown field %q", ErrInvalidOrderBy, parts[0])
+	}
+
+	if len(parts) == 1 || parts[1] == "asc" {
+		return field, false, nil
+	}
+
+	if parts[1] == "desc" {
+		return field, true, nil
+	}
+
+	return "", false, fmt.Errorf("%w: unknown direction %q", ErrInvalidOrderBy, parts[1])
+}
+
+//nolint:gochecknoglobals
+var userFilterFields = map[string]func(user *models.User) any{
+	"id":          func(user *models.User) any { return user.ID.String() },
+	"username":    func(user *models.User) any { return user.Username },
+	"email":       func(user *models.User) any { return user.Email },
+	"admin":       func(user *models.User) any { return slices.Contains(user.Roles, models.SuperuserRole) },
+	"create_time": func(user *models.User) any { return user.CreatedAt },
+}
+
+type userFilterEnv struct {
+	user *models.User
+}
+
+func (e userFilterEnv) Lookup(field string) (any, bool) {
+	getter, ok := userFilterFields[field]
+	if !ok {
+		return nil, false
+	}
+
+	return getter(e.user), true
+}
+
+type UsersPage struct {
+	Users         []*models.User
+	NextPageToken string
+}
+
+func (u *UserUseCases) ListUsers(query *ListUsersQuery) (*UsersPage, error) {
+	listQuery := &models.UserListQuery{
+		Organization: query.organization,
+		OrderBy:      query.orderField,
+		Descending:   query.descending,
+		After:        nil,
+		Limit:        query.pageSize,
+		Match: func(user *models.User) (bool, error) {
+			return query.match.Eval(userFilterEnv{user: user})
+		},
+	}
+
+	if query.pageToken != "" {
+		position, err := u.pageTokens.Decode(query.orderBy, query.filter, query.pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		listQuery.After = position
+	}
+
+	page, err := u.repo.List(listQuery)
+	switch {
+	case err == nil:
+	case errors.Is(err, filter.ErrInvalid):
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	default:
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	result := &UsersPage{
+		Users:         page.Users,
+		NextPageToken: "",
+	}
+
+	if page.Next != nil {
+		result.NextPageToken, err = u.pageTokens.Encode(query.orderBy, query.filter, page.Next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,326 @@
+package usecases
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var (
+	// ErrLockedOut means that the username or the IP address of a password attempt has failed too many times recently.
+	ErrLockedOut      = errors.New("locked out")
+	ErrInvalidLockout = errors.New("invalid lockout")
+)
+
+// maxTrackedLockouts is the number of usernames and addresses above which forgotten failures are pruned.
+const maxTrackedLockouts = 100_000
+
+// pendingAttemptsRetryAfter is how long to wait for attempts in progress, which take as long as a password check.
+const pendingAttemptsRetryAfter = time.Second
+
+type LockoutConfig struct {
+	// MaxUsernameFailures consecutive failed attempts lock a username out. Zero disables lockouts of usernames.
+	MaxUsernameFailures int
+	// MaxIPFailures consecutive failed attempts lock an IP address out. Zero disables lockouts of addresses.
+	MaxIPFailures int
+	// BaseDuration is the first lockout, and every further failure doubles it up to MaxDuration.
+	BaseDuration time.Duration
+	// MaxDuration also is how long the failures are remembered after the last one once the lockout is over.
+	MaxDuration time.Duration
+}
+
+// LockoutUseCases slows down password guessing by locking out usernames and IP addresses after consecutive
+// failed attempts, with exponential backoff. The failures are kept in memory, so they do not survive a restart.
+type LockoutUseCases struct {
+	config LockoutConfig
+
+	mu       sync.Mutex
+	lockouts map[lockoutKey]*models.Lockout
+	// pending counts the attempts that have passed CheckAttempt and are not settled yet.
+	pending map[lockoutKey]int
+}
+
+type lockoutKey struct {
+	kind         models.LockoutKind
+	organization string
+	subject      string
+}
+
+func NewLockoutUseCases(config LockoutConfig) *LockoutUseCases {
+	return &LockoutUseCases{
+		config:   config,
+		mu:       sync.Mutex{},
+		lockouts: make(map[lockoutKey]*models.Lockout),
+		pending:  make(map[lockoutKey]int),
+	}
+}
+
+type PasswordAttempt struct {
+	organization string
+	username     string
+	address      string
+}
+
+// NewPasswordAttempt describes an attempt to authenticate the username in the organization with a password
+// from the IP address, which is empty if unknown.
+func NewPasswordAttempt(organization string, username string, address string) *PasswordAttempt {
+	return &PasswordAttempt{
+		organization: organization,
+		username:     username,
+		address:      address,
+	}
+}
+
+// CheckAttempt fails with ErrLockedOut if the username or the address of the attempt is locked out,
+// and returns how long until the attempt may be retried. Otherwise the attempt is reserved, counting
+// against the limits until it is settled by RecordFailure, RecordSuccess or ReleaseAttempt,
+// so that concurrent attempts cannot exceed the limits.
+func (l *LockoutUseCases) CheckAttempt(attempt *PasswordAttempt) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	keys := l.keys(attempt)
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		failures := 0
+		if lockout, ok := l.lockouts[key]; ok {
+			if l.forgotten(lockout, now) {
+				delete(l.lockouts, key)
+			} else {
+				failures = lockout.Failures
+				retryAfter = max(retryAfter, lockout.LockedUntil.Sub(now))
+			}
+		}
+
+		// Once a lockout is over, one more attempt is allowed at a time.
+		if l.pending[key] >= max(l.limit(key.kind)-failures, 1) {
+			retryAfter = max(retryAfter, pendingAttemptsRetryAfter)
+		}
+	}
+
+	if retryAfter > 0 {
+		return retryAfter, fmt.Errorf(
+			"%w: username %q in organization %q or address %q",
+			ErrLockedOut, attempt.username, attempt.organization, attempt.address,
+		)
+	}
+
+	for _, key := range keys {
+		l.pending[key]++
+	}
+
+	return 0, nil
+}
+
+// RecordFailure settles a failed attempt, counting it against its username and address and locking them out
+// if they have failed too many times in a row.
+func (l *LockoutUseCases) RecordFailure(attempt *PasswordAttempt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if len(l.lockouts) >= maxTrackedLockouts {
+		l.prune(now)
+	}
+
+	for _, key := range l.keys(attempt) {
+		l.settle(key)
+
+		lockout, ok := l.lockouts[key]
+		if !ok || l.forgotten(lockout, now) {
+			lockout = &models.Lockout{
+				Kind:          key.kind,
+				Organization:  key.organization,
+				Subject:       key.subject,
+				Failures:      0,
+				LastFailureAt: time.Time{},
+				LockedUntil:   time.Time{},
+			}
+			l.lockouts[key] = lockout
+		}
+
+		lockout.Failures++
+		lockout.LastFailureAt = now
+
+		if duration := l.lockoutDuration(key.kind, lockout.Failures); duration > 0 {
+			lockout.LockedUntil = now.Add(duration)
+		}
+	}
+}
+
+// RecordSuccess settles a successful attempt, forgetting the failures of its username.
+// The failures of the address are kept, so that a valid account does not let its owner guess other passwords.
+func (l *LockoutUseCases) RecordSuccess(attempt *PasswordAttempt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range l.keys(attempt) {
+		l.settle(key)
+	}
+
+	delete(l.lockouts, usernameLockoutKey(attempt))
+}
+
+// ReleaseAttempt settles an attempt whose password was not checked, such as because of an internal error,
+// without counting it.
+func (l *LockoutUseCases) ReleaseAttempt(attempt *PasswordAttempt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range l.keys(attempt) {
+		l.settle(key)
+	}
+}
+
+// settle forgets a reserved attempt of the key.
+func (l *LockoutUseCases) settle(key lockoutKey) {
+	if l.pending[key] <= 1 {
+		delete(l.pending, key)
+
+		return
+	}
+
+	l.pending[key]--
+}
+
+// ListLockouts returns the usernames and addresses that are locked out, ordered by kind, organization and subject.
+func (l *LockoutUseCases) ListLockouts() []*models.Lockout {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	var lockouts []*models.Lockout
+	for _, lockout := range l.lockouts {
+		if lockout.LockedUntil.After(now) {
+			locked := *lockout
+			lockouts = append(lockouts, &locked)
+		}
+	}
+
+	slices.SortFunc(lockouts, func(a, b *models.Lockout) int {
+		if a.Kind != b.Kind {
+			return cmp.Compare(a.Kind, b.Kind)
+		}
+
+		if a.Organization != b.Organization {
+			return cmp.Compare(a.Organization, b.Organization)
+		}
+
+		return cmp.Compare(a.Subject, b.Subject)
+	})
+
+	return lockouts
+}
+
+type ClearLockoutCommand struct {
+	key lockoutKey
+}
+
+// NewClearLockoutCommand clears the failures of the username in the organization, or of the IP address
+// without an organization.
+func NewClearLockoutCommand(kind string, organization string, subject string) (*ClearLockoutCommand, error) {
+	switch models.LockoutKind(kind) {
+	case models.LockoutKindUsername:
+	case models.LockoutKindIP:
+		if organization != "" {
+			return nil, fmt.Errorf("%w: lockouts of IP addresses have no organization", ErrInvalidLockout)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidLockout, kind)
+	}
+
+	return &ClearLockoutCommand{
+		key: lockoutKey{
+			kind:         models.LockoutKind(kind),
+			organization: organization,
+			subject:      subject,
+		},
+	}, nil
+}
+
+// ClearLockout forgets the failures of the username or the address, unlocking it.
+// It fails with common.ErrNotFound if there are none.
+func (l *LockoutUseCases) ClearLockout(cmd *ClearLockoutCommand) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.lockouts[cmd.key]; !ok {
+		return fmt.Errorf("%w: no failed attempts of %s %q", common.ErrNotFound, cmd.key.kind, cmd.key.subject)
+	}
+
+	delete(l.lockouts, cmd.key)
+
+	return nil
+}
+
+func (l *LockoutUseCases) keys(attempt *PasswordAttempt) []lockoutKey {
+	var keys []lockoutKey
+	if l.config.MaxUsernameFailures > 0 {
+		keys = append(keys, usernameLockoutKey(attempt))
+	}
+
+	if l.config.MaxIPFailures > 0 && attempt.address != "" {
+		keys = append(keys, lockoutKey{
+			kind:         models.LockoutKindIP,
+			organization: "",
+			subject:      attempt.address,
+		})
+	}
+
+	return keys
+}
+
+func usernameLockoutKey(attempt *PasswordAttempt) lockoutKey {
+	return lockoutKey{
+		kind:         models.LockoutKindUsername,
+		organization: attempt.organization,
+		subject:      attempt.username,
+	}
+}
+
+// lockoutDuration returns how long the failures lock the kind out, which is zero below the limit of the kind.
+func (l *LockoutUseCases) lockoutDuration(kind models.LockoutKind, failures int) time.Duration {
+	limit := l.limit(kind)
+	if failures < limit {
+		return 0
+	}
+
+	duration := l.config.BaseDuration
+	for i := limit; i < failures && duration < l.config.MaxDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, l.config.MaxDuration)
+}
+
+// limit returns the consecutive failures that lock the kind out.
+func (l *LockoutUseCases) limit(kind models.LockoutKind) int {
+	if kind == models.LockoutKindIP {
+		return l.config.MaxIPFailures
+	}
+
+	return l.config.MaxUsernameFailures
+}
+
+// forgotten reports whether the lockout is over and the last failure is older than the maximum duration.
+func (l *LockoutUseCases) forgotten(lockout *models.Lockout, now time.Time) bool {
+	return !lockout.LockedUntil.After(now) && now.Sub(lockout.LastFailureAt) > l.config.MaxDuration
+}
+
+func (l *LockoutUseCases) prune(now time.Time) {
+	for key, lockout := range l.lockouts {
+		if l.forgotten(lockout, now) {
+			delete(l.lockouts, key)
+		}
+	}
+}
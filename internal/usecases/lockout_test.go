@@ -0,0 +1,218 @@
+package usecases_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestLockoutUseCases(t *testing.T) {
+	t.Parallel()
+
+	t.Run("username backoff", func(t *testing.T) {
+		t.Parallel()
+
+		sut := newTestLockoutUseCases(3, 0)
+		attempt := usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1")
+
+		for i := 0; i < 2; i++ {
+			_, err := sut.CheckAttempt(attempt)
+			require.NoError(t, err)
+
+			sut.RecordFailure(attempt)
+		}
+
+		_, err := sut.CheckAttempt(attempt)
+		require.NoError(t, err, "failures below the limit do not lock")
+
+		sut.RecordFailure(attempt)
+
+		retryAfter, err := sut.CheckAttempt(attempt)
+		assert.ErrorIs(t, err, usecases.ErrLockedOut)
+		assert.InDelta(t, time.Second, retryAfter, float64(100*time.Millisecond))
+
+		sut.RecordFailure(attempt)
+
+		retryAfter, _ = sut.CheckAttempt(attempt)
+		assert.InDelta(t, 2*time.Second, retryAfter, float64(100*time.Millisecond), "every failure doubles the lockout")
+
+		for i := 0; i < 10; i++ {
+			sut.RecordFailure(attempt)
+		}
+
+		retryAfter, _ = sut.CheckAttempt(attempt)
+		assert.InDelta(t, time.Minute, retryAfter, float64(100*time.Millisecond), "the lockout is capped")
+
+		_, err = sut.CheckAttempt(usecases.NewPasswordAttempt("acme", "alice", "10.0.0.1"))
+		assert.NoError(t, err, "usernames are locked per organization")
+	})
+
+	t.Run("success resets username", func(t *testing.T) {
+		t.Parallel()
+
+		sut := newTestLockoutUseCases(2, 3)
+		attempt := usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1")
+
+		sut.RecordFailure(attempt)
+		sut.RecordSuccess(attempt)
+		sut.RecordFailure(attempt)
+
+		_, err := sut.CheckAttempt(attempt)
+		require.NoError(t, err)
+
+		sut.RecordFailure(usecases.NewPasswordAttempt(models.DefaultOrganization, "bob", "10.0.0.1"))
+
+		_, err = sut.CheckAttempt(usecases.NewPasswordAttempt(models.DefaultOrganization, "carol", "10.0.0.1"))
+		assert.ErrorIs(t, err, usecases.ErrLockedOut, "failures of the address are kept after a success")
+
+		_, err = sut.CheckAttempt(usecases.NewPasswordAttempt(models.DefaultOrganization, "carol", "10.0.0.2"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("concurrent attempts", func(t *testing.T) {
+		t.Parallel()
+
+		sut := newTestLockoutUseCases(3, 0)
+		attempt := usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1")
+
+		var (
+			passed  atomic.Int32
+			checked sync.WaitGroup
+			settled sync.WaitGroup
+		)
+
+		// Every attempt is checked before any of them is settled, as if the passwords took long to check.
+		checked.Add(20)
+		settled.Add(20)
+		for i := 0; i < 20; i++ {
+			go func() {
+				defer settled.Done()
+
+				_, err := sut.CheckAttempt(attempt)
+				checked.Done()
+				checked.Wait()
+
+				if err == nil {
+					passed.Add(1)
+					sut.RecordFailure(attempt)
+				}
+			}()
+		}
+
+		settled.Wait()
+
+		assert.Equal(t, int32(3), passed.Load(), "attempts in progress count against the limit")
+
+		retryAfter, err := sut.CheckAttempt(attempt)
+		assert.ErrorIs(t, err, usecases.ErrLockedOut)
+		assert.Greater(t, retryAfter, 500*time.Millisecond)
+	})
+
+	t.Run("pending attempts", func(t *testing.T) {
+		t.Parallel()
+
+		sut := newTestLockoutUseCases(2, 0)
+		attempt := usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1")
+
+		for i := 0; i < 2; i++ {
+			_, err := sut.CheckAttempt(attempt)
+			require.NoError(t, err)
+		}
+
+		_, err := sut.CheckAttempt(attempt)
+		require.ErrorIs(t, err, usecases.ErrLockedOut, "the limit is reserved by the attempts in progress")
+
+		sut.ReleaseAttempt(attempt)
+		sut.RecordSuccess(attempt)
+
+		_, err = sut.CheckAttempt(attempt)
+		assert.NoError(t, err, "settled attempts free their reservation")
+	})
+
+	t.Run("list and clear", func(t *testing.T) {
+		t.Parallel()
+
+		sut := newTestLockoutUseCases(1, 2)
+		sut.RecordFailure(usecases.NewPasswordAttempt("acme", "bob", "10.0.0.1"))
+		sut.RecordFailure(usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1"))
+		sut.RecordFailure(usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", ""))
+
+		lockouts := sut.ListLockouts()
+		require.Len(t, lockouts, 3)
+		assert.Equal(t, models.LockoutKindIP, lockouts[0].Kind)
+		assert.Equal(t, "10.0.0.1", lockouts[0].Subject)
+		assert.Equal(t, "acme", lockouts[1].Organization)
+		assert.Equal(t, "alice", lockouts[2].Subject)
+		assert.Equal(t, 2, lockouts[2].Failures)
+
+		cmd, err := usecases.NewClearLockoutCommand("username", models.DefaultOrganization, "alice")
+		require.NoError(t, err)
+		require.NoError(t, sut.ClearLockout(cmd))
+		assert.ErrorIs(t, sut.ClearLockout(cmd), common.ErrNotFound)
+
+		cmd, err = usecases.NewClearLockoutCommand("ip", "", "10.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, sut.ClearLockout(cmd))
+
+		lockouts = sut.ListLockouts()
+		require.Len(t, lockouts, 1)
+		assert.Equal(t, "bob", lockouts[0].Subject)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		t.Parallel()
+
+		sut := usecases.NewLockoutUseCases(usecases.LockoutConfig{
+			MaxUsernameFailures: 1,
+			MaxIPFailures:       0,
+			BaseDuration:        50 * time.Millisecond,
+			MaxDuration:         100 * time.Millisecond,
+		})
+		attempt := usecases.NewPasswordAttempt(models.DefaultOrganization, "alice", "10.0.0.1")
+
+		sut.RecordFailure(attempt)
+
+		_, err := sut.CheckAttempt(attempt)
+		require.ErrorIs(t, err, usecases.ErrLockedOut)
+
+		time.Sleep(60 * time.Millisecond)
+
+		_, err = sut.CheckAttempt(attempt)
+		require.NoError(t, err)
+
+		sut.RecordFailure(attempt)
+		assert.Equal(t, 2, sut.ListLockouts()[0].Failures, "failures are remembered after the lockout")
+
+		time.Sleep(250 * time.Millisecond)
+
+		sut.RecordFailure(attempt)
+		assert.Equal(t, 1, sut.ListLockouts()[0].Failures, "old failures are forgotten")
+	})
+}
+
+func TestNewClearLockoutCommand(t *testing.T) {
+	t.Parallel()
+
+	_, err := usecases.NewClearLockoutCommand("user", models.DefaultOrganization, "alice")
+	assert.ErrorIs(t, err, usecases.ErrInvalidLockout)
+
+	_, err = usecases.NewClearLockoutCommand("ip", models.DefaultOrganization, "10.0.0.1")
+	assert.ErrorIs(t, err, usecases.ErrInvalidLockout)
+}
+
+func newTestLockoutUseCases(maxUsernameFailures int, maxIPFailures int) *usecases.LockoutUseCases {
+	return usecases.NewLockoutUseCases(usecases.LockoutConfig{
+		MaxUsernameFailures: maxUsernameFailures,
+		MaxIPFailures:       maxIPFailures,
+		BaseDuration:        time.Second,
+		MaxDuration:         time.Minute,
+	})
+}
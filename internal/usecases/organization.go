@@ -0,0 +1,90 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidOrganization = errors.New("invalid organization")
+
+type OrganizationUseCases struct {
+	organizations OrganizationRepository
+}
+
+func NewOrganizationUseCases(organizations OrganizationRepository) *OrganizationUseCases {
+	return &OrganizationUseCases{
+		organizations: organizations,
+	}
+}
+
+type CreateOrganizationCommand struct {
+	name string
+}
+
+// NewCreateOrganizationCommand accepts the same names as roles.
+func NewCreateOrganizationCommand(name string) (*CreateOrganizationCommand, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, fmt.Errorf(
+			"%w: name must start with a lowercase letter and consist of lowercase letters, digits, '_', '.' or '-'",
+			ErrInvalidOrganization,
+		)
+	}
+
+	return &CreateOrganizationCommand{
+		name: name,
+	}, nil
+}
+
+// CreateOrganization fails with common.ErrAlreadyExists if an organization with the same name exists,
+// including the default one.
+func (o *OrganizationUseCases) CreateOrganization(cmd *CreateOrganizationCommand) (*models.Organization, error) {
+	if cmd.name == models.DefaultOrganization {
+		return nil, fmt.Errorf("%w: organization %q is built-in", common.ErrAlreadyExists, cmd.name)
+	}
+
+	organization := &models.Organization{
+		Name:      cmd.name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := o.organizations.SaveOrganization(organization); err != nil {
+		return nil, fmt.Errorf("failed to save organization: %w", err)
+	}
+
+	return organization, nil
+}
+
+// GetOrganization fails with common.ErrNotFound if neither the default nor a stored organization has the name.
+func (o *OrganizationUseCases) GetOrganization(name string) (*models.Organization, error) {
+	if name == models.DefaultOrganization {
+		return newDefaultOrganization(), nil
+	}
+
+	organization, err := o.organizations.GetOrganization(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization %q: %w", name, err)
+	}
+
+	return organization, nil
+}
+
+// ListOrganizations returns the default organization followed by the stored organizations ordered by name.
+func (o *OrganizationUseCases) ListOrganizations() ([]*models.Organization, error) {
+	stored, err := o.organizations.ListOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	return append([]*models.Organization{newDefaultOrganization()}, stored...), nil
+}
+
+func newDefaultOrganization() *models.Organization {
+	return &models.Organization{
+		Name:      models.DefaultOrganization,
+		CreatedAt: time.Time{},
+	}
+}
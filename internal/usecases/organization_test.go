@@ -0,0 +1,98 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestOrganizationUseCases(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	sut := usecases.NewOrganizationUseCases(repo)
+
+	_, err = usecases.NewCreateOrganizationCommand("Acme Corp")
+	assert.ErrorIs(t, err, usecases.ErrInvalidOrganization)
+
+	cmd, err := usecases.NewCreateOrganizationCommand(models.DefaultOrganization)
+	require.NoError(t, err)
+
+	_, err = sut.CreateOrganization(cmd)
+	assert.ErrorIs(t, err, common.ErrAlreadyExists, "the default organization is built-in")
+
+	cmd, err = usecases.NewCreateOrganizationCommand("acme")
+	require.NoError(t, err)
+
+	_, err = sut.CreateOrganization(cmd)
+	require.NoError(t, err)
+
+	_, err = sut.CreateOrganization(cmd)
+	assert.ErrorIs(t, err, common.ErrAlreadyExists)
+
+	organization, err := sut.GetOrganization(models.DefaultOrganization)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultOrganization, organization.Name)
+
+	_, err = sut.GetOrganization("globex")
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	organizations, err := sut.ListOrganizations()
+	require.NoError(t, err)
+	require.Len(t, organizations, 2)
+	assert.Equal(t, models.DefaultOrganization, organizations[0].Name)
+	assert.Equal(t, "acme", organizations[1].Name)
+	assert.False(t, organizations[1].CreatedAt.IsZero())
+}
+
+func TestUserUseCases_UsernamesPerOrganization(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	sut := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+
+	defaultID, err := sut.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, "alice", "alice@corp.com", "default", nil),
+	)
+	require.NoError(t, err)
+
+	acmeID, err := sut.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand("acme", "alice", "alice@corp.com", "acme", nil),
+	)
+	require.NoError(t, err)
+	assert.NotEqual(t, defaultID, acmeID)
+
+	_, err = sut.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand("acme", "alice", "other@corp.com", "acme", nil),
+	)
+	assert.ErrorIs(t, err, common.ErrAlreadyExists)
+
+	user, err := sut.AuthenticateUser(context.Background(), "acme", "alice", "acme")
+	require.NoError(t, err)
+	assert.Equal(t, acmeID, user.ID)
+	assert.Equal(t, "acme", user.Organization)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "acme")
+	assert.ErrorIs(t, err, common.ErrInvalidCredentials, "the password of another organization is rejected")
+}
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageTokenCodec encodes list positions into opaque page tokens encrypted with AES-256-GCM,
+// so that clients can neither read nor forge them. The positions contain the sort key of the last user
+// of a page, such as an email, which must not be revealed to callers who can only see other fields.
+type PageTokenCodec struct {
+	key []byte
+}
+
+// NewPageTokenCodec derives the encryption key from the secret, which may have any length.
+func NewPageTokenCodec(secret []byte) *PageTokenCodec {
+	key := sha256.Sum256(secret)
+
+	return &PageTokenCodec{
+		key: key[:],
+	}
+}
+
+// pageToken binds the position to the order and filter of the request it was issued for,
+// since a position is meaningless for a different query.
+type pageToken struct {
+	OrderBy string    `json:"o"`
+	Filter  string    `json:"f"`
+	Key     string    `json:"k"`
+	ID      uuid.UUID `json:"i"`
+}
+
+func (c *PageTokenCodec) Encode(orderBy string, filter string, position *models.UserListPosition) (string, error) {
+	payload, err := json.Marshal(&pageToken{
+		OrderBy: orderBy,
+		Filter:  filter,
+		Key:     position.Key,
+		ID:      position.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal page token: %w", err)
+	}
+
+	aead, err := c.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate page token nonce: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, payload, nil)), nil
+}
+
+func (c *PageTokenCodec) Decode(orderBy string, filter string, token string) (*models.UserListPosition, error) {
+	aead, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrInvalidPageToken
+	}
+
+	nonce, ciphertext := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+
+	payload, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	decoded := new(pageToken)
+	if err := json.Unmarshal(payload, decoded); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	if decoded.OrderBy != orderBy || decoded.Filter != filter {
+		return nil, fmt.Errorf("%w: order_by and filter must not change between pages", ErrInvalidPageToken)
+	}
+
+	return &models.UserListPosition{
+		Key: decoded.Key,
+		ID:  decoded.ID,
+	}, nil
+}
+
+func (c *PageTokenCodec) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page token cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page token cipher: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,85 @@
+package usecases_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestPageTokenCodec(t *testing.T) {
+	t.Parallel()
+
+	const (
+		orderBy = "username desc"
+		filter  = "admin = true"
+	)
+
+	codec := usecases.NewPageTokenCodec([]byte("secret"))
+	position := &models.UserListPosition{Key: "alice", ID: uuid.New()}
+
+	token, err := codec.Encode(orderBy, filter, position)
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := codec.Decode(orderBy, filter, token)
+		assert.NoError(t, err)
+		assert.Equal(t, position, actual)
+	})
+
+	t.Run("opaque", func(t *testing.T) {
+		t.Parallel()
+
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), position.Key)
+		assert.NotContains(t, string(raw), position.ID.String())
+		assert.NotContains(t, string(raw), orderBy)
+
+		another, err := codec.Encode(orderBy, filter, position)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, another, "equal positions are not revealed either")
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		t.Parallel()
+
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		raw[len(raw)/2] ^= 0x01
+
+		_, err = codec.Decode(orderBy, filter, base64.RawURLEncoding.EncodeToString(raw))
+		assert.ErrorIs(t, err, usecases.ErrInvalidPageToken)
+	})
+
+	t.Run("another key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := usecases.NewPageTokenCodec([]byte("another")).Decode(orderBy, filter, token)
+		assert.ErrorIs(t, err, usecases.ErrInvalidPageToken)
+	})
+
+	t.Run("another query", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := codec.Decode("username", filter, token)
+		assert.ErrorIs(t, err, usecases.ErrInvalidPageToken)
+
+		_, err = codec.Decode(orderBy, "", token)
+		assert.ErrorIs(t, err, usecases.ErrInvalidPageToken)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := codec.Decode(orderBy, filter, "not a token")
+		assert.ErrorIs(t, err, usecases.ErrInvalidPageToken)
+	})
+}
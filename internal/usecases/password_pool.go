@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPasswordPoolSaturated means that as many password hashes and verifications as the pool allows are running
+// or waiting.
+var ErrPasswordPoolSaturated = errors.New("password verification pool is saturated")
+
+var _ PasswordHasher = (*PasswordPool)(nil)
+
+// PasswordPool is a PasswordHasher that bounds the hashes and verifications of another hasher that run at once
+// by a number of workers, so that a flood of password attempts or changes cannot take more CPUs than the workers.
+// Every path that hashes a password goes through the hasher of UserUseCases, so passing it a pool bounds them all.
+// Passwords that find every worker busy wait in a queue until their context is done, and passwords that find
+// the queue full are rejected instead of waiting.
+type PasswordPool struct {
+	hasher PasswordHasher
+	// slots are taken by the passwords that are being hashed or waiting for a worker.
+	slots chan struct{}
+	// workers are taken by the passwords that are being hashed.
+	workers chan struct{}
+}
+
+// NewPasswordPool runs up to workers hashes and verifications of the hasher at once,
+// while up to queueSize more wait for a worker.
+func NewPasswordPool(hasher PasswordHasher, workers int, queueSize int) *PasswordPool {
+	return &PasswordPool{
+		hasher:  hasher,
+		slots:   make(chan struct{}, workers+queueSize),
+		workers: make(chan struct{}, workers),
+	}
+}
+
+// Hash fails with ErrPasswordPoolSaturated without waiting if the queue is full.
+func (p *PasswordPool) Hash(ctx context.Context, password string) ([]byte, error) {
+	return runOnPasswordPool(ctx, p, func() ([]byte, error) {
+		return p.hasher.Hash(ctx, password)
+	})
+}
+
+// Verify fails with ErrPasswordPoolSaturated without waiting if the queue is full.
+func (p *PasswordPool) Verify(ctx context.Context, hash []byte, password string) (bool, error) {
+	return runOnPasswordPool(ctx, p, func() (bool, error) {
+		return p.hasher.Verify(ctx, hash, password)
+	})
+}
+
+// Parameters only parses the hash, so it does not wait for a worker.
+func (p *PasswordPool) Parameters(hash []byte) (string, error) {
+	return p.hasher.Parameters(hash) //nolint:wrapcheck
+}
+
+// HashWithParameters fails with ErrPasswordPoolSaturated without waiting if the queue is full.
+func (p *PasswordPool) HashWithParameters(ctx context.Context, parameters string, password string) ([]byte, error) {
+	return runOnPasswordPool(ctx, p, func() ([]byte, error) {
+		return p.hasher.HashWithParameters(ctx, parameters, password)
+	})
+}
+
+// runOnPasswordPool runs fn once a worker of the pool is free, unless the context is done first,
+// which frees the place of the caller in the queue.
+func runOnPasswordPool[Result any](ctx context.Context, pool *PasswordPool, fn func() (Result, error)) (Result, error) {
+	var zero Result
+
+	select {
+	case pool.slots <- struct{}{}:
+	default:
+		return zero, ErrPasswordPoolSaturated
+	}
+	defer func() { <-pool.slots }()
+
+	select {
+	case pool.workers <- struct{}{}:
+	case <-ctx.Done():
+		return zero, fmt.Errorf("failed to wait for a password worker: %w", ctx.Err())
+	}
+	defer func() { <-pool.workers }()
+
+	return fn()
+}
@@ -0,0 +1,130 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestPasswordPool(t *testing.T) {
+	t.Parallel()
+
+	hasher := &blockingPasswordHasher{
+		PasswordHasher: newTestPasswordHasher(t),
+		started:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	sut := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		usecases.NewPasswordPool(hasher, 1, 0),
+		nil,
+		nil,
+	)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		createUser(t, sut, "alice")
+	}()
+
+	<-hasher.started
+
+	_, err = sut.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, "bob", "bob@corp.com", "password", nil),
+	)
+	assert.ErrorIs(t, err, usecases.ErrPasswordPoolSaturated, "new passwords are hashed on the pool")
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "carol", "password")
+	assert.ErrorIs(t, err, usecases.ErrPasswordPoolSaturated, "passwords are verified on the pool")
+
+	close(hasher.release)
+	wg.Wait()
+
+	createUser(t, sut, "bob")
+	authenticate(t, sut, "bob", "password")
+}
+
+func TestPasswordPool_CancelledWaiter(t *testing.T) {
+	t.Parallel()
+
+	hasher := &blockingPasswordHasher{
+		PasswordHasher: newTestPasswordHasher(t),
+		started:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+	sut := usecases.NewPasswordPool(hasher, 1, 1)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(hasher.release)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, err := sut.Hash(context.Background(), "password")
+		assert.NoError(t, err)
+	}()
+
+	<-hasher.started
+
+	waiterCtx, cancelWaiter := context.WithCancel(context.Background())
+	waiterDone := make(chan error)
+	go func() {
+		_, err := sut.Hash(waiterCtx, "password")
+		waiterDone <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := sut.Hash(cancelled, "password")
+
+		return errors.Is(err, usecases.ErrPasswordPoolSaturated)
+	}, time.Second, time.Millisecond, "the waiter takes the place in the queue")
+
+	cancelWaiter()
+	require.ErrorIs(t, <-waiterDone, context.Canceled, "the waiter returns while the worker is still busy")
+
+	_, err := sut.Hash(cancelled, "password")
+	assert.ErrorIs(t, err, context.Canceled, "the place of the waiter is free again")
+}
+
+// blockingPasswordHasher hashes passwords once they are released.
+type blockingPasswordHasher struct {
+	usecases.PasswordHasher
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingPasswordHasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	select {
+	case h.started <- struct{}{}:
+	default:
+	}
+	<-h.release
+
+	return h.PasswordHasher.Hash(ctx, password) //nolint:wrapcheck
+}
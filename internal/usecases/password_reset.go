@@ -0,0 +1,259 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+const (
+	// passwordResetQueueSize bounds the password reset requests waiting to be processed by Run.
+	passwordResetQueueSize = 100
+	// maxTrackedPasswordResets is the number of usernames in their cooldown above which the request is rejected,
+	// once the usernames whose cooldown is over have been pruned.
+	maxTrackedPasswordResets = 100_000
+)
+
+var (
+	// ErrPasswordResetQueueFull means that too many password reset requests are waiting to be processed.
+	ErrPasswordResetQueueFull = errors.New("password reset queue is full")
+	// ErrPasswordResetCooldown means that a password reset of the username has been requested recently.
+	ErrPasswordResetCooldown = errors.New("password reset requested recently")
+)
+
+type PasswordResetUseCases struct {
+	users         *UserUseCases
+	refreshTokens RefreshTokenRepository
+	mail          MailSender
+	resetTTL      time.Duration
+	cooldown      time.Duration
+	requests      chan *RequestPasswordResetCommand
+
+	mu sync.Mutex
+	// requestedAt are the times of the last accepted requests of the usernames in their cooldown.
+	requestedAt map[passwordResetKey]time.Time
+}
+
+type passwordResetKey struct {
+	organization string
+	username     string
+}
+
+// NewPasswordResetUseCases mails reset tokens with the sender. The tokens expire after resetTTL.
+// Another reset of a username may only be requested once cooldown has passed, and zero disables the cooldown.
+// Requests for reset tokens are only processed while Run is running.
+func NewPasswordResetUseCases(
+	users *UserUseCases,
+	refreshTokens RefreshTokenRepository,
+	mail MailSender,
+	resetTTL time.Duration,
+	cooldown time.Duration,
+) *PasswordResetUseCases {
+	return &PasswordResetUseCases{
+		users:         users,
+		refreshTokens: refreshTokens,
+		mail:          mail,
+		resetTTL:      resetTTL,
+		cooldown:      cooldown,
+		requests:      make(chan *RequestPasswordResetCommand, passwordResetQueueSize),
+		mu:            sync.Mutex{},
+		requestedAt:   make(map[passwordResetKey]time.Time),
+	}
+}
+
+type RequestPasswordResetCommand struct {
+	organization string
+	username     string
+}
+
+func NewRequestPasswordResetCommand(organization string, username string) *RequestPasswordResetCommand {
+	return &RequestPasswordResetCommand{
+		organization: organization,
+		username:     username,
+	}
+}
+
+// RequestPasswordReset queues the request for Run, which replaces the pending password reset of the user
+// with a new one and mails its token. The request is answered before the user is even looked up,
+// so neither the result nor the time it takes reveals which usernames exist. It fails only with
+// ErrPasswordResetQueueFull, or with ErrPasswordResetCooldown and how long until the username may be retried,
+// whatever the username.
+func (p *PasswordResetUseCases) RequestPasswordReset(cmd *RequestPasswordResetCommand) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	key := passwordResetKey{organization: cmd.organization, username: cmd.username}
+
+	if requestedAt, ok := p.requestedAt[key]; ok {
+		if retryAfter := requestedAt.Add(p.cooldown).Sub(now); retryAfter > 0 {
+			return retryAfter, fmt.Errorf(
+				"%w: username %q in organization %q", ErrPasswordResetCooldown, cmd.username, cmd.organization,
+			)
+		}
+
+		delete(p.requestedAt, key)
+	}
+
+	if p.cooldown > 0 && len(p.requestedAt) >= maxTrackedPasswordResets {
+		p.prune(now)
+
+		if len(p.requestedAt) >= maxTrackedPasswordResets {
+			return 0, ErrPasswordResetQueueFull
+		}
+	}
+
+	select {
+	case p.requests <- cmd:
+	default:
+		return 0, ErrPasswordResetQueueFull
+	}
+
+	if p.cooldown > 0 {
+		p.requestedAt[key] = now
+	}
+
+	return 0, nil
+}
+
+// prune forgets the usernames whose cooldown is over.
+func (p *PasswordResetUseCases) prune(now time.Time) {
+	for key, requestedAt := range p.requestedAt {
+		if !now.Before(requestedAt.Add(p.cooldown)) {
+			delete(p.requestedAt, key)
+		}
+	}
+}
+
+// Run processes the queued password reset requests one at a time until the context is done.
+// The callers have already been answered, so failures are logged with the logger of the context.
+func (p *PasswordResetUseCases) Run(ctx context.Context) {
+	logger := common.ExtractLogger(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cmd := <-p.requests:
+			if err := p.resetPendingPassword(cmd); err != nil {
+				logger.ErrorContext(ctx, "failed to process password reset request", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// resetPendingPassword replaces the pending password reset of the user with a new one and mails its token.
+// Unknown users are skipped.
+func (p *PasswordResetUseCases) resetPendingPassword(cmd *RequestPasswordResetCommand) error {
+	user, err := p.users.repo.GetByUsername(cmd.organization, cmd.username)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil
+	default:
+		return fmt.Errorf("failed to get user by username %q: %w", cmd.username, err)
+	}
+
+	token, hash, err := newUserToken(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	pending := *user
+	pending.PasswordReset = &models.PasswordReset{
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(p.resetTTL),
+	}
+
+	if err := p.users.repo.Save(&pending); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	mail := &models.Mail{
+		To:      pending.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nreset your password with the token %s\nIt expires at %s.\n"+
+				"If you did not request it, ignore this mail.\n",
+			pending.Username, token, pending.PasswordReset.ExpiresAt.Format(time.RFC3339),
+		),
+	}
+
+	if err := p.mail.Send(mail); err != nil {
+		return fmt.Errorf("failed to send password reset mail: %w", err)
+	}
+
+	return nil
+}
+
+type ResetPasswordCommand struct {
+	token       string
+	newPassword string
+}
+
+func NewResetPasswordCommand(token string, newPassword string) *ResetPasswordCommand {
+	return &ResetPasswordCommand{
+		token:       token,
+		newPassword: newPassword,
+	}
+}
+
+// ResetPassword replaces the password of the user the token was mailed to and revokes the refresh tokens
+// and access tokens of the user.
+// Since the token proves that the user owns the email, a pending email verification is completed as well.
+// It fails with common.ErrInvalidToken if the token is malformed, expired, used or not the latest one
+// mailed to the user, and with common.ErrConflict if the user is modified concurrently.
+func (p *PasswordResetUseCases) ResetPassword(ctx context.Context, cmd *ResetPasswordCommand) error {
+	id, hash, err := parseUserToken(cmd.token)
+	if err != nil {
+		return err
+	}
+
+	user, err := p.users.repo.GetByID(id)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return fmt.Errorf("%w: user of the password reset token does not exist", common.ErrInvalidToken)
+	default:
+		return fmt.Errorf("failed to get user by id %q: %w", id, err)
+	}
+
+	reset := user.PasswordReset
+	if reset == nil || subtle.ConstantTimeCompare(reset.TokenHash, hash) != 1 {
+		return fmt.Errorf("%w: token does not match pending password reset of user %q", common.ErrInvalidToken, id)
+	}
+
+	if time.Now().After(reset.ExpiresAt) {
+		return fmt.Errorf("%w: password reset token of user %q has expired", common.ErrInvalidToken, id)
+	}
+
+	passwordHash, err := p.users.hashPassword(ctx, cmd.newPassword)
+	if err != nil {
+		return err
+	}
+
+	updated := *user
+	updated.PasswordHash = passwordHash
+	updated.PasswordChangedAt = time.Now().UTC()
+	updated.PasswordReset = nil
+	updated.EmailVerification = nil
+
+	if err := p.users.repo.Save(&updated); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	p.users.forgetCredentials(id)
+
+	if err := p.refreshTokens.DeleteRefreshTokensOfUser(id); err != nil {
+		return fmt.Errorf("failed to delete refresh tokens of user %q: %w", id, err)
+	}
+
+	return nil
+}
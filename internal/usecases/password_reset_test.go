@@ -0,0 +1,262 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestPasswordResetUseCases_RequestPasswordReset(t *testing.T) {
+	t.Parallel()
+
+	sut, users, repo, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+	id := createUser(t, users, "alice")
+
+	_, err := sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, "bob"))
+	require.NoError(t, err, "unknown users are not revealed")
+
+	_, err = sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand("acme", "alice"))
+	require.NoError(t, err)
+
+	_, err = sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, "alice"))
+	require.NoError(t, err)
+
+	// The requests are processed in order, so the earlier ones are done once alice is mailed.
+	waitForMails(t, outbox, 1)
+
+	mails := outbox.Mails()
+	require.Len(t, mails, 1, "unknown users and users of other organizations are not mailed")
+	assert.Equal(t, "alice@corp.com", mails[0].To)
+
+	user, err := repo.GetByID(id)
+	require.NoError(t, err)
+	require.NotNil(t, user.PasswordReset)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), user.PasswordReset.ExpiresAt, time.Minute)
+}
+
+func TestPasswordResetUseCases_RequestPasswordResetCooldown(t *testing.T) {
+	t.Parallel()
+
+	sut, users, repo, outbox := newTestPasswordResetUseCases(t, time.Hour, time.Minute)
+	id := createUser(t, users, "alice")
+
+	token := requestPasswordReset(t, sut, outbox, "alice")
+
+	retryAfter, err := sut.RequestPasswordReset(
+		usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, "alice"),
+	)
+	assert.ErrorIs(t, err, usecases.ErrPasswordResetCooldown)
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second))
+
+	_, err = sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, "bob"))
+	require.NoError(t, err)
+
+	_, err = sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, "bob"))
+	assert.ErrorIs(t, err, usecases.ErrPasswordResetCooldown, "unknown usernames have a cooldown as well")
+
+	_, err = sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand("acme", "alice"))
+	assert.NoError(t, err, "the cooldown is per organization")
+
+	user, err := repo.GetByID(id)
+	require.NoError(t, err)
+	require.NotNil(t, user.PasswordReset)
+	assert.NoError(t, sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "new password")),
+		"the pending reset is not replaced in the cooldown")
+}
+
+func TestPasswordResetUseCases_ResetPassword(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, repo, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+		id := createUser(t, users, "alice")
+		session := saveRefreshToken(t, repo, id)
+
+		token := requestPasswordReset(t, sut, outbox, "alice")
+		require.NoError(t, sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "new password")))
+
+		_, err := users.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+		assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+		_, err = users.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "new password")
+		assert.NoError(t, err)
+
+		_, err = repo.GetRefreshToken(session.Hash)
+		assert.ErrorIs(t, err, common.ErrNotFound, "sessions are revoked")
+
+		err = sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "another password"))
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "the token is single-use")
+	})
+
+	t.Run("access tokens", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, repo, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+		createUser(t, users, "alice")
+		auth := usecases.NewAuthUseCases(users, newTestAccessTokenIssuer(t), repo, time.Hour)
+
+		before, err := auth.Login(
+			context.Background(),
+			usecases.NewLoginCommand(models.DefaultOrganization, "alice", "password"),
+		)
+		require.NoError(t, err)
+
+		token := requestPasswordReset(t, sut, outbox, "alice")
+		require.NoError(t, sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "new password")))
+
+		_, err = auth.AuthenticateAccessToken(before.AccessToken)
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "access tokens issued before the reset are revoked")
+
+		after, err := auth.Login(
+			context.Background(),
+			usecases.NewLoginCommand(models.DefaultOrganization, "alice", "new password"),
+		)
+		require.NoError(t, err)
+
+		_, err = auth.AuthenticateAccessToken(after.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, _, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+		createUser(t, users, "alice")
+
+		previous := requestPasswordReset(t, sut, outbox, "alice")
+		latest := requestPasswordReset(t, sut, outbox, "alice")
+
+		err := sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(previous, "new password"))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+
+		assert.NoError(t, sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(latest, "new password")))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, _, outbox := newTestPasswordResetUseCases(t, -time.Second, 0)
+		createUser(t, users, "alice")
+
+		token := requestPasswordReset(t, sut, outbox, "alice")
+
+		err := sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "new password"))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+
+		_, err = users.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+		assert.NoError(t, err, "the password is kept")
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, _, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+		id := createUser(t, users, "alice")
+
+		token := requestPasswordReset(t, sut, outbox, "alice")
+
+		for _, invalid := range []string{
+			"",
+			"garbage",
+			id.String() + ".forged",
+			uuid.NewString() + token[len(id.String()):],
+		} {
+			err := sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(invalid, "new password"))
+			assert.ErrorIs(t, err, common.ErrInvalidToken, "token %q", invalid)
+		}
+	})
+
+	t.Run("verifies email", func(t *testing.T) {
+		t.Parallel()
+
+		sut, users, repo, outbox := newTestPasswordResetUseCases(t, time.Hour, 0)
+		registration := usecases.NewRegistrationUseCases(
+			users, usecases.NewOrganizationUseCases(repo), usecases.NewEmailVerifications(outbox, time.Hour), true,
+		)
+
+		id, err := registration.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		token := requestPasswordReset(t, sut, outbox, "alice")
+		require.NoError(t, sut.ResetPassword(context.Background(), usecases.NewResetPasswordCommand(token, "new password")))
+
+		user, err := repo.GetByID(id)
+		require.NoError(t, err)
+		assert.Nil(t, user.EmailVerification)
+		assert.Nil(t, user.PasswordReset)
+	})
+}
+
+func requestPasswordReset(
+	t *testing.T,
+	sut *usecases.PasswordResetUseCases,
+	outbox *infrastructure.MemoryOutbox,
+	username string,
+) string {
+	t.Helper()
+
+	sent := len(outbox.Mails())
+
+	_, err := sut.RequestPasswordReset(usecases.NewRequestPasswordResetCommand(models.DefaultOrganization, username))
+	require.NoError(t, err)
+
+	waitForMails(t, outbox, sent+1)
+
+	return lastMailedToken(t, outbox)
+}
+
+// waitForMails waits until the queued password reset requests have sent the number of mails.
+func waitForMails(t *testing.T, outbox *infrastructure.MemoryOutbox, count int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return len(outbox.Mails()) >= count
+	}, 5*time.Second, time.Millisecond)
+}
+
+func saveRefreshToken(t *testing.T, repo *infrastructure.Repository, userID uuid.UUID) *models.RefreshToken {
+	t.Helper()
+
+	now := time.Now().UTC()
+	token := &models.RefreshToken{
+		Hash:      []byte(uuid.NewString()),
+		FamilyID:  uuid.New(),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+		Rotated:   false,
+	}
+	require.NoError(t, repo.SaveRefreshToken(token))
+
+	return token
+}
+
+func newTestPasswordResetUseCases(
+	t *testing.T,
+	resetTTL time.Duration,
+	cooldown time.Duration,
+) (*usecases.PasswordResetUseCases, *usecases.UserUseCases, *infrastructure.Repository, *infrastructure.MemoryOutbox) {
+	t.Helper()
+
+	users, repo := newTestUserUseCases(t)
+	outbox := infrastructure.NewMemoryOutbox()
+	sut := usecases.NewPasswordResetUseCases(users, repo, outbox, resetTTL, cooldown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go sut.Run(ctx)
+
+	return sut, users, repo, outbox
+}
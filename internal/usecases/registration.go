@@ -0,0 +1,220 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// MailSender delivers mails to users, such as the tokens of email verification.
+type MailSender interface {
+	Send(mail *models.Mail) error
+}
+
+// ErrRegistrationDisabled means that users can only be created by admins.
+var ErrRegistrationDisabled = errors.New("registration is disabled")
+
+type RegistrationUseCases struct {
+	users         *UserUseCases
+	organizations *OrganizationUseCases
+	verifications *EmailVerifications
+	enabled       bool
+}
+
+// NewRegistrationUseCases lets users register if enabled, and mails their verification tokens with verifications.
+func NewRegistrationUseCases(
+	users *UserUseCases,
+	organizations *OrganizationUseCases,
+	verifications *EmailVerifications,
+	enabled bool,
+) *RegistrationUseCases {
+	return &RegistrationUseCases{
+		users:         users,
+		organizations: organizations,
+		verifications: verifications,
+		enabled:       enabled,
+	}
+}
+
+type RegisterCommand struct {
+	organization string
+	username     string
+	email        string
+	password     string
+}
+
+func NewRegisterCommand(organization string, username string, email string, password string) *RegisterCommand {
+	return &RegisterCommand{
+		organization: organization,
+		username:     username,
+		email:        email,
+		password:     password,
+	}
+}
+
+// Register creates a user without roles and with a pending email verification, and mails its token to the user.
+// It fails with ErrRegistrationDisabled unless registration is enabled, with common.ErrNotFound
+// if the organization does not exist, and with common.ErrAlreadyExists if the username or email is taken.
+func (r *RegistrationUseCases) Register(ctx context.Context, cmd *RegisterCommand) (uuid.UUID, error) {
+	if !r.enabled {
+		return uuid.UUID{}, ErrRegistrationDisabled
+	}
+
+	if _, err := r.organizations.GetOrganization(cmd.organization); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	passwordHash, err := r.users.hashPassword(ctx, cmd.password)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	id := uuid.New()
+
+	token, verification, err := r.verifications.newVerification(id)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	user := &models.User{
+		ID:                id,
+		Organization:      cmd.organization,
+		Username:          cmd.username,
+		Email:             cmd.email,
+		PasswordHash:      passwordHash,
+		Roles:             nil,
+		EmailVerification: verification,
+		PasswordReset:     nil,
+		PasswordChangedAt: time.Time{},
+		CreatedAt:         time.Now().UTC(),
+		Version:           0,
+	}
+
+	if err := r.users.repo.Save(user); err != nil {
+		return uuid.UUID{}, fmt.Errorf("failed to save user: %w", err)
+	}
+
+	if err := r.verifications.send(user, token); err != nil {
+		// Without the token the user could never verify the email, so the username and email are released.
+		if err := r.users.repo.Delete(user.ID, user.Version); err != nil {
+			return uuid.UUID{}, fmt.Errorf("failed to delete user without verification mail: %w", err)
+		}
+
+		return uuid.UUID{}, err
+	}
+
+	return id, nil
+}
+
+type VerifyEmailCommand struct {
+	token string
+}
+
+func NewVerifyEmailCommand(token string) *VerifyEmailCommand {
+	return &VerifyEmailCommand{
+		token: token,
+	}
+}
+
+// VerifyEmail fails with common.ErrInvalidToken if the token is malformed, expired or not the latest one
+// mailed to a user with a pending verification, and with common.ErrConflict if the user is modified concurrently.
+func (r *RegistrationUseCases) VerifyEmail(cmd *VerifyEmailCommand) error {
+	id, hash, err := parseUserToken(cmd.token)
+	if err != nil {
+		return err
+	}
+
+	user, err := r.users.repo.GetByID(id)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return fmt.Errorf("%w: user of the verification token does not exist", common.ErrInvalidToken)
+	default:
+		return fmt.Errorf("failed to get user by id %q: %w", id, err)
+	}
+
+	verification := user.EmailVerification
+	if verification == nil || subtle.ConstantTimeCompare(verification.TokenHash, hash) != 1 {
+		return fmt.Errorf("%w: token does not match pending verification of user %q", common.ErrInvalidToken, id)
+	}
+
+	if time.Now().After(verification.ExpiresAt) {
+		return fmt.Errorf("%w: verification token of user %q has expired", common.ErrInvalidToken, id)
+	}
+
+	verified := *user
+	verified.EmailVerification = nil
+
+	if err := r.users.repo.Save(&verified); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail replaces the pending verification of the user with a new one and mails its token.
+// It does nothing if the email of the user is verified,
+// and fails with common.ErrConflict if the user is modified concurrently.
+func (r *RegistrationUseCases) ResendVerificationEmail(user *models.User) error {
+	if user.EmailVerification == nil {
+		return nil
+	}
+
+	token, verification, err := r.verifications.newVerification(user.ID)
+	if err != nil {
+		return err
+	}
+
+	pending := *user
+	pending.EmailVerification = verification
+
+	if err := r.users.repo.Save(&pending); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return r.verifications.send(&pending, token)
+}
+
+// userTokenSeparator separates the ID of the user from the secret in tokens mailed to users,
+// which lets the tokens be stored with the user instead of being indexed by their hash.
+const userTokenSeparator = "."
+
+// newUserToken returns a token of the user and the hash of its secret.
+func newUserToken(userID uuid.UUID) (string, []byte, error) {
+	const secretSize = 32
+
+	raw := make([]byte, secretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+
+	return userID.String() + userTokenSeparator + secret, hashSecret(secret), nil
+}
+
+// parseUserToken returns the ID of the user of the token and the hash of its secret.
+// It fails with common.ErrInvalidToken if the token is malformed.
+func parseUserToken(token string) (uuid.UUID, []byte, error) {
+	rawID, secret, ok := strings.Cut(token, userTokenSeparator)
+	if !ok || secret == "" {
+		return uuid.UUID{}, nil, fmt.Errorf("%w: token without secret", common.ErrInvalidToken)
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.UUID{}, nil, fmt.Errorf("%w: failed to parse user id: %w", common.ErrInvalidToken, err)
+	}
+
+	return id, hashSecret(secret), nil
+}
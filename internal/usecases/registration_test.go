@@ -0,0 +1,225 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestRegistrationUseCases_Register(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, outbox := newTestRegistrationUseCases(t, time.Hour, false)
+
+		_, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		assert.ErrorIs(t, err, usecases.ErrRegistrationDisabled)
+		assert.Empty(t, outbox.Mails())
+	})
+
+	t.Run("unknown organization", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, _ := newTestRegistrationUseCases(t, time.Hour, true)
+
+		_, err := sut.Register(context.Background(), newRegisterCommand("acme", "alice"))
+		assert.ErrorIs(t, err, common.ErrNotFound)
+	})
+
+	t.Run("pending verification", func(t *testing.T) {
+		t.Parallel()
+
+		sut, repo, outbox := newTestRegistrationUseCases(t, time.Hour, true)
+
+		id, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		user, err := repo.GetByID(id)
+		require.NoError(t, err)
+		assert.Empty(t, user.Roles)
+		require.NotNil(t, user.EmailVerification)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), user.EmailVerification.ExpiresAt, time.Minute)
+
+		mails := outbox.Mails()
+		require.Len(t, mails, 1)
+		assert.Equal(t, "alice@corp.com", mails[0].To)
+
+		_, err = sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		assert.ErrorIs(t, err, common.ErrAlreadyExists)
+	})
+
+	t.Run("failed mail", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := infrastructure.NewRepository()
+		require.NoError(t, err)
+
+		sut := usecases.NewRegistrationUseCases(
+			usecases.NewUserUseCases(
+				repo,
+				repo,
+				usecases.NewPageTokenCodec([]byte("secret")),
+				newTestPasswordHasher(t),
+				nil,
+				nil,
+			),
+			usecases.NewOrganizationUseCases(repo),
+			usecases.NewEmailVerifications(failingMailSender{}, time.Hour),
+			true,
+		)
+
+		_, err = sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		assert.ErrorIs(t, err, errMailUnavailable)
+
+		_, err = repo.GetByUsername(models.DefaultOrganization, "alice")
+		assert.ErrorIs(t, err, common.ErrNotFound, "the username is released")
+	})
+}
+
+func TestRegistrationUseCases_VerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		sut, repo, outbox := newTestRegistrationUseCases(t, time.Hour, true)
+
+		id, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		token := lastMailedToken(t, outbox)
+		require.NoError(t, sut.VerifyEmail(usecases.NewVerifyEmailCommand(token)))
+
+		user, err := repo.GetByID(id)
+		require.NoError(t, err)
+		assert.Nil(t, user.EmailVerification)
+
+		err = sut.VerifyEmail(usecases.NewVerifyEmailCommand(token))
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "the token is single-use")
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, outbox := newTestRegistrationUseCases(t, time.Hour, true)
+
+		id, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		token := lastMailedToken(t, outbox)
+
+		for _, invalid := range []string{
+			"",
+			"garbage",
+			id.String() + ".",
+			id.String() + ".forged",
+			token[1:],
+			"00000000-0000-0000-0000-000000000000" + token[len(id.String()):],
+		} {
+			err := sut.VerifyEmail(usecases.NewVerifyEmailCommand(invalid))
+			assert.ErrorIs(t, err, common.ErrInvalidToken, "token %q", invalid)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		sut, _, outbox := newTestRegistrationUseCases(t, -time.Second, true)
+
+		_, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		err = sut.VerifyEmail(usecases.NewVerifyEmailCommand(lastMailedToken(t, outbox)))
+		assert.ErrorIs(t, err, common.ErrInvalidToken)
+	})
+
+	t.Run("resent", func(t *testing.T) {
+		t.Parallel()
+
+		sut, repo, outbox := newTestRegistrationUseCases(t, time.Hour, true)
+
+		id, err := sut.Register(context.Background(), newRegisterCommand(models.DefaultOrganization, "alice"))
+		require.NoError(t, err)
+
+		previous := lastMailedToken(t, outbox)
+
+		user, err := repo.GetByID(id)
+		require.NoError(t, err)
+		require.NoError(t, sut.ResendVerificationEmail(user))
+
+		err = sut.VerifyEmail(usecases.NewVerifyEmailCommand(previous))
+		assert.ErrorIs(t, err, common.ErrInvalidToken, "the previous token is replaced")
+
+		require.NoError(t, sut.VerifyEmail(usecases.NewVerifyEmailCommand(lastMailedToken(t, outbox))))
+
+		user, err = repo.GetByID(id)
+		require.NoError(t, err)
+		require.NoError(t, sut.ResendVerificationEmail(user))
+		assert.Len(t, outbox.Mails(), 2, "verified users get no mail")
+	})
+}
+
+var errMailUnavailable = errors.New("mail unavailable")
+
+type failingMailSender struct{}
+
+func (failingMailSender) Send(_ *models.Mail) error {
+	return errMailUnavailable
+}
+
+var mailedTokenPattern = regexp.MustCompile(`token (\S+)`)
+
+func lastMailedToken(t *testing.T, outbox *infrastructure.MemoryOutbox) string {
+	t.Helper()
+
+	mails := outbox.Mails()
+	require.NotEmpty(t, mails)
+
+	match := mailedTokenPattern.FindStringSubmatch(mails[len(mails)-1].Body)
+	require.Len(t, match, 2)
+
+	return match[1]
+}
+
+func newRegisterCommand(organization string, username string) *usecases.RegisterCommand {
+	return usecases.NewRegisterCommand(organization, username, username+"@corp.com", "password")
+}
+
+func newTestRegistrationUseCases(
+	t *testing.T,
+	verificationTTL time.Duration,
+	enabled bool,
+) (*usecases.RegistrationUseCases, *infrastructure.Repository, *infrastructure.MemoryOutbox) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	outbox := infrastructure.NewMemoryOutbox()
+
+	return usecases.NewRegistrationUseCases(
+		usecases.NewUserUseCases(
+			repo,
+			repo,
+			usecases.NewPageTokenCodec([]byte("secret")),
+			newTestPasswordHasher(t),
+			nil,
+			nil,
+		),
+		usecases.NewOrganizationUseCases(repo),
+		usecases.NewEmailVerifications(outbox, verificationTTL),
+		enabled,
+	), repo, outbox
+}
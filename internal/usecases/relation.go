@@ -0,0 +1,261 @@
+package usecases
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/relation"
+)
+
+var (
+	ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+	// ErrConsistencyTokenAhead means that the tuples are older than the consistency token,
+	// for example because the token was issued before an in-memory repository was restarted.
+	ErrConsistencyTokenAhead = errors.New("consistency token is ahead of the tuples")
+)
+
+// maxTupleChanges bounds the writes and deletes of a single WriteTuples call.
+const maxTupleChanges = 100
+
+// RelationUseCases stores relation tuples and evaluates them according to the namespace schema.
+// Every organization has its own tuples, and commands and queries only see the tuples of their organization.
+// Every result carries a consistency token, the opaque revision of the tuples it was computed from.
+type RelationUseCases struct {
+	tuples    TupleRepository
+	schema    *relation.Schema
+	evaluator *relation.Evaluator
+}
+
+func NewRelationUseCases(tuples TupleRepository, schema *relation.Schema) *RelationUseCases {
+	return &RelationUseCases{
+		tuples:    tuples,
+		schema:    schema,
+		evaluator: relation.NewEvaluator(schema, tuples),
+	}
+}
+
+type WriteTuplesCommand struct {
+	writes  []*models.Tuple
+	deletes []*models.Tuple
+}
+
+// NewWriteTuplesCommand writes and deletes the tuples in the organization, whatever organization they name.
+func NewWriteTuplesCommand(
+	organization string,
+	writes []*models.Tuple,
+	deletes []*models.Tuple,
+) (*WriteTuplesCommand, error) {
+	if changes := len(writes) + len(deletes); changes == 0 || changes > maxTupleChanges {
+		return nil, fmt.Errorf("%w: writes and deletes must contain from 1 to %d tuples", relation.ErrInvalid, maxTupleChanges)
+	}
+
+	return &WriteTuplesCommand{
+		writes:  tuplesInOrganization(organization, writes),
+		deletes: tuplesInOrganization(organization, deletes),
+	}, nil
+}
+
+func tuplesInOrganization(organization string, tuples []*models.Tuple) []*models.Tuple {
+	scoped := make([]*models.Tuple, len(tuples))
+	for i, tuple := range tuples {
+		scoped[i] = &models.Tuple{
+			Organization: organization,
+			Object:       tuple.Object,
+			Relation:     tuple.Relation,
+			Subject:      tuple.Subject,
+		}
+	}
+
+	return scoped
+}
+
+// WriteTuples validates the written tuples against the schema. Deleted tuples are not validated,
+// so that tuples of relations removed from the schema can still be cleaned up.
+func (r *RelationUseCases) WriteTuples(cmd *WriteTuplesCommand) (string, error) {
+	for _, tuple := range cmd.writes {
+		if err := r.schema.ValidateTuple(tuple); err != nil {
+			return "", fmt.Errorf("tuple %s: %w", tuple, err)
+		}
+	}
+
+	revision, err := r.tuples.WriteTuples(cmd.writes, cmd.deletes)
+	if err != nil {
+		return "", fmt.Errorf("failed to write tuples: %w", err)
+	}
+
+	return encodeConsistencyToken(revision), nil
+}
+
+type ReadTuplesQuery struct {
+	filter           *models.TupleFilter
+	consistencyToken string
+}
+
+// NewReadTuplesQuery creates a query of the tuples of the namespace in the organization.
+// The object ID, relation and subject are optional.
+func NewReadTuplesQuery(
+	organization string,
+	namespace string,
+	objectID string,
+	relationName string,
+	rawSubject string,
+	consistencyToken string,
+) (*ReadTuplesQuery, error) {
+	filter := &models.TupleFilter{
+		Organization: organization,
+		Namespace:    namespace,
+		ObjectID:     objectID,
+		Relation:     relationName,
+		Subject:      nil,
+	}
+
+	if rawSubject != "" {
+		subject, err := relation.ParseSubject(rawSubject)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.Subject = &subject
+	}
+
+	return &ReadTuplesQuery{
+		filter:           filter,
+		consistencyToken: consistencyToken,
+	}, nil
+}
+
+func (r *RelationUseCases) ReadTuples(query *ReadTuplesQuery) ([]*models.Tuple, string, error) {
+	token, err := r.consistentRevision(query.consistencyToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tuples, err := r.tuples.ReadTuples(query.filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read tuples: %w", err)
+	}
+
+	return tuples, token, nil
+}
+
+type CheckRelationQuery struct {
+	organization     string
+	object           models.Object
+	relation         string
+	subject          models.Subject
+	consistencyToken string
+}
+
+func NewCheckRelationQuery(
+	organization string,
+	rawObject string,
+	relationName string,
+	rawSubject string,
+	consistencyToken string,
+) (*CheckRelationQuery, error) {
+	tuple, err := relation.ParseTuple(rawObject, relationName, rawSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckRelationQuery{
+		organization:     organization,
+		object:           tuple.Object,
+		relation:         tuple.Relation,
+		subject:          tuple.Subject,
+		consistencyToken: consistencyToken,
+	}, nil
+}
+
+func (r *RelationUseCases) Check(query *CheckRelationQuery) (bool, string, error) {
+	token, err := r.consistentRevision(query.consistencyToken)
+	if err != nil {
+		return false, "", err
+	}
+
+	allowed, err := r.evaluator.InOrganization(query.organization).Check(query.object, query.relation, query.subject)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check %s#%s@%s: %w", query.object, query.relation, query.subject, err)
+	}
+
+	return allowed, token, nil
+}
+
+type ExpandQuery struct {
+	organization     string
+	object           models.Object
+	relation         string
+	consistencyToken string
+}
+
+func NewExpandQuery(
+	organization string,
+	rawObject string,
+	relationName string,
+	consistencyToken string,
+) (*ExpandQuery, error) {
+	object, err := relation.ParseObject(rawObject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpandQuery{
+		organization:     organization,
+		object:           object,
+		relation:         relationName,
+		consistencyToken: consistencyToken,
+	}, nil
+}
+
+func (r *RelationUseCases) Expand(query *ExpandQuery) (*relation.Node, string, error) {
+	token, err := r.consistentRevision(query.consistencyToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tree, err := r.evaluator.InOrganization(query.organization).Expand(query.object, query.relation)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to expand %s#%s: %w", query.object, query.relation, err)
+	}
+
+	return tree, token, nil
+}
+
+// consistentRevision returns the token of the current revision of the tuples, which is at least as fresh
+// as the given token. Every write is applied before it returns its token, so a token can only be ahead
+// of the tuples if they were lost.
+func (r *RelationUseCases) consistentRevision(consistencyToken string) (string, error) {
+	revision, err := r.tuples.TupleRevision()
+	if err != nil {
+		return "", fmt.Errorf("failed to get tuple revision: %w", err)
+	}
+
+	if consistencyToken != "" {
+		required, err := decodeConsistencyToken(consistencyToken)
+		if err != nil {
+			return "", err
+		}
+
+		if required > revision {
+			return "", ErrConsistencyTokenAhead
+		}
+	}
+
+	return encodeConsistencyToken(revision), nil
+}
+
+func encodeConsistencyToken(revision uint64) string {
+	return base64.RawURLEncoding.EncodeToString(binary.BigEndian.AppendUint64(nil, revision))
+}
+
+func decodeConsistencyToken(token string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != 8 { //nolint:gomnd
+		return 0, ErrInvalidConsistencyToken
+	}
+
+	return binary.BigEndian.Uint64(raw), nil
+}
@@ -0,0 +1,151 @@
+package usecases_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/relation"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+const relationSchema = `
+namespaces:
+  user: {}
+  group:
+    relations:
+      member: {}
+  document:
+    relations:
+      owner: {}
+      viewer:
+        direct: false
+        computed: [owner]
+`
+
+func TestRelationUseCases(t *testing.T) {
+	t.Parallel()
+
+	schema, err := relation.ParseSchema([]byte(relationSchema))
+	require.NoError(t, err)
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	sut := usecases.NewRelationUseCases(repo, schema)
+
+	check := func(t *testing.T, subject string, consistencyToken string) (bool, string, error) {
+		t.Helper()
+
+		query, err := usecases.NewCheckRelationQuery(
+			models.DefaultOrganization,
+			"document:readme",
+			"viewer",
+			subject,
+			consistencyToken,
+		)
+		require.NoError(t, err)
+
+		return sut.Check(query)
+	}
+
+	initialAllowed, initialToken, err := check(t, "user:alice", "")
+	require.NoError(t, err)
+	assert.False(t, initialAllowed)
+
+	cmd, err := usecases.NewWriteTuplesCommand(models.DefaultOrganization, []*models.Tuple{
+		mustParseTuple(t, "document:readme", "owner", "group:eng#member"),
+		mustParseTuple(t, "group:eng", "member", "user:alice"),
+	}, nil)
+	require.NoError(t, err)
+
+	writeToken, err := sut.WriteTuples(cmd)
+	require.NoError(t, err)
+	assert.NotEqual(t, initialToken, writeToken)
+
+	allowed, token, err := check(t, "user:alice", writeToken)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, writeToken, token)
+
+	t.Run("tuples must match the schema", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, err := usecases.NewWriteTuplesCommand(models.DefaultOrganization, []*models.Tuple{
+			mustParseTuple(t, "document:readme", "viewer", "user:bob"),
+		}, nil)
+		require.NoError(t, err)
+
+		_, err = sut.WriteTuples(cmd)
+		assert.ErrorIs(t, err, relation.ErrInvalid)
+	})
+
+	t.Run("token ahead of the tuples", func(t *testing.T) {
+		t.Parallel()
+
+		fresh, err := infrastructure.NewRepository()
+		require.NoError(t, err)
+
+		query, err := usecases.NewCheckRelationQuery(
+			models.DefaultOrganization,
+			"document:readme",
+			"viewer",
+			"user:alice",
+			writeToken,
+		)
+		require.NoError(t, err)
+
+		_, _, err = usecases.NewRelationUseCases(fresh, schema).Check(query)
+		assert.ErrorIs(t, err, usecases.ErrConsistencyTokenAhead)
+	})
+
+	t.Run("other organizations", func(t *testing.T) {
+		t.Parallel()
+
+		query, err := usecases.NewCheckRelationQuery("acme", "document:readme", "viewer", "user:alice", writeToken)
+		require.NoError(t, err)
+
+		allowed, _, err := sut.Check(query)
+		require.NoError(t, err)
+		assert.False(t, allowed, "tuples of another organization do not apply")
+
+		readQuery, err := usecases.NewReadTuplesQuery("acme", "document", "", "", "", "")
+		require.NoError(t, err)
+
+		tuples, _, err := sut.ReadTuples(readQuery)
+		require.NoError(t, err)
+		assert.Empty(t, tuples)
+
+		forged := mustParseTuple(t, "document:readme", "owner", "user:mallory")
+		forged.Organization = models.DefaultOrganization
+
+		cmd, err := usecases.NewWriteTuplesCommand("acme", []*models.Tuple{forged}, nil)
+		require.NoError(t, err)
+
+		_, err = sut.WriteTuples(cmd)
+		require.NoError(t, err)
+
+		allowed, _, err = check(t, "user:mallory", "")
+		require.NoError(t, err)
+		assert.False(t, allowed, "tuples are written to the organization of the command")
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := check(t, "user:alice", "not a token")
+		assert.ErrorIs(t, err, usecases.ErrInvalidConsistencyToken)
+	})
+}
+
+func mustParseTuple(t *testing.T, object string, relationName string, subject string) *models.Tuple {
+	t.Helper()
+
+	tuple, err := relation.ParseTuple(object, relationName, subject)
+	require.NoError(t, err)
+
+	return tuple
+}
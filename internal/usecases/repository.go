@@ -0,0 +1,90 @@
+package usecases
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+// UserRepository is a storage of users. Usernames and emails are unique within an organization.
+// Every implementation must pass the conformance suite from the infrastructure/repositorytest package.
+type UserRepository interface {
+	// Save creates the user if its version is zero, or replaces it if its version matches the stored one,
+	// and assigns the new version to the user. A version mismatch fails with common.ErrConflict.
+	Save(user *models.User) error
+	GetByID(id uuid.UUID) (*models.User, error)
+	GetByUsername(organization string, username string) (*models.User, error)
+	GetAll() ([]*models.User, error)
+	// List returns a page of users in the order of the query, starting after the position of the query.
+	List(query *models.UserListQuery) (*models.UserListPage, error)
+	// Delete removes the user if its version matches expectedVersion, or unconditionally if it is zero.
+	Delete(id uuid.UUID, expectedVersion uint64) error
+}
+
+// RefreshTokenRepository is a storage of refresh tokens grouped in families.
+// Deleting a user must delete all refresh tokens of the user.
+type RefreshTokenRepository interface {
+	// SaveRefreshToken starts a new family with the token. It fails with common.ErrNotFound if the user does not exist.
+	SaveRefreshToken(token *models.RefreshToken) error
+	GetRefreshToken(hash []byte) (*models.RefreshToken, error)
+	// RotateRefreshToken marks the token with the previous hash as rotated and adds the next token to its family.
+	// It fails with common.ErrConflict if the previous token has already been rotated.
+	RotateRefreshToken(previousHash []byte, next *models.RefreshToken) error
+	DeleteRefreshTokenFamily(familyID uuid.UUID) error
+	// DeleteRefreshTokensOfUser deletes every family of the user. A user without refresh tokens is not an error.
+	DeleteRefreshTokensOfUser(userID uuid.UUID) error
+}
+
+// APIKeyRepository is a storage of API keys. Deleting a user must delete all API keys of the user.
+type APIKeyRepository interface {
+	// SaveAPIKey creates the key. It fails with common.ErrNotFound if the owner does not exist.
+	SaveAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(hash []byte) (*models.APIKey, error)
+	// ListAPIKeys returns the keys of the user, oldest first.
+	ListAPIKeys(userID uuid.UUID) ([]*models.APIKey, error)
+	DeleteAPIKey(id uuid.UUID) error
+}
+
+// RoleRepository is a storage of custom roles. Built-in roles are not stored.
+type RoleRepository interface {
+	// SaveRole creates the role. It fails with common.ErrAlreadyExists if a role with the same name exists.
+	SaveRole(role *models.Role) error
+	GetRole(name string) (*models.Role, error)
+	// ListRoles returns all roles ordered by name.
+	ListRoles() ([]*models.Role, error)
+}
+
+// OrganizationRepository is a storage of organizations. The default organization is not stored.
+type OrganizationRepository interface {
+	// SaveOrganization creates the organization.
+	// It fails with common.ErrAlreadyExists if an organization with the same name exists.
+	SaveOrganization(organization *models.Organization) error
+	GetOrganization(name string) (*models.Organization, error)
+	// ListOrganizations returns all organizations ordered by name.
+	ListOrganizations() ([]*models.Organization, error)
+}
+
+// TupleRepository is a storage of relation tuples. Deleting a user must delete the tuples of user:<id>.
+type TupleRepository interface {
+	// WriteTuples atomically deletes and then writes the tuples, and returns the revision after the change.
+	// Writing an existing tuple or deleting a missing one is not an error.
+	WriteTuples(writes []*models.Tuple, deletes []*models.Tuple) (uint64, error)
+	// ReadTuples returns the tuples that match the filter, ordered by object, relation and subject.
+	ReadTuples(filter *models.TupleFilter) ([]*models.Tuple, error)
+	// TupleRevision returns the revision of the last change of the tuples. Revisions only increase.
+	TupleRevision() (uint64, error)
+}
+
+// GroupRepository is a storage of groups. Deleting a user or a group must remove it from the groups it is a member of.
+type GroupRepository interface {
+	// SaveGroup creates the group if its version is zero, or replaces it if its version matches the stored one,
+	// and assigns the new version to the group. A version mismatch fails with common.ErrConflict,
+	// and another group with the same name in the organization with common.ErrAlreadyExists.
+	SaveGroup(group *models.Group) error
+	GetGroup(id uuid.UUID) (*models.Group, error)
+	// ListGroups returns all groups ordered by name.
+	ListGroups() ([]*models.Group, error)
+	// GroupsOfMember returns the groups the user or group with the ID is a direct member of, ordered by name.
+	GroupsOfMember(memberID uuid.UUID) ([]*models.Group, error)
+	DeleteGroup(id uuid.UUID) error
+}
@@ -0,0 +1,354 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// roleNamePattern allows names such as "support" or "billing.readers".
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`) //nolint:gochecknoglobals
+
+type RoleUseCases struct {
+	roles  RoleRepository
+	users  UserRepository
+	groups GroupRepository
+}
+
+func NewRoleUseCases(roles RoleRepository, users UserRepository, groups GroupRepository) *RoleUseCases {
+	return &RoleUseCases{
+		roles:  roles,
+		users:  users,
+		groups: groups,
+	}
+}
+
+type CreateRoleCommand struct {
+	name        string
+	permissions []models.Permission
+}
+
+func NewCreateRoleCommand(name string, permissions []string) (*CreateRoleCommand, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, fmt.Errorf(
+			"%w: name must start with a lowercase letter and consist of lowercase letters, digits, '_', '.' or '-'",
+			ErrInvalidRole,
+		)
+	}
+
+	known := models.Permissions()
+	parsed := make([]models.Permission, 0, len(permissions))
+
+	for _, raw := range permissions {
+		permission := models.Permission(raw)
+		if !slices.Contains(known, permission) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidRole, raw)
+		}
+
+		if !slices.Contains(parsed, permission) {
+			parsed = append(parsed, permission)
+		}
+	}
+
+	return &CreateRoleCommand{
+		name:        name,
+		permissions: parsed,
+	}, nil
+}
+
+// CreateRole fails with common.ErrAlreadyExists if a custom or built-in role with the same name exists.
+func (r *RoleUseCases) CreateRole(cmd *CreateRoleCommand) (*models.Role, error) {
+	if cmd.name == models.SuperuserRole || cmd.name == models.OrgAdminRole {
+		return nil, fmt.Errorf("%w: role %q is built-in", common.ErrAlreadyExists, cmd.name)
+	}
+
+	role := &models.Role{
+		Name:        cmd.name,
+		Permissions: cmd.permissions,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := r.roles.SaveRole(role); err != nil {
+		return nil, fmt.Errorf("failed to save role: %w", err)
+	}
+
+	return role, nil
+}
+
+// ListRoles returns the built-in roles followed by the custom roles ordered by name.
+func (r *RoleUseCases) ListRoles() ([]*models.Role, error) {
+	custom, err := r.roles.ListRoles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return append([]*models.Role{newSuperuserRole(), newOrgAdminRole()}, custom...), nil
+}
+
+type AssignRoleCommand struct {
+	userID uuid.UUID
+	role   string
+}
+
+func NewAssignRoleCommand(rawUserID string, role string) (*AssignRoleCommand, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return &AssignRoleCommand{
+		userID: userID,
+		role:   role,
+	}, nil
+}
+
+// AssignRole is a no-op if the user already has the role.
+// It fails with ErrInvalidRole if the role does not exist.
+func (r *RoleUseCases) AssignRole(cmd *AssignRoleCommand) error {
+	if _, err := r.getRole(cmd.role); err != nil {
+		return err
+	}
+
+	return r.updateRoles(cmd.userID, func(roles []string) []string {
+		if slices.Contains(roles, cmd.role) {
+			return roles
+		}
+
+		return append(slices.Clone(roles), cmd.role)
+	})
+}
+
+type UnassignRoleCommand struct {
+	userID uuid.UUID
+	role   string
+}
+
+func NewUnassignRoleCommand(rawUserID string, role string) (*UnassignRoleCommand, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return &UnassignRoleCommand{
+		userID: userID,
+		role:   role,
+	}, nil
+}
+
+// UnassignRole is a no-op if the user does not have the role.
+func (r *RoleUseCases) UnassignRole(cmd *UnassignRoleCommand) error {
+	return r.updateRoles(cmd.userID, func(roles []string) []string {
+		return slices.DeleteFunc(slices.Clone(roles), func(role string) bool {
+			return role == cmd.role
+		})
+	})
+}
+
+type ListPermissionsQuery struct {
+	userID uuid.UUID
+}
+
+func NewListPermissionsQuery(rawUserID string) (*ListPermissionsQuery, error) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return &ListPermissionsQuery{
+		userID: userID,
+	}, nil
+}
+
+// ListPermissions returns the effective permissions of the user.
+func (r *RoleUseCases) ListPermissions(query *ListPermissionsQuery) ([]models.Permission, error) {
+	user, err := r.users.GetByID(query.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id %q: %w", query.userID, err)
+	}
+
+	return r.EffectivePermissions(user)
+}
+
+// EffectiveRoles returns the roles assigned to the user followed by the roles granted to the groups
+// the user is a member of, directly or through nested groups, without duplicates.
+// Groups are visited breadth-first, nearest first, and every group only once, so cycles are harmless.
+func (r *RoleUseCases) EffectiveRoles(user *models.User) ([]string, error) {
+	return r.effectiveRoles(user.Roles, user.ID)
+}
+
+// effectiveRoles returns the assigned roles of the user or group with the ID
+// followed by the roles of the groups it is a member of, in the order of EffectiveRoles.
+func (r *RoleUseCases) effectiveRoles(assigned []string, memberID uuid.UUID) ([]string, error) {
+	roles := slices.Clone(assigned)
+	visited := make(map[uuid.UUID]struct{})
+	members := []uuid.UUID{memberID}
+
+	for len(members) > 0 {
+		memberID := members[0]
+		members = members[1:]
+
+		groups, err := r.groups.GroupsOfMember(memberID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get groups of member %q: %w", memberID, err)
+		}
+
+		for _, group := range groups {
+			if _, ok := visited[group.ID]; ok {
+				continue
+			}
+
+			visited[group.ID] = struct{}{}
+			members = append(members, group.ID)
+
+			for _, role := range group.Roles {
+				if !slices.Contains(roles, role) {
+					roles = append(roles, role)
+				}
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+// EffectivePermissions returns the union of the permissions of the user's effective roles
+// in the order of models.Permissions. Roles that do not exist grant nothing.
+func (r *RoleUseCases) EffectivePermissions(user *models.User) ([]models.Permission, error) {
+	roles, err := r.EffectiveRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make(map[models.Permission]struct{})
+
+	for _, name := range roles {
+		role, err := r.getRole(name)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidRole):
+			continue
+		default:
+			return nil, err
+		}
+
+		for _, permission := range role.Permissions {
+			granted[permission] = struct{}{}
+		}
+	}
+
+	permissions := make([]models.Permission, 0, len(granted))
+	for _, permission := range models.Permissions() {
+		if _, ok := granted[permission]; ok {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return permissions, nil
+}
+
+// IsSuperuser reports whether one of the effective roles of the user is the superuser role,
+// which lets the user act in every organization.
+func (r *RoleUseCases) IsSuperuser(user *models.User) (bool, error) {
+	roles, err := r.EffectiveRoles(user)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(roles, models.SuperuserRole), nil
+}
+
+// GrantingRoles returns the effective roles of the user that grant the permission, in the order of EffectiveRoles.
+func (r *RoleUseCases) GrantingRoles(user *models.User, permission models.Permission) ([]string, error) {
+	roles, err := r.EffectiveRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	granting := make([]string, 0, len(roles))
+
+	for _, name := range roles {
+		role, err := r.getRole(name)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidRole):
+			continue
+		default:
+			return nil, err
+		}
+
+		if slices.Contains(role.Permissions, permission) {
+			granting = append(granting, name)
+		}
+	}
+
+	return granting, nil
+}
+
+// getRole fails with ErrInvalidRole if neither a built-in nor a custom role has the name.
+func (r *RoleUseCases) getRole(name string) (*models.Role, error) {
+	switch name {
+	case models.SuperuserRole:
+		return newSuperuserRole(), nil
+	case models.OrgAdminRole:
+		return newOrgAdminRole(), nil
+	}
+
+	role, err := r.roles.GetRole(name)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		return nil, fmt.Errorf("%w: role %q does not exist", ErrInvalidRole, name)
+	default:
+		return nil, fmt.Errorf("failed to get role %q: %w", name, err)
+	}
+
+	return role, nil
+}
+
+// updateRoles saves the user with the roles returned by update. The current roles must not be modified in place.
+// It fails with common.ErrConflict if the user is modified concurrently.
+func (r *RoleUseCases) updateRoles(userID uuid.UUID, update func(roles []string) []string) error {
+	existing, err := r.users.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user by id %q: %w", userID, err)
+	}
+
+	roles := update(existing.Roles)
+	if slices.Equal(roles, existing.Roles) {
+		return nil
+	}
+
+	user := *existing
+	user.Roles = roles
+
+	if err := r.users.Save(&user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return nil
+}
+
+func newSuperuserRole() *models.Role {
+	return &models.Role{
+		Name:        models.SuperuserRole,
+		Permissions: models.Permissions(),
+		CreatedAt:   time.Time{},
+	}
+}
+
+func newOrgAdminRole() *models.Role {
+	return &models.Role{
+		Name:        models.OrgAdminRole,
+		Permissions: models.Permissions(),
+		CreatedAt:   time.Time{},
+	}
+}
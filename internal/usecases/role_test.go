@@ -0,0 +1,194 @@
+package usecases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestNewCreateRoleCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		roleName    string
+		permissions []string
+		expectedErr error
+	}{
+		{
+			name:        "valid",
+			roleName:    "billing.readers",
+			permissions: []string{"users.read", "users.read"},
+			expectedErr: nil,
+		},
+		{
+			name:        "without permissions",
+			roleName:    "nobody",
+			permissions: nil,
+			expectedErr: nil,
+		},
+		{
+			name:        "invalid name",
+			roleName:    "Support Team",
+			permissions: []string{"users.read"},
+			expectedErr: usecases.ErrInvalidRole,
+		},
+		{
+			name:        "unknown permission",
+			roleName:    "support",
+			permissions: []string{"users.impersonate"},
+			expectedErr: usecases.ErrInvalidRole,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := usecases.NewCreateRoleCommand(tc.roleName, tc.permissions)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRoleUseCases_CreateRole(t *testing.T) {
+	t.Parallel()
+
+	sut, _ := newTestRoleUseCases(t)
+
+	for _, builtIn := range []string{models.SuperuserRole, models.OrgAdminRole} {
+		cmd, err := usecases.NewCreateRoleCommand(builtIn, []string{"users.read"})
+		require.NoError(t, err)
+
+		_, err = sut.CreateRole(cmd)
+		assert.ErrorIs(t, err, common.ErrAlreadyExists, "built-in roles cannot be overwritten")
+	}
+
+	createRole(t, sut, "support", models.PermissionUsersRead)
+
+	roles, err := sut.ListRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, 3)
+	assert.Equal(t, models.SuperuserRole, roles[0].Name)
+	assert.Equal(t, models.Permissions(), roles[0].Permissions)
+	assert.Equal(t, models.OrgAdminRole, roles[1].Name)
+	assert.Equal(t, models.Permissions(), roles[1].Permissions)
+	assert.Equal(t, "support", roles[2].Name)
+}
+
+func TestRoleUseCases_EffectivePermissions(t *testing.T) {
+	t.Parallel()
+
+	sut, user := newTestRoleUseCases(t)
+
+	createRole(t, sut, "support", models.PermissionUsersWrite, models.PermissionUsersRead)
+	createRole(t, sut, "cleaner", models.PermissionUsersDelete, models.PermissionUsersRead)
+
+	permissions := listPermissions(t, sut, user.ID)
+	assert.Empty(t, permissions)
+
+	assignRole(t, sut, user.ID, "support")
+	assignRole(t, sut, user.ID, "cleaner")
+	assignRole(t, sut, user.ID, "cleaner")
+
+	permissions = listPermissions(t, sut, user.ID)
+	assert.Equal(t, []models.Permission{
+		models.PermissionUsersRead,
+		models.PermissionUsersWrite,
+		models.PermissionUsersDelete,
+	}, permissions)
+
+	cmd, err := usecases.NewUnassignRoleCommand(user.ID.String(), "support")
+	require.NoError(t, err)
+	require.NoError(t, sut.UnassignRole(cmd))
+
+	permissions = listPermissions(t, sut, user.ID)
+	assert.Equal(t, []models.Permission{models.PermissionUsersRead, models.PermissionUsersDelete}, permissions)
+
+	assignRole(t, sut, user.ID, models.SuperuserRole)
+
+	permissions = listPermissions(t, sut, user.ID)
+	assert.Equal(t, models.Permissions(), permissions)
+}
+
+func TestRoleUseCases_AssignRole(t *testing.T) {
+	t.Parallel()
+
+	sut, user := newTestRoleUseCases(t)
+
+	cmd, err := usecases.NewAssignRoleCommand(user.ID.String(), "unknown")
+	require.NoError(t, err)
+	assert.ErrorIs(t, sut.AssignRole(cmd), usecases.ErrInvalidRole)
+
+	cmd, err = usecases.NewAssignRoleCommand(uuid.NewString(), models.SuperuserRole)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sut.AssignRole(cmd), common.ErrNotFound)
+}
+
+func newTestRoleUseCases(t *testing.T) (*usecases.RoleUseCases, *models.User) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	user := &models.User{
+		ID:                uuid.New(),
+		Organization:      models.DefaultOrganization,
+		Username:          "bob",
+		Email:             "bob@example.com",
+		PasswordHash:      nil,
+		Roles:             nil,
+		EmailVerification: nil,
+		PasswordReset:     nil,
+		PasswordChangedAt: time.Time{},
+		CreatedAt:         time.Now().UTC(),
+		Version:           0,
+	}
+	require.NoError(t, repo.Save(user))
+
+	return usecases.NewRoleUseCases(repo, repo, repo), user
+}
+
+func createRole(t *testing.T, sut *usecases.RoleUseCases, name string, permissions ...models.Permission) {
+	t.Helper()
+
+	raw := make([]string, len(permissions))
+	for i, permission := range permissions {
+		raw[i] = string(permission)
+	}
+
+	cmd, err := usecases.NewCreateRoleCommand(name, raw)
+	require.NoError(t, err)
+
+	_, err = sut.CreateRole(cmd)
+	require.NoError(t, err)
+}
+
+func assignRole(t *testing.T, sut *usecases.RoleUseCases, userID uuid.UUID, role string) {
+	t.Helper()
+
+	cmd, err := usecases.NewAssignRoleCommand(userID.String(), role)
+	require.NoError(t, err)
+	require.NoError(t, sut.AssignRole(cmd))
+}
+
+func listPermissions(t *testing.T, sut *usecases.RoleUseCases, userID uuid.UUID) []models.Permission {
+	t.Helper()
+
+	query, err := usecases.NewListPermissionsQuery(userID.String())
+	require.NoError(t, err)
+
+	permissions, err := sut.ListPermissions(query)
+	require.NoError(t, err)
+
+	return permissions
+}
@@ -0,0 +1,100 @@
+package usecases
+
+import (
+	"sync"
+)
+
+const (
+	// unknownUserPassword is hashed to be compared with the passwords of unknown usernames.
+	unknownUserPassword = "password of unknown users"
+	// maxObservedPasswordHashes bounds the counts of the parameters of verified hashes,
+	// so that they follow the hashes of users as they are replaced by hashes of the current parameters.
+	maxObservedPasswordHashes = 1024
+)
+
+// unknownUserPasswordHashes are the hashes that the passwords of unknown usernames are compared with.
+// Since hashes of different algorithms or costs take different times to verify, the hash has the parameters
+// that the users who authenticated recently have most often, such as bcrypt while most users still have
+// bcrypt hashes from before argon2id was configured.
+type unknownUserPasswordHashes struct {
+	countsMu sync.Mutex
+	// counts are the verified hashes of users by their parameters. They are halved once they add up to
+	// maxObservedPasswordHashes.
+	counts map[string]int
+	total  int
+
+	// hashesMu is held while a hash is hashed, without blocking the counts of authenticating users.
+	hashesMu sync.Mutex
+	// hashes of unknownUserPassword by their parameters. The empty parameters are the configured ones.
+	hashes map[string][]byte
+}
+
+func newUnknownUserPasswordHashes() *unknownUserPasswordHashes {
+	return &unknownUserPasswordHashes{
+		countsMu: sync.Mutex{},
+		counts:   make(map[string]int),
+		total:    0,
+		hashesMu: sync.Mutex{},
+		hashes:   make(map[string][]byte),
+	}
+}
+
+// observe counts the parameters of a verified hash of a user.
+func (h *unknownUserPasswordHashes) observe(parameters string) {
+	h.countsMu.Lock()
+	defer h.countsMu.Unlock()
+
+	h.counts[parameters]++
+	h.total++
+
+	if h.total < maxObservedPasswordHashes {
+		return
+	}
+
+	h.total = 0
+	for observed, count := range h.counts {
+		if count/2 == 0 {
+			delete(h.counts, observed)
+
+			continue
+		}
+
+		h.counts[observed] = count / 2
+		h.total += count / 2
+	}
+}
+
+// typicalParameters returns the parameters observed most often, or the empty parameters if none have been.
+func (h *unknownUserPasswordHashes) typicalParameters() string {
+	h.countsMu.Lock()
+	defer h.countsMu.Unlock()
+
+	typical, typicalCount := "", 0
+	for parameters, count := range h.counts {
+		if count > typicalCount || (count == typicalCount && parameters < typical) {
+			typical, typicalCount = parameters, count
+		}
+	}
+
+	return typical
+}
+
+// get returns the hash with the parameters, hashing it with hash the first time it succeeds,
+// so that a saturated PasswordPool does not disable the comparison for good.
+func (h *unknownUserPasswordHashes) get(parameters string, hash func() ([]byte, error)) ([]byte, error) {
+	h.hashesMu.Lock()
+	defer h.hashesMu.Unlock()
+
+	if hashed, ok := h.hashes[parameters]; ok {
+		return hashed, nil
+	}
+
+	hashed, err := hash()
+	if err != nil {
+		return nil, err
+	}
+
+	h.hashes[parameters] = hashed
+
+	return hashed, nil
+}
@@ -1,71 +1,110 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/ScareTrow/grpc_user_auth/internal/common"
-	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
 	"github.com/ScareTrow/grpc_user_auth/internal/models"
 )
 
+// PasswordHasher hashes passwords into encoded hashes that describe their algorithm and parameters.
+type PasswordHasher interface {
+	Hash(ctx context.Context, password string) ([]byte, error)
+	// Verify fails with common.ErrInvalidCredentials if the password does not match the hash, and reports
+	// whether the hash is outdated, which means that it should be replaced by a new hash of the password.
+	Verify(ctx context.Context, hash []byte, password string) (bool, error)
+	// Parameters returns the algorithm and parameters that the hash encodes, without its salt.
+	// Hashes with the same parameters take as long to verify.
+	Parameters(hash []byte) (string, error)
+	// HashWithParameters hashes the password with the algorithm and parameters returned by Parameters.
+	HashWithParameters(ctx context.Context, parameters string, password string) ([]byte, error)
+}
+
 type UserUseCases struct {
-	repo *infrastructure.Repository
+	repo          UserRepository
+	refreshTokens RefreshTokenRepository
+	pageTokens    *PageTokenCodec
+	hasher        PasswordHasher
+	credentials   *CredentialCache
+	verifications *EmailVerifications
+
+	unknownUserPasswordHashes *unknownUserPasswordHashes
 }
 
-func NewUserUseCases(repo *infrastructure.Repository) *UserUseCases {
+// NewUserUseCases remembers verified passwords in the credential cache, or verifies every password if it is nil.
+// Every password is hashed and verified by the hasher, which may be a PasswordPool to bound them.
+// Changed emails must be verified again with a token mailed by verifications, unless it is nil.
+func NewUserUseCases(
+	repo UserRepository,
+	refreshTokens RefreshTokenRepository,
+	pageTokens *PageTokenCodec,
+	hasher PasswordHasher,
+	credentials *CredentialCache,
+	verifications *EmailVerifications,
+) *UserUseCases {
 	return &UserUseCases{
-		repo: repo,
+		repo:          repo,
+		refreshTokens: refreshTokens,
+		pageTokens:    pageTokens,
+		hasher:        hasher,
+		credentials:   credentials,
+		verifications: verifications,
+
+		unknownUserPasswordHashes: newUnknownUserPasswordHashes(),
 	}
 }
 
 type CreateUserCommand struct {
-	username string
-	email    string
-	password string
-	admin    bool
+	organization string
+	username     string
+	email        string
+	password     string
+	roles        []string
 }
 
+// NewCreateUserCommand creates a user with the given roles in the organization.
+// The caller must ensure that the organization and the roles exist.
 func NewCreateUserCommand(
+	organization string,
 	username string,
 	email string,
 	password string,
-	admin bool,
+	roles []string,
 ) *CreateUserCommand {
 	return &CreateUserCommand{
-		username: username,
-		email:    email,
-		password: password,
-		admin:    admin,
+		organization: organization,
+		username:     username,
+		email:        email,
+		password:     password,
+		roles:        roles,
 	}
 }
 
-func (u *UserUseCases) CreateUser(cmd *CreateUserCommand) (uuid.UUID, error) {
-	_, err := u.repo.GetByUsername(cmd.username)
-	switch {
-	case errors.Is(err, common.ErrNotFound):
-	case err == nil:
-		return uuid.UUID{}, fmt.Errorf("%w: user with username %q already exists", common.ErrAlreadyExists, cmd.username)
-	default:
-		return uuid.UUID{}, fmt.Errorf("failed to get user by username %q: %w", cmd.username, err)
-	}
-
+func (u *UserUseCases) CreateUser(ctx context.Context, cmd *CreateUserCommand) (uuid.UUID, error) {
 	id := uuid.New()
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(cmd.password), bcrypt.DefaultCost)
+	passwordHash, err := u.hashPassword(ctx, cmd.password)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to hash password: %w", err)
+		return uuid.UUID{}, err
 	}
 
 	user := &models.User{
-		ID:           id,
-		Username:     cmd.username,
-		Email:        cmd.email,
-		PasswordHash: passwordHash,
-		Admin:        cmd.admin,
+		ID:                id,
+		Organization:      cmd.organization,
+		Username:          cmd.username,
+		Email:             cmd.email,
+		PasswordHash:      passwordHash,
+		Roles:             cmd.roles,
+		EmailVerification: nil,
+		PasswordReset:     nil,
+		PasswordChangedAt: time.Time{},
+		CreatedAt:         time.Now().UTC(),
+		Version:           0,
 	}
 
 	err = u.repo.Save(user)
@@ -100,21 +139,12 @@ func (u *UserUseCases) GetUserByID(query *GetUserByIDQuery) (*models.User, error
 	return user, nil
 }
 
-func (u *UserUseCases) GetAllUsers() ([]*models.User, error) {
-	users, err := u.repo.GetAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all users: %w", err)
-	}
-
-	return users, nil
-}
-
 type UpdateUserCommand struct {
-	id       uuid.UUID
-	username string
-	email    string
-	password string
-	admin    bool
+	id              uuid.UUID
+	username        string
+	email           string
+	password        string
+	expectedVersion uint64
 }
 
 func NewUpdateUserCommand(
@@ -122,83 +152,415 @@ func NewUpdateUserCommand(
 	username string,
 	email string,
 	password string,
-	admin bool,
+	etag string,
 ) (*UpdateUserCommand, error) {
 	userUUID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse id %q: %w", id, err)
 	}
 
+	expectedVersion, err := ParseETag(etag)
+	if err != nil {
+		return nil, err
+	}
+
 	return &UpdateUserCommand{
-		id:       userUUID,
-		username: username,
-		email:    email,
-		password: password,
-		admin:    admin,
+		id:              userUUID,
+		username:        username,
+		email:           email,
+		password:        password,
+		expectedVersion: expectedVersion,
 	}, nil
 }
 
-func (u *UserUseCases) UpdateUser(cmd *UpdateUserCommand) error {
-	_, err := u.repo.GetByID(cmd.id)
+// UpdateUser replaces the username, email and password of the user. The organization and roles are kept,
+// and so is the pending email verification unless the email changes, which must be verified again.
+func (u *UserUseCases) UpdateUser(ctx context.Context, cmd *UpdateUserCommand) error {
+	passwordHash, err := u.hashPassword(ctx, cmd.password)
 	if err != nil {
-		return fmt.Errorf("failed to get user by id %q: %w", cmd.id, err)
+		return err
+	}
+
+	var updated *models.User
+	var verificationToken string
+
+	err = u.updateUser(cmd.id, cmd.expectedVersion, func(user *models.User) error {
+		updated = user
+		user.Username = cmd.username
+		user.PasswordHash = passwordHash
+		user.PasswordChangedAt = time.Now().UTC()
+
+		var err error
+		verificationToken, err = u.changeEmail(user, cmd.email)
+
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(cmd.password), bcrypt.DefaultCost)
+	return u.sendVerification(updated, verificationToken)
+}
+
+type UpdateProfileCommand struct {
+	id              uuid.UUID
+	username        string
+	email           string
+	expectedVersion uint64
+}
+
+// NewUpdateProfileCommand changes the profile of the user with the ID, which must be the authenticated user.
+func NewUpdateProfileCommand(id uuid.UUID, username string, email string, etag string) (*UpdateProfileCommand, error) {
+	expectedVersion, err := ParseETag(etag)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
-	user := &models.User{
-		ID:           cmd.id,
-		Username:     cmd.username,
-		Email:        cmd.email,
-		PasswordHash: passwordHash,
-		Admin:        cmd.admin,
+	return &UpdateProfileCommand{
+		id:              id,
+		username:        username,
+		email:           email,
+		expectedVersion: expectedVersion,
+	}, nil
+}
+
+// UpdateProfile replaces the username and email of the user. Everything else is kept,
+// except that a changed email must be verified again.
+func (u *UserUseCases) UpdateProfile(cmd *UpdateProfileCommand) error {
+	var updated *models.User
+	var verificationToken string
+
+	err := u.updateUser(cmd.id, cmd.expectedVersion, func(user *models.User) error {
+		updated = user
+		user.Username = cmd.username
+
+		var err error
+		verificationToken, err = u.changeEmail(user, cmd.email)
+
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	err = u.repo.Save(user)
+	return u.sendVerification(updated, verificationToken)
+}
+
+// changeEmail replaces the email of the user. A changed email gets a pending verification, whose token is returned.
+// The token is empty if the email is the same or changed emails are not verified.
+func (u *UserUseCases) changeEmail(user *models.User, email string) (string, error) {
+	if email == user.Email || u.verifications == nil {
+		user.Email = email
+
+		return "", nil
+	}
+
+	token, verification, err := u.verifications.newVerification(user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	user.Email = email
+	user.EmailVerification = verification
+
+	return token, nil
+}
+
+// sendVerification mails the verification token returned by changeEmail to the saved user, if there is one.
+// If the mail fails, the user can have the token mailed again with ResendVerificationEmail.
+func (u *UserUseCases) sendVerification(user *models.User, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	return u.verifications.send(user, token)
+}
+
+type ChangePasswordCommand struct {
+	id              uuid.UUID
+	currentPassword string
+	newPassword     string
+}
+
+// NewChangePasswordCommand changes the password of the user with the ID, which must be the authenticated user.
+func NewChangePasswordCommand(id uuid.UUID, currentPassword string, newPassword string) *ChangePasswordCommand {
+	return &ChangePasswordCommand{
+		id:              id,
+		currentPassword: currentPassword,
+		newPassword:     newPassword,
+	}
+}
+
+// ChangePassword fails with common.ErrInvalidCredentials if the current password is wrong, in which case
+// the new password is not hashed, and with common.ErrConflict if the user changes while the passwords are hashed.
+func (u *UserUseCases) ChangePassword(ctx context.Context, cmd *ChangePasswordCommand) error {
+	user, err := u.repo.GetByID(cmd.id)
+	if err != nil {
+		return fmt.Errorf("failed to get user by id %q: %w", cmd.id, err)
+	}
+
+	if _, err := u.checkPassword(ctx, user, cmd.currentPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := u.hashPassword(ctx, cmd.newPassword)
 	if err != nil {
+		return err
+	}
+
+	return u.updateUser(cmd.id, user.Version, func(user *models.User) error {
+		user.PasswordHash = passwordHash
+		user.PasswordChangedAt = time.Now().UTC()
+
+		return nil
+	})
+}
+
+// updateUser saves a copy of the user changed by update. It fails with common.ErrConflict
+// if the user is modified concurrently or, if expectedVersion is not zero, has another version.
+// The verified passwords of the user are forgotten, and if update changes the password,
+// so are its refresh tokens, which could otherwise outlive the password they were issued for.
+func (u *UserUseCases) updateUser(id uuid.UUID, expectedVersion uint64, update func(user *models.User) error) error {
+	existing, err := u.repo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get user by id %q: %w", id, err)
+	}
+
+	user := *existing
+	if expectedVersion != 0 {
+		user.Version = expectedVersion
+	}
+
+	if err := update(&user); err != nil {
+		return err
+	}
+
+	if err := u.repo.Save(&user); err != nil {
 		return fmt.Errorf("failed to save user: %w", err)
 	}
 
+	u.forgetCredentials(id)
+
+	if !user.PasswordChangedAt.Equal(existing.PasswordChangedAt) {
+		if err := u.refreshTokens.DeleteRefreshTokensOfUser(id); err != nil {
+			return fmt.Errorf("failed to delete refresh tokens of user %q: %w", id, err)
+		}
+	}
+
 	return nil
 }
 
 type DeleteUserCommand struct {
-	id uuid.UUID
+	id              uuid.UUID
+	expectedVersion uint64
 }
 
-func NewDeleteUserCommand(id string) (*DeleteUserCommand, error) {
+func NewDeleteUserCommand(id string, etag string) (*DeleteUserCommand, error) {
 	userUUID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse id %q: %w", id, err)
 	}
 
+	expectedVersion, err := ParseETag(etag)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DeleteUserCommand{
-		id: userUUID,
+		id:              userUUID,
+		expectedVersion: expectedVersion,
 	}, nil
 }
 
 func (u *UserUseCases) DeleteUser(cmd *DeleteUserCommand) error {
-	err := u.repo.Delete(cmd.id)
+	err := u.repo.Delete(cmd.id, cmd.expectedVersion)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
+	u.forgetCredentials(cmd.id)
+
 	return nil
 }
 
-func (u *UserUseCases) AuthenticateUser(username string, rawPassword string) (*models.User, error) {
-	user, err := u.repo.GetByUsername(username)
+// AuthenticateUser checks the password of the user with the username in the organization.
+// An outdated password hash is replaced by a hash with the current algorithm and parameters.
+// Passwords found in the credential cache are not checked again.
+func (u *UserUseCases) AuthenticateUser(
+	ctx context.Context,
+	organization string,
+	username string,
+	rawPassword string,
+) (*models.User, error) {
+	if u.credentials == nil {
+		return u.verifyUserPassword(ctx, organization, username, rawPassword)
+	}
+
+	key := u.credentials.key(organization, username, rawPassword)
+	if user, ok := u.cachedUser(key); ok {
+		return user, nil
+	}
+
+	user, err := u.verifyUserPassword(ctx, organization, username, rawPassword)
 	if err != nil {
+		return nil, err
+	}
+
+	u.credentials.put(key, user.ID, user.Version)
+
+	return user, nil
+}
+
+// cachedUser returns the user whose password has been verified with the key, unless the user has changed since.
+func (u *UserUseCases) cachedUser(key credentialKey) (*models.User, bool) {
+	userID, version, ok := u.credentials.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	user, err := u.repo.GetByID(userID)
+	if err != nil || user.Version != version {
+		u.credentials.remove(key)
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+// forgetCredentials removes the verified passwords of the user from the credential cache.
+func (u *UserUseCases) forgetCredentials(id uuid.UUID) {
+	if u.credentials != nil {
+		u.credentials.invalidateUser(id)
+	}
+}
+
+func (u *UserUseCases) verifyUserPassword(
+	ctx context.Context,
+	organization string,
+	username string,
+	rawPassword string,
+) (*models.User, error) {
+	user, err := u.repo.GetByUsername(organization, username)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrNotFound):
+		// The password is compared anyway, so that unknown usernames take as long to reject as wrong passwords.
+		if err := u.checkUnknownUserPassword(ctx, rawPassword); err != nil {
+			return nil, err
+		}
+
+		return nil, wrongUsernameOrPassword(organization, username)
+	default:
 		return nil, fmt.Errorf("failed to get user by username %q: %w", username, err)
 	}
 
-	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(rawPassword))
-	if err != nil {
-		return nil, fmt.Errorf("failed to compare password hash: %w", err)
+	// Hashes that cannot be parsed fail to be verified below as well.
+	if parameters, err := u.hasher.Parameters(user.PasswordHash); err == nil {
+		u.unknownUserPasswordHashes.observe(parameters)
+	}
+
+	outdated, err := u.checkPassword(ctx, user, rawPassword)
+	switch {
+	case err == nil:
+	case errors.Is(err, common.ErrInvalidCredentials):
+		return nil, wrongUsernameOrPassword(organization, username)
+	default:
+		return nil, err
+	}
+
+	if outdated {
+		return u.rehashPassword(ctx, user, rawPassword)
 	}
 
 	return user, nil
 }
+
+// wrongUsernameOrPassword is the error of unknown usernames and of wrong passwords alike,
+// so that not even the error chain tells them apart.
+func wrongUsernameOrPassword(organization string, username string) error {
+	return fmt.Errorf(
+		"%w: wrong username or password of %q in organization %q",
+		common.ErrInvalidCredentials, username, organization,
+	)
+}
+
+// checkUnknownUserPassword compares the password with a hash that no password matches, whose parameters
+// are typical of the hashes of users. It only fails if the password could not be compared,
+// such as when the hasher is a saturated PasswordPool.
+func (u *UserUseCases) checkUnknownUserPassword(ctx context.Context, rawPassword string) error {
+	parameters := u.unknownUserPasswordHashes.typicalParameters()
+
+	hash, err := u.unknownUserPasswordHashes.get(parameters, func() ([]byte, error) {
+		if parameters == "" {
+			return u.hashPassword(ctx, unknownUserPassword)
+		}
+
+		hash, err := u.hasher.HashWithParameters(ctx, parameters, unknownUserPassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password with parameters %q: %w", parameters, err)
+		}
+
+		return hash, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hash password of unknown users: %w", err)
+	}
+
+	_, err = u.hasher.Verify(ctx, hash, rawPassword)
+	switch {
+	case err == nil, errors.Is(err, common.ErrInvalidCredentials):
+		return nil
+	default:
+		return fmt.Errorf("failed to verify password hash of unknown users: %w", err)
+	}
+}
+
+// rehashPassword saves the user with a new hash of the verified password. If the user has been changed
+// in the meantime, the user is returned as is, and the hash is replaced on a later authentication.
+func (u *UserUseCases) rehashPassword(
+	ctx context.Context,
+	user *models.User,
+	rawPassword string,
+) (*models.User, error) {
+	passwordHash, err := u.hashPassword(ctx, rawPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *user
+	updated.PasswordHash = passwordHash
+
+	err = u.repo.Save(&updated)
+	switch {
+	case err == nil:
+		return &updated, nil
+	case errors.Is(err, common.ErrConflict), errors.Is(err, common.ErrNotFound):
+		return user, nil
+	default:
+		return nil, fmt.Errorf("failed to save rehashed password of user %q: %w", user.ID, err)
+	}
+}
+
+// checkPassword fails with common.ErrInvalidCredentials if the password is not the password of the user,
+// and reports whether the password hash of the user is outdated.
+func (u *UserUseCases) checkPassword(ctx context.Context, user *models.User, rawPassword string) (bool, error) {
+	outdated, err := u.hasher.Verify(ctx, user.PasswordHash, rawPassword)
+	switch {
+	case err == nil:
+		return outdated, nil
+	case errors.Is(err, common.ErrInvalidCredentials):
+		return false, fmt.Errorf("%w: wrong password of user %q", common.ErrInvalidCredentials, user.Username)
+	default:
+		return false, fmt.Errorf("failed to verify password hash: %w", err)
+	}
+}
+
+func (u *UserUseCases) hashPassword(ctx context.Context, password string) ([]byte, error) {
+	passwordHash, err := u.hasher.Hash(ctx, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return passwordHash, nil
+}
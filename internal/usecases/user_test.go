@@ -0,0 +1,358 @@
+package usecases_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ScareTrow/grpc_user_auth/internal/common"
+	"github.com/ScareTrow/grpc_user_auth/internal/infrastructure"
+	"github.com/ScareTrow/grpc_user_auth/internal/models"
+	"github.com/ScareTrow/grpc_user_auth/internal/usecases"
+)
+
+func TestUserUseCases_UpdateProfile(t *testing.T) {
+	t.Parallel()
+
+	sut, repo := newTestUserUseCases(t)
+	aliceID := createUser(t, sut, "alice")
+	createUser(t, sut, "bob")
+
+	user, err := repo.GetByID(aliceID)
+	require.NoError(t, err)
+
+	cmd, err := usecases.NewUpdateProfileCommand(aliceID, "alicia", "alicia@corp.com", usecases.FormatETag(user.Version))
+	require.NoError(t, err)
+	require.NoError(t, sut.UpdateProfile(cmd))
+
+	user, err = repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, "alicia", user.Username)
+	assert.Equal(t, "alicia@corp.com", user.Email)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alicia", "password")
+	assert.NoError(t, err, "the password is kept")
+
+	err = sut.UpdateProfile(cmd)
+	assert.ErrorIs(t, err, common.ErrConflict, "the etag is stale")
+
+	cmd, err = usecases.NewUpdateProfileCommand(aliceID, "bob", "alicia@corp.com", "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, sut.UpdateProfile(cmd), common.ErrAlreadyExists)
+
+	cmd, err = usecases.NewUpdateProfileCommand(uuid.New(), "carol", "carol@corp.com", "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, sut.UpdateProfile(cmd), common.ErrNotFound)
+
+	_, err = usecases.NewUpdateProfileCommand(aliceID, "alicia", "alicia@corp.com", "garbage")
+	assert.ErrorIs(t, err, usecases.ErrInvalidETag)
+}
+
+func TestUserUseCases_ChangeEmail(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	outbox := infrastructure.NewMemoryOutbox()
+	sut := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		usecases.NewEmailVerifications(outbox, time.Hour),
+	)
+	aliceID := createUser(t, sut, "alice")
+
+	cmd, err := usecases.NewUpdateProfileCommand(aliceID, "alicia", "alice@corp.com", "")
+	require.NoError(t, err)
+	require.NoError(t, sut.UpdateProfile(cmd))
+
+	user, err := repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.Nil(t, user.EmailVerification, "the email is unchanged")
+	assert.Empty(t, outbox.Mails())
+
+	cmd, err = usecases.NewUpdateProfileCommand(aliceID, "alicia", "alicia@corp.com", "")
+	require.NoError(t, err)
+	require.NoError(t, sut.UpdateProfile(cmd))
+
+	user, err = repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.NotNil(t, user.EmailVerification, "the changed email must be verified again")
+
+	mails := outbox.Mails()
+	require.Len(t, mails, 1)
+	assert.Equal(t, "alicia@corp.com", mails[0].To)
+	profileToken := lastMailedToken(t, outbox)
+
+	updateCmd, err := usecases.NewUpdateUserCommand(aliceID.String(), "alicia", "a@corp.com", "password", "")
+	require.NoError(t, err)
+	require.NoError(t, sut.UpdateUser(context.Background(), updateCmd))
+
+	mails = outbox.Mails()
+	require.Len(t, mails, 2)
+	assert.Equal(t, "a@corp.com", mails[1].To)
+	assert.NotEqual(t, profileToken, lastMailedToken(t, outbox), "the pending verification is replaced")
+}
+
+func TestUserUseCases_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	sut, _, hasher := newCachingUserUseCases(t, time.Minute, 10)
+	aliceID := createUser(t, sut, "alice")
+	hashes := hasher.hashes.Load()
+
+	err := sut.ChangePassword(context.Background(), usecases.NewChangePasswordCommand(aliceID, "wrong", "new password"))
+	assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+	assert.Equal(t, hashes, hasher.hashes.Load(), "the new password is not hashed")
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+	require.NoError(t, err, "the password is kept after a failed change")
+
+	cmd := usecases.NewChangePasswordCommand(aliceID, "password", "new password")
+	require.NoError(t, sut.ChangePassword(context.Background(), cmd))
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+	assert.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "new password")
+	assert.NoError(t, err)
+
+	cmd = usecases.NewChangePasswordCommand(uuid.New(), "password", "new password")
+	err = sut.ChangePassword(context.Background(), cmd)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+}
+
+func TestUserUseCases_AuthenticateUser(t *testing.T) {
+	t.Parallel()
+
+	sut, _ := newTestUserUseCases(t)
+	createUser(t, sut, "alice")
+
+	_, wrongPasswordErr := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+	assert.ErrorIs(t, wrongPasswordErr, common.ErrInvalidCredentials)
+
+	_, unknownUserErr := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "mallory", "wrong")
+	assert.ErrorIs(t, unknownUserErr, common.ErrInvalidCredentials, "unknown usernames are rejected like wrong passwords")
+	assert.NotErrorIs(t, unknownUserErr, common.ErrNotFound)
+	assert.Equal(
+		t, strings.Replace(wrongPasswordErr.Error(), "alice", "mallory", 1), unknownUserErr.Error(),
+		"the errors differ only by the username",
+	)
+}
+
+func TestUserUseCases_AuthenticateUserRehash(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	bcryptHasher, err := infrastructure.NewPasswordHasher(infrastructure.PasswordHasherConfig{
+		Algorithm: infrastructure.PasswordHashBcrypt,
+		Cost:      bcrypt.MinCost,
+		Memory:    0,
+	})
+	require.NoError(t, err)
+
+	previous := usecases.NewUserUseCases(repo, repo, usecases.NewPageTokenCodec([]byte("secret")), bcryptHasher, nil, nil)
+	aliceID := createUser(t, previous, "alice")
+
+	sut := usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+	require.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+	stored, err := repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(stored.PasswordHash), "$2a$"), "wrong passwords are not rehashed")
+
+	user, err := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+	require.NoError(t, err)
+
+	stored, err = repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(stored.PasswordHash), "$argon2id$"), "the outdated hash is replaced")
+	assert.Equal(t, stored.Version, user.Version)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+	require.NoError(t, err)
+
+	unchanged, err := repo.GetByID(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Version, unchanged.Version, "current hashes are kept")
+
+	_, err = previous.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "password")
+	assert.NoError(t, err, "hashes of other algorithms are still verified")
+}
+
+// The timing is measured without parallel tests competing for the CPU.
+func TestUserUseCases_AuthenticateUnknownUser(t *testing.T) {
+	t.Parallel()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	bcryptHasher, err := infrastructure.NewPasswordHasher(infrastructure.PasswordHasherConfig{
+		Algorithm: infrastructure.PasswordHashBcrypt,
+		Cost:      bcrypt.MinCost,
+		Memory:    0,
+	})
+	require.NoError(t, err)
+
+	previous := usecases.NewUserUseCases(repo, repo, usecases.NewPageTokenCodec([]byte("secret")), bcryptHasher, nil, nil)
+	createUser(t, previous, "alice")
+	createUser(t, previous, "bob")
+
+	hasher := &countingPasswordHasher{
+		PasswordHasher:   newTestPasswordHasher(t),
+		hashes:           atomic.Int64{},
+		verifications:    atomic.Int64{},
+		lastVerifiedHash: atomic.Pointer[[]byte]{},
+	}
+	sut := usecases.NewUserUseCases(repo, repo, usecases.NewPageTokenCodec([]byte("secret")), hasher, nil, nil)
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "mallory", "wrong")
+	require.ErrorIs(t, err, common.ErrInvalidCredentials)
+	assert.True(
+		t, strings.HasPrefix(string(*hasher.lastVerifiedHash.Load()), "$argon2id$"),
+		"without users, the configured algorithm is compared",
+	)
+
+	for _, username := range []string{"alice", "bob"} {
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, username, "wrong")
+		require.ErrorIs(t, err, common.ErrInvalidCredentials)
+	}
+
+	_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "mallory", "wrong")
+	require.ErrorIs(t, err, common.ErrInvalidCredentials)
+	assert.True(
+		t, strings.HasPrefix(string(*hasher.lastVerifiedHash.Load()), "$2a$04$"),
+		"the algorithm and cost of the hashes of users are compared",
+	)
+}
+
+func TestUserUseCases_AuthenticateUserComparesUnknownUsers(t *testing.T) {
+	t.Parallel()
+
+	sut, _, hasher := newCachingUserUseCases(t, time.Minute, 10)
+	createUser(t, sut, "alice")
+
+	_, err := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "alice", "wrong")
+	require.ErrorIs(t, err, common.ErrInvalidCredentials)
+	assert.EqualValues(t, 1, hasher.verifications.Load())
+
+	for i := 0; i < 2; i++ {
+		_, err = sut.AuthenticateUser(context.Background(), models.DefaultOrganization, "mallory", "wrong")
+		require.ErrorIs(t, err, common.ErrInvalidCredentials)
+	}
+
+	assert.EqualValues(
+		t, 3, hasher.verifications.Load(),
+		"the passwords of unknown usernames are verified like wrong passwords, so that they take as long",
+	)
+}
+
+// The timing is measured without parallel tests competing for the CPU.
+//
+//nolint:paralleltest
+func TestUserUseCases_AuthenticateUserTiming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the timing of password hashing is slow to measure")
+	}
+
+	const samples = 15
+	const maxRelativeDifference = 0.2
+
+	sut, _ := newTestUserUseCases(t)
+	createUser(t, sut, "alice")
+
+	measure := func(username string) time.Duration {
+		start := time.Now()
+		_, err := sut.AuthenticateUser(context.Background(), models.DefaultOrganization, username, "wrong")
+		elapsed := time.Since(start)
+
+		require.ErrorIs(t, err, common.ErrInvalidCredentials)
+
+		return elapsed
+	}
+
+	// The samples are interleaved, so that a slowdown of the machine affects both alike.
+	var known, unknown []time.Duration
+	for i := 0; i < samples; i++ {
+		known = append(known, measure("alice"))
+		unknown = append(unknown, measure("mallory"))
+	}
+
+	knownMedian, unknownMedian := median(known), median(unknown)
+	difference := (knownMedian - unknownMedian).Abs()
+
+	assert.Less(
+		t, float64(difference)/float64(knownMedian), maxRelativeDifference,
+		"a wrong password takes %s and an unknown username %s", knownMedian, unknownMedian,
+	)
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	return sorted[len(sorted)/2]
+}
+
+func newTestUserUseCases(t *testing.T) (*usecases.UserUseCases, *infrastructure.Repository) {
+	t.Helper()
+
+	repo, err := infrastructure.NewRepository()
+	require.NoError(t, err)
+
+	return usecases.NewUserUseCases(
+		repo,
+		repo,
+		usecases.NewPageTokenCodec([]byte("secret")),
+		newTestPasswordHasher(t),
+		nil,
+		nil,
+	), repo
+}
+
+func createUser(t *testing.T, sut *usecases.UserUseCases, username string) uuid.UUID {
+	t.Helper()
+
+	id, err := sut.CreateUser(
+		context.Background(),
+		usecases.NewCreateUserCommand(models.DefaultOrganization, username, username+"@corp.com", "password", nil),
+	)
+	require.NoError(t, err)
+
+	return id
+}
+
+func newTestPasswordHasher(t *testing.T) *infrastructure.PasswordHasher {
+	t.Helper()
+
+	hasher, err := infrastructure.NewPasswordHasher(infrastructure.PasswordHasherConfig{
+		Algorithm: infrastructure.PasswordHashArgon2id,
+		Cost:      0,
+		Memory:    0,
+	})
+	require.NoError(t, err)
+
+	return hasher
+}
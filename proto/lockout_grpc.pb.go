@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.24.4
+// source: proto/lockout.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// LockoutServiceClient is the client API for LockoutService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LockoutServiceClient interface {
+	// ListLockouts returns the current lockouts ordered by kind, organization and subject.
+	ListLockouts(ctx context.Context, in *ListLockoutsRequest, opts ...grpc.CallOption) (*ListLockoutsResponse, error)
+	// ClearLockout forgets the failed attempts of a username or an IP address, lifting its lockout.
+	ClearLockout(ctx context.Context, in *ClearLockoutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type lockoutServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLockoutServiceClient(cc grpc.ClientConnInterface) LockoutServiceClient {
+	return &lockoutServiceClient{cc}
+}
+
+func (c *lockoutServiceClient) ListLockouts(ctx context.Context, in *ListLockoutsRequest, opts ...grpc.CallOption) (*ListLockoutsResponse, error) {
+	out := new(ListLockoutsResponse)
+	err := c.cc.Invoke(ctx, "/users.LockoutService/ListLockouts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockoutServiceClient) ClearLockout(ctx context.Context, in *ClearLockoutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/users.LockoutService/ClearLockout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LockoutServiceServer is the server API for LockoutService service.
+// All implementations must embed UnimplementedLockoutServiceServer
+// for forward compatibility
+type LockoutServiceServer interface {
+	// ListLockouts returns the current lockouts ordered by kind, organization and subject.
+	ListLockouts(context.Context, *ListLockoutsRequest) (*ListLockoutsResponse, error)
+	// ClearLockout forgets the failed attempts of a username or an IP address, lifting its lockout.
+	ClearLockout(context.Context, *ClearLockoutRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedLockoutServiceServer()
+}
+
+// UnimplementedLockoutServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedLockoutServiceServer struct {
+}
+
+func (UnimplementedLockoutServiceServer) ListLockouts(context.Context, *ListLockoutsRequest) (*ListLockoutsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLockouts not implemented")
+}
+func (UnimplementedLockoutServiceServer) ClearLockout(context.Context, *ClearLockoutRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearLockout not implemented")
+}
+func (UnimplementedLockoutServiceServer) mustEmbedUnimplementedLockoutServiceServer() {}
+
+// UnsafeLockoutServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LockoutServiceServer will
+// result in compilation errors.
+type UnsafeLockoutServiceServer interface {
+	mustEmbedUnimplementedLockoutServiceServer()
+}
+
+func RegisterLockoutServiceServer(s grpc.ServiceRegistrar, srv LockoutServiceServer) {
+	s.RegisterService(&LockoutService_ServiceDesc, srv)
+}
+
+func _LockoutService_ListLockouts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLockoutsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockoutServiceServer).ListLockouts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.LockoutService/ListLockouts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockoutServiceServer).ListLockouts(ctx, req.(*ListLockoutsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockoutService_ClearLockout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearLockoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockoutServiceServer).ClearLockout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.LockoutService/ClearLockout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockoutServiceServer).ClearLockout(ctx, req.(*ClearLockoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LockoutService_ServiceDesc is the grpc.ServiceDesc for LockoutService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LockoutService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.LockoutService",
+	HandlerType: (*LockoutServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListLockouts",
+			Handler:    _LockoutService_ListLockouts_Handler,
+		},
+		{
+			MethodName: "ClearLockout",
+			Handler:    _LockoutService_ClearLockout_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/lockout.proto",
+}
@@ -0,0 +1,152 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.24.4
+// source: proto/password_reset.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// PasswordResetServiceClient is the client API for PasswordResetService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PasswordResetServiceClient interface {
+	// RequestPasswordReset mails a reset token to the user with the username in the organization named by
+	// the "x-organization" metadata, "default" if not set, invalidating the previous token.
+	// It succeeds whether the user exists or not.
+	RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ResetPassword sets the password of the user the token was mailed to and revokes the refresh tokens
+	// of the user. Every token is valid once, and fails with INVALID_ARGUMENT if it is used, expired or replaced.
+	ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type passwordResetServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPasswordResetServiceClient(cc grpc.ClientConnInterface) PasswordResetServiceClient {
+	return &passwordResetServiceClient{cc}
+}
+
+func (c *passwordResetServiceClient) RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/users.PasswordResetService/RequestPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordResetServiceClient) ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/users.PasswordResetService/ResetPassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PasswordResetServiceServer is the server API for PasswordResetService service.
+// All implementations must embed UnimplementedPasswordResetServiceServer
+// for forward compatibility
+type PasswordResetServiceServer interface {
+	// RequestPasswordReset mails a reset token to the user with the username in the organization named by
+	// the "x-organization" metadata, "default" if not set, invalidating the previous token.
+	// It succeeds whether the user exists or not.
+	RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*emptypb.Empty, error)
+	// ResetPassword sets the password of the user the token was mailed to and revokes the refresh tokens
+	// of the user. Every token is valid once, and fails with INVALID_ARGUMENT if it is used, expired or replaced.
+	ResetPassword(context.Context, *ResetPasswordRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedPasswordResetServiceServer()
+}
+
+// UnimplementedPasswordResetServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPasswordResetServiceServer struct {
+}
+
+func (UnimplementedPasswordResetServiceServer) RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedPasswordResetServiceServer) ResetPassword(context.Context, *ResetPasswordRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedPasswordResetServiceServer) mustEmbedUnimplementedPasswordResetServiceServer() {}
+
+// UnsafePasswordResetServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PasswordResetServiceServer will
+// result in compilation errors.
+type UnsafePasswordResetServiceServer interface {
+	mustEmbedUnimplementedPasswordResetServiceServer()
+}
+
+func RegisterPasswordResetServiceServer(s grpc.ServiceRegistrar, srv PasswordResetServiceServer) {
+	s.RegisterService(&PasswordResetService_ServiceDesc, srv)
+}
+
+func _PasswordResetService_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestPasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordResetServiceServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.PasswordResetService/RequestPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordResetServiceServer).RequestPasswordReset(ctx, req.(*RequestPasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PasswordResetService_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordResetServiceServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.PasswordResetService/ResetPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordResetServiceServer).ResetPassword(ctx, req.(*ResetPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PasswordResetService_ServiceDesc is the grpc.ServiceDesc for PasswordResetService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PasswordResetService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.PasswordResetService",
+	HandlerType: (*PasswordResetServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _PasswordResetService_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _PasswordResetService_ResetPassword_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/password_reset.proto",
+}
@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.24.4
+// source: proto/relation.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RelationServiceClient is the client API for RelationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RelationServiceClient interface {
+	// WriteTuples atomically deletes and then writes the tuples.
+	WriteTuples(ctx context.Context, in *WriteTuplesRequest, opts ...grpc.CallOption) (*WriteTuplesResponse, error)
+	ReadTuples(ctx context.Context, in *ReadTuplesRequest, opts ...grpc.CallOption) (*ReadTuplesResponse, error)
+	// Check answers whether the subject has the relation to the object, directly or as derived by the schema.
+	Check(ctx context.Context, in *CheckRelationRequest, opts ...grpc.CallOption) (*CheckRelationResponse, error)
+	// Expand returns the tree of the subjects that have the relation to the object.
+	Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error)
+}
+
+type relationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRelationServiceClient(cc grpc.ClientConnInterface) RelationServiceClient {
+	return &relationServiceClient{cc}
+}
+
+func (c *relationServiceClient) WriteTuples(ctx context.Context, in *WriteTuplesRequest, opts ...grpc.CallOption) (*WriteTuplesResponse, error) {
+	out := new(WriteTuplesResponse)
+	err := c.cc.Invoke(ctx, "/users.RelationService/WriteTuples", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) ReadTuples(ctx context.Context, in *ReadTuplesRequest, opts ...grpc.CallOption) (*ReadTuplesResponse, error) {
+	out := new(ReadTuplesResponse)
+	err := c.cc.Invoke(ctx, "/users.RelationService/ReadTuples", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) Check(ctx context.Context, in *CheckRelationRequest, opts ...grpc.CallOption) (*CheckRelationResponse, error) {
+	out := new(CheckRelationResponse)
+	err := c.cc.Invoke(ctx, "/users.RelationService/Check", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error) {
+	out := new(ExpandResponse)
+	err := c.cc.Invoke(ctx, "/users.RelationService/Expand", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RelationServiceServer is the server API for RelationService service.
+// All implementations must embed UnimplementedRelationServiceServer
+// for forward compatibility
+type RelationServiceServer interface {
+	// WriteTuples atomically deletes and then writes the tuples.
+	WriteTuples(context.Context, *WriteTuplesRequest) (*WriteTuplesResponse, error)
+	ReadTuples(context.Context, *ReadTuplesRequest) (*ReadTuplesResponse, error)
+	// Check answers whether the subject has the relation to the object, directly or as derived by the schema.
+	Check(context.Context, *CheckRelationRequest) (*CheckRelationResponse, error)
+	// Expand returns the tree of the subjects that have the relation to the object.
+	Expand(context.Context, *ExpandRequest) (*ExpandResponse, error)
+	mustEmbedUnimplementedRelationServiceServer()
+}
+
+// UnimplementedRelationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRelationServiceServer struct {
+}
+
+func (UnimplementedRelationServiceServer) WriteTuples(context.Context, *WriteTuplesRequest) (*WriteTuplesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteTuples not implemented")
+}
+func (UnimplementedRelationServiceServer) ReadTuples(context.Context, *ReadTuplesRequest) (*ReadTuplesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadTuples not implemented")
+}
+func (UnimplementedRelationServiceServer) Check(context.Context, *CheckRelationRequest) (*CheckRelationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedRelationServiceServer) Expand(context.Context, *ExpandRequest) (*ExpandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Expand not implemented")
+}
+func (UnimplementedRelationServiceServer) mustEmbedUnimplementedRelationServiceServer() {}
+
+// UnsafeRelationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RelationServiceServer will
+// result in compilation errors.
+type UnsafeRelationServiceServer interface {
+	mustEmbedUnimplementedRelationServiceServer()
+}
+
+func RegisterRelationServiceServer(s grpc.ServiceRegistrar, srv RelationServiceServer) {
+	s.RegisterService(&RelationService_ServiceDesc, srv)
+}
+
+func _RelationService_WriteTuples_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteTuplesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).WriteTuples(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.RelationService/WriteTuples",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).WriteTuples(ctx, req.(*WriteTuplesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_ReadTuples_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadTuplesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).ReadTuples(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.RelationService/ReadTuples",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).ReadTuples(ctx, req.(*ReadTuplesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRelationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.RelationService/Check",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).Check(ctx, req.(*CheckRelationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_Expand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).Expand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/users.RelationService/Expand",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).Expand(ctx, req.(*ExpandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RelationService_ServiceDesc is the grpc.ServiceDesc for RelationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RelationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.RelationService",
+	HandlerType: (*RelationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "WriteTuples",
+			Handler:    _RelationService_WriteTuples_Handler,
+		},
+		{
+			MethodName: "ReadTuples",
+			Handler:    _RelationService_ReadTuples_Handler,
+		},
+		{
+			MethodName: "Check",
+			Handler:    _RelationService_Check_Handler,
+		},
+		{
+			MethodName: "Expand",
+			Handler:    _RelationService_Expand_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/relation.proto",
+}
@@ -10,9 +10,46 @@ import (
 
 var (
 	_ Validatable = (*CreateUserRequest)(nil)
+	_ Validatable = (*GetAllUsersRequest)(nil)
 	_ Validatable = (*GetUserRequest)(nil)
 	_ Validatable = (*UpdateUserRequest)(nil)
 	_ Validatable = (*DeleteUserRequest)(nil)
+	_ Validatable = (*UpdateMeRequest)(nil)
+	_ Validatable = (*ChangePasswordRequest)(nil)
+	_ Validatable = (*LoginRequest)(nil)
+	_ Validatable = (*RefreshRequest)(nil)
+	_ Validatable = (*LogoutRequest)(nil)
+	_ Validatable = (*CreateApiKeyRequest)(nil)
+	_ Validatable = (*ListApiKeysRequest)(nil)
+	_ Validatable = (*RevokeApiKeyRequest)(nil)
+	_ Validatable = (*CreateRoleRequest)(nil)
+	_ Validatable = (*AssignRoleRequest)(nil)
+	_ Validatable = (*UnassignRoleRequest)(nil)
+	_ Validatable = (*ListPermissionsRequest)(nil)
+	_ Validatable = (*CheckRequest)(nil)
+	_ Validatable = (*BatchCheckRequest)(nil)
+	_ Validatable = (*WriteTuplesRequest)(nil)
+	_ Validatable = (*ReadTuplesRequest)(nil)
+	_ Validatable = (*CheckRelationRequest)(nil)
+	_ Validatable = (*ExpandRequest)(nil)
+	_ Validatable = (*CreateGroupRequest)(nil)
+	_ Validatable = (*GetGroupRequest)(nil)
+	_ Validatable = (*DeleteGroupRequest)(nil)
+	_ Validatable = (*AddGroupMemberRequest)(nil)
+	_ Validatable = (*RemoveGroupMemberRequest)(nil)
+	_ Validatable = (*AssignGroupRoleRequest)(nil)
+	_ Validatable = (*UnassignGroupRoleRequest)(nil)
+	_ Validatable = (*CreateOrganizationRequest)(nil)
+	_ Validatable = (*RegisterRequest)(nil)
+	_ Validatable = (*VerifyEmailRequest)(nil)
+	_ Validatable = (*RequestPasswordResetRequest)(nil)
+	_ Validatable = (*ResetPasswordRequest)(nil)
+	_ Validatable = (*ClearLockoutRequest)(nil)
+)
+
+const (
+	maxBatchChecks  = 100
+	maxTupleChanges = 100
 )
 
 type Validatable interface {
@@ -27,6 +64,14 @@ func (m *CreateUserRequest) Validate() error {
 	return nil
 }
 
+func (m *GetAllUsersRequest) Validate() error {
+	if m.PageSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "Page size must not be negative")
+	}
+
+	return nil
+}
+
 func (m *GetUserRequest) Validate() error {
 	if _, err := uuid.Parse(m.Id); err != nil {
 		return status.Errorf(codes.InvalidArgument, "ID is invalid")
@@ -54,3 +99,311 @@ func (m *DeleteUserRequest) Validate() error {
 
 	return nil
 }
+
+func (m *UpdateMeRequest) Validate() error {
+	if m.Username == "" {
+		return status.Errorf(codes.InvalidArgument, "Username is required")
+	}
+
+	if _, err := mail.ParseAddress(m.Email); err != nil {
+		return status.Errorf(codes.InvalidArgument, "E-mail is invalid")
+	}
+
+	return nil
+}
+
+func (m *ChangePasswordRequest) Validate() error {
+	if m.CurrentPassword == "" || m.NewPassword == "" {
+		return status.Errorf(codes.InvalidArgument, "Current and new passwords are required")
+	}
+
+	return nil
+}
+
+func (m *LoginRequest) Validate() error {
+	if m.Username == "" || m.Password == "" {
+		return status.Errorf(codes.InvalidArgument, "Username and password are required")
+	}
+
+	return nil
+}
+
+func (m *RefreshRequest) Validate() error {
+	if m.RefreshToken == "" {
+		return status.Errorf(codes.InvalidArgument, "Refresh token is required")
+	}
+
+	return nil
+}
+
+func (m *LogoutRequest) Validate() error {
+	if m.RefreshToken == "" {
+		return status.Errorf(codes.InvalidArgument, "Refresh token is required")
+	}
+
+	return nil
+}
+
+func (m *CreateApiKeyRequest) Validate() error {
+	if err := validateOptionalID(m.UserId, "User ID"); err != nil {
+		return err
+	}
+
+	if m.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "Name is required")
+	}
+
+	if m.ExpireTime != nil {
+		if err := m.ExpireTime.CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Expire time is invalid")
+		}
+	}
+
+	return nil
+}
+
+func (m *ListApiKeysRequest) Validate() error {
+	return validateOptionalID(m.UserId, "User ID")
+}
+
+func (m *RevokeApiKeyRequest) Validate() error {
+	if err := validateOptionalID(m.UserId, "User ID"); err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(m.Id); err != nil {
+		return status.Errorf(codes.InvalidArgument, "ID is invalid")
+	}
+
+	return nil
+}
+
+func (m *CreateRoleRequest) Validate() error {
+	if m.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "Name is required")
+	}
+
+	return nil
+}
+
+func (m *AssignRoleRequest) Validate() error {
+	return validateRoleAssignment(m.UserId, m.Role)
+}
+
+func (m *UnassignRoleRequest) Validate() error {
+	return validateRoleAssignment(m.UserId, m.Role)
+}
+
+func (m *ListPermissionsRequest) Validate() error {
+	return validateOptionalID(m.UserId, "User ID")
+}
+
+func (m *CheckRequest) Validate() error {
+	if err := validateOptionalID(m.Subject, "Subject"); err != nil {
+		return err
+	}
+
+	if m.Action == "" {
+		return status.Errorf(codes.InvalidArgument, "Action is required")
+	}
+
+	return nil
+}
+
+func (m *BatchCheckRequest) Validate() error {
+	if len(m.Checks) == 0 || len(m.Checks) > maxBatchChecks {
+		return status.Errorf(codes.InvalidArgument, "Checks must contain from 1 to %d checks", maxBatchChecks)
+	}
+
+	for i, check := range m.Checks {
+		if err := check.Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Check %d: %s", i, status.Convert(err).Message())
+		}
+	}
+
+	return nil
+}
+
+func (m *WriteTuplesRequest) Validate() error {
+	if changes := len(m.Writes) + len(m.Deletes); changes == 0 || changes > maxTupleChanges {
+		return status.Errorf(codes.InvalidArgument, "Writes and deletes must contain from 1 to %d tuples", maxTupleChanges)
+	}
+
+	for _, tuples := range [][]*RelationTuple{m.Writes, m.Deletes} {
+		for _, tuple := range tuples {
+			if tuple.Object == "" || tuple.Relation == "" || tuple.Subject == "" {
+				return status.Errorf(codes.InvalidArgument, "Object, relation and subject of tuples are required")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *ReadTuplesRequest) Validate() error {
+	if m.Namespace == "" {
+		return status.Errorf(codes.InvalidArgument, "Namespace is required")
+	}
+
+	return nil
+}
+
+func (m *CheckRelationRequest) Validate() error {
+	if m.Object == "" || m.Relation == "" || m.Subject == "" {
+		return status.Errorf(codes.InvalidArgument, "Object, relation and subject are required")
+	}
+
+	return nil
+}
+
+func (m *ExpandRequest) Validate() error {
+	if m.Object == "" || m.Relation == "" {
+		return status.Errorf(codes.InvalidArgument, "Object and relation are required")
+	}
+
+	return nil
+}
+
+func (m *CreateGroupRequest) Validate() error {
+	if m.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "Name is required")
+	}
+
+	return nil
+}
+
+func (m *GetGroupRequest) Validate() error {
+	if _, err := uuid.Parse(m.Id); err != nil {
+		return status.Errorf(codes.InvalidArgument, "ID is invalid")
+	}
+
+	return nil
+}
+
+func (m *DeleteGroupRequest) Validate() error {
+	if _, err := uuid.Parse(m.Id); err != nil {
+		return status.Errorf(codes.InvalidArgument, "ID is invalid")
+	}
+
+	return nil
+}
+
+func (m *AddGroupMemberRequest) Validate() error {
+	return validateGroupMember(m.GroupId, m.UserId, m.MemberGroupId)
+}
+
+func (m *RemoveGroupMemberRequest) Validate() error {
+	return validateGroupMember(m.GroupId, m.UserId, m.MemberGroupId)
+}
+
+func (m *AssignGroupRoleRequest) Validate() error {
+	return validateGroupRole(m.GroupId, m.Role)
+}
+
+func (m *UnassignGroupRoleRequest) Validate() error {
+	return validateGroupRole(m.GroupId, m.Role)
+}
+
+func (m *CreateOrganizationRequest) Validate() error {
+	if m.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "Name is required")
+	}
+
+	return nil
+}
+
+func (m *RegisterRequest) Validate() error {
+	if m.Username == "" || m.Password == "" {
+		return status.Errorf(codes.InvalidArgument, "Username and password are required")
+	}
+
+	if _, err := mail.ParseAddress(m.Email); err != nil {
+		return status.Errorf(codes.InvalidArgument, "E-mail is invalid")
+	}
+
+	return nil
+}
+
+func (m *VerifyEmailRequest) Validate() error {
+	if m.Token == "" {
+		return status.Errorf(codes.InvalidArgument, "Token is required")
+	}
+
+	return nil
+}
+
+func (m *RequestPasswordResetRequest) Validate() error {
+	if m.Username == "" {
+		return status.Errorf(codes.InvalidArgument, "Username is required")
+	}
+
+	return nil
+}
+
+func (m *ResetPasswordRequest) Validate() error {
+	if m.Token == "" || m.NewPassword == "" {
+		return status.Errorf(codes.InvalidArgument, "Token and new password are required")
+	}
+
+	return nil
+}
+
+func (m *ClearLockoutRequest) Validate() error {
+	if m.Kind == "" || m.Subject == "" {
+		return status.Errorf(codes.InvalidArgument, "Kind and subject are required")
+	}
+
+	return nil
+}
+
+func validateGroupMember(groupID string, userID string, memberGroupID string) error {
+	if _, err := uuid.Parse(groupID); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Group ID is invalid")
+	}
+
+	if (userID == "") == (memberGroupID == "") {
+		return status.Errorf(codes.InvalidArgument, "Exactly one of user ID and member group ID is required")
+	}
+
+	if err := validateOptionalID(userID, "User ID"); err != nil {
+		return err
+	}
+
+	return validateOptionalID(memberGroupID, "Member group ID")
+}
+
+func validateGroupRole(groupID string, role string) error {
+	if _, err := uuid.Parse(groupID); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Group ID is invalid")
+	}
+
+	if role == "" {
+		return status.Errorf(codes.InvalidArgument, "Role is required")
+	}
+
+	return nil
+}
+
+func validateRoleAssignment(userID string, role string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return status.Errorf(codes.InvalidArgument, "User ID is invalid")
+	}
+
+	if role == "" {
+		return status.Errorf(codes.InvalidArgument, "Role is required")
+	}
+
+	return nil
+}
+
+func validateOptionalID(id string, name string) error {
+	if id == "" {
+		return nil
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s is invalid", name)
+	}
+
+	return nil
+}
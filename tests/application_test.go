@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -22,7 +23,7 @@ func TestUnauthorized(t *testing.T) {
 		{
 			name: "GetAllUsers",
 			invoke: func(ctx context.Context, client proto.UserServiceClient) (any, error) {
-				return client.GetAllUsers(ctx, empty)
+				return client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
 			},
 		},
 		{
@@ -84,7 +85,7 @@ func TestAdminWorkflow(t *testing.T) {
 	client, closeConnection := NewClient(t, WithUnsecure(), WithBasicAuth(adminUsername, adminPassword))
 	defer closeConnection()
 
-	getAllUsersResponse, err := client.GetAllUsers(ctx, empty)
+	getAllUsersResponse, err := client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
 	assert.NoError(t, err)
 	assert.NotEmpty(t, getAllUsersResponse.Users)
 
@@ -121,6 +122,9 @@ func TestAdminWorkflow(t *testing.T) {
 	assert.Equal(t, user.username, getUserByIDResponse.User.Username)
 	assert.Equal(t, user.admin, getUserByIDResponse.User.Admin)
 
+	assert.NotEmpty(t, getUserByIDResponse.User.Etag)
+
+	staleEtag := getUserByIDResponse.User.Etag
 	newUsername := "newUsername"
 
 	updateUserResponse, err := client.UpdateUser(ctx, &proto.UpdateUserRequest{
@@ -129,6 +133,7 @@ func TestAdminWorkflow(t *testing.T) {
 		Username: newUsername,
 		Password: user.password,
 		Admin:    user.admin,
+		Etag:     staleEtag,
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, empty, updateUserResponse)
@@ -138,9 +143,18 @@ func TestAdminWorkflow(t *testing.T) {
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, newUsername, getUserByIDResponse.User.Username)
+	assert.NotEqual(t, staleEtag, getUserByIDResponse.User.Etag)
 
 	deleteUserResponse, err := client.DeleteUser(ctx, &proto.DeleteUserRequest{
-		Id: createdUserID,
+		Id:   createdUserID,
+		Etag: staleEtag,
+	})
+	assert.Nil(t, deleteUserResponse)
+	AssertErrorCode(t, codes.Aborted, err)
+
+	deleteUserResponse, err = client.DeleteUser(ctx, &proto.DeleteUserRequest{
+		Id:   createdUserID,
+		Etag: getUserByIDResponse.User.Etag,
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, empty, deleteUserResponse)
@@ -169,11 +183,320 @@ func TestUserWorkflow(t *testing.T) {
 	client, closeConnection := NewClient(t, WithUnsecure(), WithBasicAuth(username, password))
 	defer closeConnection()
 
-	response, err := client.GetAllUsers(ctx, empty)
+	response, err := client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
 	assert.NoError(t, err)
 	assert.NotEmpty(t, response.Users)
 }
 
+func TestGetAllUsersPagination(t *testing.T) {
+	t.Parallel()
+
+	const (
+		adminUsername = "admin"
+		adminPassword = "admin"
+	)
+
+	usernames := []string{"page_c", "page_a", "page_b"}
+	for _, username := range usernames {
+		createUser(t, username+"@pagination.test", username, "password")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client, closeConnection := NewClient(t, WithUnsecure(), WithBasicAuth(adminUsername, adminPassword))
+	defer closeConnection()
+
+	request := &proto.GetAllUsersRequest{
+		PageSize: 2,
+		OrderBy:  "username desc",
+		Filter:   `email:"@pagination.test"`,
+	}
+
+	firstPage, err := client.GetAllUsers(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, firstPage.Users, 2)
+	assert.Equal(t, "page_c", firstPage.Users[0].Username)
+	assert.Equal(t, "page_b", firstPage.Users[1].Username)
+	require.NotEmpty(t, firstPage.NextPageToken)
+
+	request.PageToken = firstPage.NextPageToken
+
+	secondPage, err := client.GetAllUsers(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, secondPage.Users, 1)
+	assert.Equal(t, "page_a", secondPage.Users[0].Username)
+	assert.Empty(t, secondPage.NextPageToken)
+
+	request.Filter = "admin = true"
+
+	_, err = client.GetAllUsers(ctx, request)
+	AssertErrorCode(t, codes.InvalidArgument, err)
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	const (
+		email    = "login@email.com"
+		username = "login"
+		password = "login"
+	)
+
+	createUser(t, email, username, password)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	authClient, closeAuthConnection := NewAuthClient(t, WithUnsecure())
+	defer closeAuthConnection()
+
+	_, err := authClient.Login(ctx, &proto.LoginRequest{
+		Username: username,
+		Password: "wrong password",
+	})
+	AssertErrorCode(t, codes.Unauthenticated, err)
+
+	loginResponse, err := authClient.Login(ctx, &proto.LoginRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", loginResponse.TokenType)
+	assert.Positive(t, loginResponse.ExpiresIn)
+
+	client, closeConnection := NewClient(t, WithUnsecure(), WithBearerToken(loginResponse.AccessToken))
+	defer closeConnection()
+
+	response, err := client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Users)
+
+	forgedClient, closeForgedConnection := NewClient(t, WithUnsecure(), WithBearerToken(loginResponse.AccessToken+"x"))
+	defer closeForgedConnection()
+
+	_, err = forgedClient.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+	AssertErrorCode(t, codes.Unauthenticated, err)
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	const (
+		email    = "refresh@email.com"
+		username = "refresh"
+		password = "refresh"
+	)
+
+	createUser(t, email, username, password)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	authClient, closeAuthConnection := NewAuthClient(t, WithUnsecure())
+	defer closeAuthConnection()
+
+	loginResponse, err := authClient.Login(ctx, &proto.LoginRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, loginResponse.RefreshToken)
+
+	refreshResponse, err := authClient.Refresh(ctx, &proto.RefreshRequest{
+		RefreshToken: loginResponse.RefreshToken,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, loginResponse.RefreshToken, refreshResponse.RefreshToken)
+
+	client, closeConnection := NewClient(t, WithUnsecure(), WithBearerToken(refreshResponse.AccessToken))
+	defer closeConnection()
+
+	_, err = client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+	assert.NoError(t, err)
+
+	// Reusing the rotated token revokes the refresh token issued in exchange for it.
+	_, err = authClient.Refresh(ctx, &proto.RefreshRequest{
+		RefreshToken: loginResponse.RefreshToken,
+	})
+	AssertErrorCode(t, codes.Unauthenticated, err)
+
+	_, err = authClient.Refresh(ctx, &proto.RefreshRequest{
+		RefreshToken: refreshResponse.RefreshToken,
+	})
+	AssertErrorCode(t, codes.Unauthenticated, err)
+
+	loginResponse, err = authClient.Login(ctx, &proto.LoginRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	_, err = authClient.Logout(ctx, &proto.LogoutRequest{
+		RefreshToken: loginResponse.RefreshToken,
+	})
+	require.NoError(t, err)
+
+	_, err = authClient.Refresh(ctx, &proto.RefreshRequest{
+		RefreshToken: loginResponse.RefreshToken,
+	})
+	AssertErrorCode(t, codes.Unauthenticated, err)
+}
+
+func TestAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	const (
+		email    = "apikey@email.com"
+		username = "apikey"
+		password = "apikey"
+	)
+
+	createUser(t, email, username, password)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	apiKeyClient, closeAPIKeyConnection := NewAPIKeyClient(t, WithUnsecure(), WithBasicAuth(username, password))
+	defer closeAPIKeyConnection()
+
+	createResponse, err := apiKeyClient.CreateApiKey(ctx, &proto.CreateApiKeyRequest{
+		UserId:     "",
+		Name:       "ci",
+		Scopes:     []string{"/users.UserService/GetAllUsers"},
+		ExpireTime: nil,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, createResponse.Key)
+
+	client, closeConnection := NewClient(t, WithUnsecure(), WithAPIKey(createResponse.Key))
+	defer closeConnection()
+
+	_, err = client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+	assert.NoError(t, err)
+
+	_, err = client.GetUserByID(ctx, &proto.GetUserRequest{Id: createResponse.ApiKey.UserId})
+	AssertErrorCode(t, codes.PermissionDenied, err)
+
+	listResponse, err := apiKeyClient.ListApiKeys(ctx, new(proto.ListApiKeysRequest))
+	require.NoError(t, err)
+	require.Len(t, listResponse.ApiKeys, 1)
+	assert.Equal(t, createResponse.ApiKey.Id, listResponse.ApiKeys[0].Id)
+
+	_, err = apiKeyClient.ListApiKeys(ctx, &proto.ListApiKeysRequest{UserId: "00000000-0000-0000-0000-000000000000"})
+	AssertErrorCode(t, codes.PermissionDenied, err)
+
+	_, err = apiKeyClient.RevokeApiKey(ctx, &proto.RevokeApiKeyRequest{
+		UserId: "",
+		Id:     createResponse.ApiKey.Id,
+	})
+	require.NoError(t, err)
+
+	_, err = client.GetAllUsers(ctx, new(proto.GetAllUsersRequest))
+	AssertErrorCode(t, codes.Unauthenticated, err)
+}
+
+func TestRoles(t *testing.T) {
+	t.Parallel()
+
+	const (
+		email    = "roles@email.com"
+		username = "roles"
+		password = "roles"
+	)
+
+	createUser(t, email, username, password)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	admin, closeAdminConnection := NewClient(t, WithUnsecure(), WithBasicAuth("admin", "admin"))
+	defer closeAdminConnection()
+
+	adminRoles, closeAdminRoleConnection := NewRoleClient(t, WithUnsecure(), WithBasicAuth("admin", "admin"))
+	defer closeAdminRoleConnection()
+
+	listResponse, err := admin.GetAllUsers(ctx, &proto.GetAllUsersRequest{Filter: fmt.Sprintf("username = %q", username)})
+	require.NoError(t, err)
+	require.Len(t, listResponse.Users, 1)
+
+	userID := listResponse.Users[0].Id
+
+	client, closeConnection := NewClient(t, WithUnsecure(), WithBasicAuth(username, password))
+	defer closeConnection()
+
+	roles, closeRoleConnection := NewRoleClient(t, WithUnsecure(), WithBasicAuth(username, password))
+	defer closeRoleConnection()
+
+	_, err = client.GetUserByID(ctx, &proto.GetUserRequest{Id: userID})
+	AssertErrorCode(t, codes.PermissionDenied, err)
+
+	_, err = roles.CreateRole(ctx, &proto.CreateRoleRequest{Name: "escalation", Permissions: []string{"roles.manage"}})
+	AssertErrorCode(t, codes.PermissionDenied, err)
+
+	_, err = adminRoles.CreateRole(ctx, &proto.CreateRoleRequest{Name: "reader", Permissions: []string{"users.read"}})
+	require.NoError(t, err)
+
+	_, err = adminRoles.AssignRole(ctx, &proto.AssignRoleRequest{UserId: userID, Role: "reader"})
+	require.NoError(t, err)
+
+	permissionsResponse, err := roles.ListPermissions(ctx, new(proto.ListPermissionsRequest))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users.read"}, permissionsResponse.Permissions)
+
+	getUserResponse, err := client.GetUserByID(ctx, &proto.GetUserRequest{Id: userID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reader"}, getUserResponse.User.Roles)
+	assert.False(t, getUserResponse.User.Admin)
+
+	_, err = client.DeleteUser(ctx, &proto.DeleteUserRequest{Id: userID})
+	AssertErrorCode(t, codes.PermissionDenied, err)
+}
+
+func TestAuthorization(t *testing.T) {
+	t.Parallel()
+
+	const (
+		email    = "authorization@email.com"
+		username = "authorization"
+		password = "authorization"
+	)
+
+	createUser(t, email, username, password)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	admin, closeAdminConnection := NewAuthorizationClient(t, WithUnsecure(), WithBasicAuth("admin", "admin"))
+	defer closeAdminConnection()
+
+	client, closeConnection := NewAuthorizationClient(t, WithUnsecure(), WithBasicAuth(username, password))
+	defer closeConnection()
+
+	response, err := client.Check(ctx, &proto.CheckRequest{Action: "users.delete"})
+	require.NoError(t, err)
+	assert.False(t, response.Allowed)
+	assert.NotEmpty(t, response.Reason)
+
+	_, err = client.Check(ctx, &proto.CheckRequest{Action: "users.unknown"})
+	AssertErrorCode(t, codes.InvalidArgument, err)
+
+	adminResponse, err := admin.Check(ctx, &proto.CheckRequest{Action: "users.delete"})
+	require.NoError(t, err)
+	assert.True(t, adminResponse.Allowed)
+
+	batchResponse, err := admin.BatchCheck(ctx, &proto.BatchCheckRequest{
+		Checks: []*proto.CheckRequest{
+			{Action: "users.read"},
+			{Subject: "00000000-0000-0000-0000-000000000000", Action: "users.read"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, batchResponse.Results, 2)
+	assert.True(t, batchResponse.Results[0].Allowed)
+	assert.False(t, batchResponse.Results[1].Allowed)
+}
+
 func createUser(t *testing.T, email, username, password string) {
 	t.Helper()
 
@@ -34,6 +34,58 @@ func NewClient(t *testing.T, opts ...grpc.DialOption) (proto.UserServiceClient,
 	return proto.NewUserServiceClient(conn), closeConnectionFn
 }
 
+func NewAuthClient(t *testing.T, opts ...grpc.DialOption) (proto.AuthServiceClient, CloseFn) { //nolint:ireturn
+	t.Helper()
+
+	conn, err := grpc.Dial(appURL, opts...)
+	require.NoError(t, err)
+
+	closeConnectionFn := func() {
+		require.NoError(t, conn.Close())
+	}
+
+	return proto.NewAuthServiceClient(conn), closeConnectionFn
+}
+
+func NewAPIKeyClient(t *testing.T, opts ...grpc.DialOption) (proto.ApiKeyServiceClient, CloseFn) { //nolint:ireturn
+	t.Helper()
+
+	conn, err := grpc.Dial(appURL, opts...)
+	require.NoError(t, err)
+
+	closeConnectionFn := func() {
+		require.NoError(t, conn.Close())
+	}
+
+	return proto.NewApiKeyServiceClient(conn), closeConnectionFn
+}
+
+func NewRoleClient(t *testing.T, opts ...grpc.DialOption) (proto.RoleServiceClient, CloseFn) { //nolint:ireturn
+	t.Helper()
+
+	conn, err := grpc.Dial(appURL, opts...)
+	require.NoError(t, err)
+
+	closeConnectionFn := func() {
+		require.NoError(t, conn.Close())
+	}
+
+	return proto.NewRoleServiceClient(conn), closeConnectionFn
+}
+
+func NewAuthorizationClient(t *testing.T, opts ...grpc.DialOption) (proto.AuthorizationServiceClient, CloseFn) { //nolint:ireturn
+	t.Helper()
+
+	conn, err := grpc.Dial(appURL, opts...)
+	require.NoError(t, err)
+
+	closeConnectionFn := func() {
+		require.NoError(t, conn.Close())
+	}
+
+	return proto.NewAuthorizationServiceClient(conn), closeConnectionFn
+}
+
 func WithUnsecure() grpc.DialOption { //nolint:ireturn
 	return grpc.WithTransportCredentials(insecure.NewCredentials())
 }
@@ -63,6 +115,47 @@ func (b *basicAuth) RequireTransportSecurity() bool {
 	return false
 }
 
+func WithBearerToken(token string) grpc.DialOption { //nolint:ireturn
+	return grpc.WithPerRPCCredentials(&bearerAuth{token})
+}
+
+type bearerAuth struct {
+	token string
+}
+
+func (b *bearerAuth) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	const AuthorizationHeaderKey = "authorization"
+	const AuthorizationScheme = "Bearer"
+
+	return map[string]string{
+		AuthorizationHeaderKey: fmt.Sprintf("%s %s", AuthorizationScheme, b.token),
+	}, nil
+}
+
+func (b *bearerAuth) RequireTransportSecurity() bool {
+	return false
+}
+
+func WithAPIKey(key string) grpc.DialOption { //nolint:ireturn
+	return grpc.WithPerRPCCredentials(&apiKeyAuth{key})
+}
+
+type apiKeyAuth struct {
+	key string
+}
+
+func (a *apiKeyAuth) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	const APIKeyHeaderKey = "x-api-key"
+
+	return map[string]string{
+		APIKeyHeaderKey: a.key,
+	}, nil
+}
+
+func (a *apiKeyAuth) RequireTransportSecurity() bool {
+	return false
+}
+
 func AssertErrorCode(t *testing.T, expected codes.Code, err error) {
 	t.Helper()
 